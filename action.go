@@ -0,0 +1,122 @@
+package grules
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// Action is something a Dispatcher does with the Result of a top level
+// composite that is true, such as sending a Webhook. Return a Permanent
+// error when trying again can not succeed
+type Action interface {
+	Execute(ctx context.Context, r Result) error
+}
+
+// ActionFunc is a function that is an Action
+type ActionFunc func(ctx context.Context, r Result) error
+
+// Execute will call the function
+func (f ActionFunc) Execute(ctx context.Context, r Result) error {
+	return f(ctx, r)
+}
+
+// ErrorPolicy is what a Dispatcher does when an action returns an
+// error
+type ErrorPolicy int
+
+const (
+	// PolicyRetry will retry the action with backoff, unless the error
+	// is Permanent, and report it to OnError after the last retry. It
+	// is the policy of actions that do not have one
+	PolicyRetry ErrorPolicy = iota
+	// PolicyReport will report the error to OnError without retrying
+	PolicyReport
+	// PolicyIgnore will drop the error
+	PolicyIgnore
+)
+
+// policyAction is an action with an error policy
+type policyAction struct {
+	Action
+	policy ErrorPolicy
+}
+
+// WithPolicy will return the action with an error policy
+func WithPolicy(a Action, policy ErrorPolicy) Action {
+	return policyAction{Action: a, policy: policy}
+}
+
+// policyOf will return the error policy of the action
+func policyOf(a Action) ErrorPolicy {
+	if pa, ok := a.(policyAction); ok {
+		return pa.policy
+	}
+	return PolicyRetry
+}
+
+// permanentError is an error that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent will wrap an error to tell a Dispatcher not to retry the
+// action that returned it
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// isPermanent will return true if the error was wrapped by Permanent
+func isPermanent(err error) bool {
+	var pe permanentError
+	return errors.As(err, &pe)
+}
+
+// LogAction will return an action that logs the ID of the composite,
+// to the standard logger if logger is nil
+func LogAction(logger *log.Logger) Action {
+	return ActionFunc(func(ctx context.Context, r Result) error {
+		if logger == nil {
+			log.Printf("grules: composite %q is true", r.Composite.ID)
+		} else {
+			logger.Printf("grules: composite %q is true", r.Composite.ID)
+		}
+		return nil
+	})
+}
+
+// Counter is a set of metrics that can be incremented by key, such as
+// an *expvar.Map
+type Counter interface {
+	Add(key string, delta int64)
+}
+
+// MetricAction will return an action that increments the counter for
+// the ID of the composite
+func MetricAction(c Counter) Action {
+	return ActionFunc(func(ctx context.Context, r Result) error {
+		c.Add(r.Composite.ID, 1)
+		return nil
+	})
+}
+
+// ChannelAction will return an action that sends the Result on the
+// channel, waiting while it is full until the context is done
+func ChannelAction(ch chan<- Result) Action {
+	return ActionFunc(func(ctx context.Context, r Result) error {
+		select {
+		case ch <- r:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package grules
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestActions(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{ID: "adult", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "gte", Path: "age", Value: float64(18)}}},
+		Composite{ID: "senior", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "gte", Path: "age", Value: float64(65)}}},
+	}
+
+	var logs bytes.Buffer
+	metrics := new(expvar.Map).Init()
+	results := make(chan Result, 2)
+	d := NewDispatcher(DispatcherConfig{Actions: map[string][]Action{
+		"adult":  []Action{LogAction(log.New(&logs, "", 0)), MetricAction(metrics), ChannelAction(results)},
+		"senior": []Action{MetricAction(metrics)},
+	}})
+	d.Dispatch(e, map[string]interface{}{"age": float64(30)})
+	d.Dispatch(e, map[string]interface{}{"age": float64(40)})
+	d.Close()
+
+	if n := strings.Count(logs.String(), `grules: composite "adult" is true`); n != 2 {
+		t.Fatalf("expected adult to be logged twice, got %q", logs.String())
+	}
+	if v := metrics.Get("adult"); v == nil || v.String() != "2" {
+		t.Fatalf("expected adult to be counted twice, got %v", v)
+	}
+	if v := metrics.Get("senior"); v != nil {
+		t.Fatalf("expected senior to not be counted, got %v", v)
+	}
+	if r := <-results; r.Composite.ID != "adult" || r.Props["age"] != float64(30) {
+		t.Fatalf("expected the first result on the channel, got %+v", r)
+	}
+}
+
+func BenchmarkActions(b *testing.B) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{ID: "adult", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "gte", Path: "age", Value: float64(18)}}},
+	}
+	d := NewDispatcher(DispatcherConfig{Actions: map[string][]Action{
+		"adult": []Action{MetricAction(new(expvar.Map).Init())},
+	}})
+	props := map[string]interface{}{"age": float64(30)}
+	for i := 0; i < b.N; i++ {
+		d.Dispatch(e, props)
+	}
+	d.Close()
+}
+
+func TestErrorPolicy(t *testing.T) {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	failing := func(name string, err error) Action {
+		return ActionFunc(func(ctx context.Context, r Result) error {
+			mu.Lock()
+			attempts[name]++
+			mu.Unlock()
+			return err
+		})
+	}
+	errFailed := errors.New("failed")
+
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{ID: "a", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "exists", Path: "id"}}},
+	}
+	reported := []error{}
+	d := NewDispatcher(DispatcherConfig{
+		Retries: 2,
+		Backoff: time.Millisecond,
+		Actions: map[string][]Action{"a": []Action{
+			failing("retry", errFailed),
+			failing("permanent", Permanent(errFailed)),
+			WithPolicy(failing("report", errFailed), PolicyReport),
+			WithPolicy(failing("ignore", errFailed), PolicyIgnore),
+		}},
+		OnError: func(a Action, r Result, err error) {
+			reported = append(reported, err)
+		},
+	})
+	d.Dispatch(e, map[string]interface{}{"id": "1"})
+	d.Close()
+
+	expected := map[string]int{"retry": 3, "permanent": 1, "report": 1, "ignore": 1}
+	for name, n := range expected {
+		if attempts[name] != n {
+			t.Fatalf("expected %s to be run %d times, got %d", name, n, attempts[name])
+		}
+	}
+	if len(reported) != 3 {
+		t.Fatalf("expected 3 errors to be reported, got %v", reported)
+	}
+	for _, err := range reported {
+		if !errors.Is(err, errFailed) {
+			t.Fatalf("expected the action's error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package grules
+
+import (
+	"reflect"
+	"strings"
+)
+
+// aggregateFunc is a function that reduces an array to a single number, ok
+// should be false if the array can not be reduced
+type aggregateFunc func(values []float64) (float64, bool)
+
+// aggregate will create a comparator that reduces the numeric array a
+// with fn, then compares the result to b with comp. It is false if a
+// is not an array of numbers
+func aggregate(fn aggregateFunc, comp Comparator) Comparator {
+	return func(a, b interface{}) bool {
+		values, ok := numbers(a)
+		if !ok {
+			return false
+		}
+		v, ok := fn(values)
+		return ok && comp(v, b)
+	}
+}
+
+// count will create a comparator that compares the number of elements
+// in the array a, of any type, to b with comp
+func count(comp Comparator) Comparator {
+	return func(a, b interface{}) bool {
+		v := reflect.ValueOf(a)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return false
+		}
+		return comp(float64(v.Len()), b)
+	}
+}
+
+// numbers will return the elements of the array a as numbers, ok is
+// false if a is not an array or any element is not a number
+func numbers(a interface{}) ([]float64, bool) {
+	if values, ok := a.([]float64); ok {
+		return values, true
+	}
+	v := reflect.ValueOf(a)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	values := make([]float64, v.Len())
+	for i := range values {
+		f, ok := toFloat64(v.Index(i).Interface())
+		if !ok {
+			return nil, false
+		}
+		values[i] = f
+	}
+	return values, true
+}
+
+// sum will return the total of the values, which is 0 for no values
+func sum(values []float64) (float64, bool) {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total, true
+}
+
+// avg will return the mean of the values, ok is false for no values
+func avg(values []float64) (float64, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+	total, _ := sum(values)
+	return total / float64(len(values)), true
+}
+
+// minimum will return the smallest of the values, ok is false for no
+// values
+func minimum(values []float64) (float64, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m, true
+}
+
+// maximum will return the largest of the values, ok is false for no
+// values
+func maximum(values []float64) (float64, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+	m := values[0]
+	for _, v := range values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m, true
+}
+
+// aggregatePrefixes is a list of the prefixes of the aggregate
+// comparators
+var aggregatePrefixes = []string{"sum-", "avg-", "min-", "max-", "count-"}
+
+// isAggregate will return true if the comparator aggregates an array,
+// in which case a wildcard path gives it every value at the path
+// instead of one at a time
+func isAggregate(comparator string) bool {
+	for _, prefix := range aggregatePrefixes {
+		if strings.HasPrefix(comparator, prefix) {
+			return true
+		}
+	}
+	return false
+}
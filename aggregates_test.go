@@ -0,0 +1,56 @@
+package grules
+
+import (
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	prices := []interface{}{float64(20), float64(50), float64(40)}
+	cases := []struct {
+		comparator string
+		a, b       interface{}
+		expected   bool
+	}{
+		{comparator: "sum-gt", a: prices, b: float64(100), expected: true},
+		{comparator: "sum-gt", a: prices, b: float64(110), expected: false},
+		{comparator: "sum-eq", a: prices, b: float64(110), expected: true},
+		{comparator: "sum-eq", a: []interface{}{}, b: float64(0), expected: true},
+		{comparator: "avg-lt", a: prices, b: float64(40), expected: true},
+		{comparator: "avg-gte", a: []interface{}{}, b: float64(0), expected: false},
+		{comparator: "min-gte", a: prices, b: float64(20), expected: true},
+		{comparator: "min-gt", a: prices, b: float64(20), expected: false},
+		{comparator: "max-lte", a: prices, b: float64(50), expected: true},
+		{comparator: "max-lt", a: []float64{1, 2, 3}, b: float64(3), expected: false},
+		{comparator: "max-neq", a: []int{1, 2, 3}, b: float64(4), expected: true},
+		{comparator: "count-eq", a: prices, b: float64(3), expected: true},
+		{comparator: "count-eq", a: []interface{}{"a", "b"}, b: float64(2), expected: true},
+		{comparator: "count-lte", a: []interface{}{}, b: float64(0), expected: true},
+		{comparator: "sum-gt", a: []interface{}{float64(1), "2"}, b: float64(0), expected: false},
+		{comparator: "sum-gt", a: float64(200), b: float64(100), expected: false},
+		{comparator: "count-eq", a: "abc", b: float64(3), expected: false},
+		{comparator: "sum-gt", a: prices, b: "100", expected: false},
+	}
+
+	for i, c := range cases {
+		res := defaultComparators[c.comparator](c.a, c.b)
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkAggregate(b *testing.B) {
+	prices := []interface{}{float64(20), float64(50), float64(40), float64(15), float64(5)}
+	sumGreaterThan := aggregate(sum, greaterThan)
+	for i := 0; i < b.N; i++ {
+		sumGreaterThan(prices, float64(100))
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	prices := []interface{}{float64(20), float64(50), float64(40), float64(15), float64(5)}
+	countEqual := count(equal)
+	for i := 0; i < b.N; i++ {
+		countEqual(prices, float64(5))
+	}
+}
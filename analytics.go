@@ -0,0 +1,179 @@
+package grules
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Collector receives rule usage from the instances of a service, so
+// usage across a fleet can be put together in one place. Each call has
+// the change in the stats of every composite since the last
+// successful call from the same instance
+type Collector interface {
+	Collect(instance string, deltas []CompositeStats) error
+}
+
+// MemoryCollector is a Collector that adds up the usage it receives in
+// memory. It is safe to use from multiple goroutines
+type MemoryCollector struct {
+	mu        sync.Mutex
+	totals    map[string]CompositeStats
+	instances map[string]bool
+}
+
+// NewMemoryCollector will create a new, empty memory collector
+func NewMemoryCollector() *MemoryCollector {
+	return &MemoryCollector{
+		totals:    map[string]CompositeStats{},
+		instances: map[string]bool{},
+	}
+}
+
+// Collect will add the deltas to the totals
+func (c *MemoryCollector) Collect(instance string, deltas []CompositeStats) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instances[instance] = true
+	for _, d := range deltas {
+		s := c.totals[d.ID]
+		s.ID = d.ID
+		s.Evaluations += d.Evaluations
+		s.Matches += d.Matches
+		c.totals[d.ID] = s
+	}
+	return nil
+}
+
+// Totals will return the usage of every composite across all
+// instances, ordered by the most matched first
+func (c *MemoryCollector) Totals() []CompositeStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	totals := make([]CompositeStats, 0, len(c.totals))
+	for _, s := range c.totals {
+		totals = append(totals, s)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Matches != totals[j].Matches {
+			return totals[i].Matches > totals[j].Matches
+		}
+		return totals[i].ID < totals[j].ID
+	})
+	return totals
+}
+
+// Instances will return the number of instances that have pushed
+// usage to the collector
+func (c *MemoryCollector) Instances() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.instances)
+}
+
+// collectorSnapshot is the saved state of a MemoryCollector
+type collectorSnapshot struct {
+	Totals    []CompositeStats `json:"totals"`
+	Instances []string         `json:"instances"`
+}
+
+// Snapshot will return the totals and instances of the collector as
+// JSON, so a process can Restore them after it restarts
+func (c *MemoryCollector) Snapshot() ([]byte, error) {
+	s := collectorSnapshot{Totals: c.Totals(), Instances: []string{}}
+	c.mu.Lock()
+	for instance := range c.instances {
+		s.Instances = append(s.Instances, instance)
+	}
+	c.mu.Unlock()
+	sort.Strings(s.Instances)
+	return json.Marshal(s)
+}
+
+// Restore will replace the totals and instances of the collector with
+// a snapshot
+func (c *MemoryCollector) Restore(data []byte) error {
+	var s collectorSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	totals := make(map[string]CompositeStats, len(s.Totals))
+	for _, t := range s.Totals {
+		totals[t.ID] = t
+	}
+	instances := make(map[string]bool, len(s.Instances))
+	for _, instance := range s.Instances {
+		instances[instance] = true
+	}
+	c.mu.Lock()
+	c.totals, c.instances = totals, instances
+	c.mu.Unlock()
+	return nil
+}
+
+// Push will send the change in the usage of every composite since the
+// last successful push to the collector. If the collector returns an
+// error, the change is kept and sent with the next push
+func (p *Profile) Push(c Collector, instance string) error {
+	p.pushMu.Lock()
+	defer p.pushMu.Unlock()
+
+	p.mu.Lock()
+	deltas := []CompositeStats{}
+	for id, s := range p.composites {
+		last := p.pushed[id]
+		if s.Evaluations == last.Evaluations {
+			continue
+		}
+		deltas = append(deltas, CompositeStats{
+			ID:          id,
+			Evaluations: s.Evaluations - last.Evaluations,
+			Matches:     s.Matches - last.Matches,
+		})
+	}
+	p.mu.Unlock()
+	if len(deltas) == 0 {
+		return nil
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[i].ID < deltas[j].ID
+	})
+
+	if err := c.Collect(instance, deltas); err != nil {
+		return err
+	}
+	for _, d := range deltas {
+		s := p.pushed[d.ID]
+		s.ID = d.ID
+		s.Evaluations += d.Evaluations
+		s.Matches += d.Matches
+		p.pushed[d.ID] = s
+	}
+	return nil
+}
+
+// PushEvery will push to the collector every interval until the
+// returned stop function is called. Errors are passed to onError, if
+// it is not nil, and the usage is sent again with the next push
+func (p *Profile) PushEvery(c Collector, instance string, interval time.Duration, onError func(error)) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.Push(c, instance); err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
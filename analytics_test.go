@@ -0,0 +1,148 @@
+package grules
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// failingCollector is a collector that fails until it is told not to
+type failingCollector struct {
+	*MemoryCollector
+	fail bool
+}
+
+func (c *failingCollector) Collect(instance string, deltas []CompositeStats) error {
+	if c.fail {
+		return errors.New("collector is down")
+	}
+	return c.MemoryCollector.Collect(instance, deltas)
+}
+
+func TestPush(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			ID:       "adult",
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "gte", Path: "user.age", Value: float64(18)},
+			},
+		},
+		Composite{
+			ID:       "admin",
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "eq", Path: "user.role", Value: "admin"},
+			},
+		},
+	}
+	adult := map[string]interface{}{
+		"user": map[string]interface{}{"age": float64(30), "role": "admin"},
+	}
+	child := map[string]interface{}{
+		"user": map[string]interface{}{"age": float64(12), "role": "admin"},
+	}
+
+	c := &failingCollector{MemoryCollector: NewMemoryCollector()}
+	p1, p2 := NewProfile(), NewProfile()
+	e1, e2 := e.WithProfile(p1), e.WithProfile(p2)
+
+	e1.Evaluate(adult)
+	e1.Evaluate(child)
+	e2.Evaluate(adult)
+
+	// Composites after the first that fails are not evaluated
+	expected := []CompositeStats{
+		CompositeStats{ID: "admin", Evaluations: 1, Matches: 1},
+		CompositeStats{ID: "adult", Evaluations: 2, Matches: 1},
+	}
+	if r := p1.Report(); !reflect.DeepEqual(r.Composites, expected) {
+		t.Fatalf("expected %v but got %v", expected, r.Composites)
+	}
+
+	if err := p1.Push(c, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2.Push(c, "b"); err != nil {
+		t.Fatal(err)
+	}
+	e1.Evaluate(adult)
+	c.fail = true
+	if err := p1.Push(c, "a"); err == nil {
+		t.Fatal("expected push to fail")
+	}
+	c.fail = false
+	if err := p1.Push(c, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p1.Push(c, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []CompositeStats{
+		CompositeStats{ID: "admin", Evaluations: 3, Matches: 3},
+		CompositeStats{ID: "adult", Evaluations: 4, Matches: 3},
+	}
+	if totals := c.Totals(); !reflect.DeepEqual(totals, expected) {
+		t.Fatalf("expected %v but got %v", expected, totals)
+	}
+	if c.Instances() != 2 {
+		t.Fatalf("expected 2 instances, got %d", c.Instances())
+	}
+}
+
+func TestPushEvery(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			ID:       "adult",
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "gte", Path: "user.age", Value: float64(18)},
+			},
+		},
+	}
+	p := NewProfile()
+	e.WithProfile(p).Evaluate(map[string]interface{}{
+		"user": map[string]interface{}{"age": float64(30)},
+	})
+
+	c := NewMemoryCollector()
+	stop := p.PushEvery(c, "a", time.Millisecond, nil)
+	defer stop()
+
+	deadline := time.Now().Add(time.Second)
+	for len(c.Totals()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected usage to be pushed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+}
+
+func TestMemoryCollectorSnapshot(t *testing.T) {
+	c := NewMemoryCollector()
+	c.Collect("a", []CompositeStats{CompositeStats{ID: "adult", Evaluations: 4, Matches: 2}})
+	c.Collect("b", []CompositeStats{CompositeStats{ID: "adult", Evaluations: 1, Matches: 1}})
+	data, err := c.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := NewMemoryCollector()
+	if err := restarted.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	restarted.Collect("a", []CompositeStats{CompositeStats{ID: "adult", Evaluations: 1, Matches: 0}})
+	expected := []CompositeStats{CompositeStats{ID: "adult", Evaluations: 6, Matches: 3}}
+	if totals := restarted.Totals(); !reflect.DeepEqual(totals, expected) || restarted.Instances() != 2 {
+		t.Fatalf("expected %v from 2 instances, got %v from %d", expected, totals, restarted.Instances())
+	}
+
+	if err := restarted.Restore([]byte("[")); err == nil {
+		t.Fatal("expected an invalid snapshot to be an error")
+	}
+}
@@ -0,0 +1,185 @@
+package grules
+
+import (
+	"math"
+)
+
+// arithmetic is a parsed arithmetic expression. It is either a number,
+// a path whose value is a number, or an operator applied to left and
+// right, where a unary minus has no left
+type arithmetic struct {
+	op    string
+	num   float64
+	path  string
+	left  *arithmetic
+	right *arithmetic
+}
+
+// arithmetics is a cache of parsed arithmetic expressions, keyed by
+// the expression. Errors are cached too, so an invalid expression is
+// only parsed once
+var arithmetics cache
+
+// parseArithmetic will parse an arithmetic expression like
+// "$order.subtotal * 1.2", which may use numbers, $paths, + - * / %
+// and parentheses. Errors wrap ErrSyntax
+func parseArithmetic(s string) (*arithmetic, error) {
+	if v, ok := arithmetics.Load(s); ok {
+		if err, ok := v.(error); ok {
+			return nil, err
+		}
+		return v.(*arithmetic), nil
+	}
+
+	var a *arithmetic
+	p, err := newParser(s)
+	if err == nil {
+		a, err = p.sum()
+		if err == nil {
+			err = p.done()
+		}
+	}
+	if err != nil {
+		arithmetics.Store(s, err)
+		return nil, err
+	}
+	arithmetics.Store(s, a)
+	return a, nil
+}
+
+// valueArithmetic will return the parsed arithmetic expression of a
+// rule value, see ExprRef. ok is false if the value is not an
+// expression or the expression is invalid
+func valueArithmetic(v interface{}) (*arithmetic, bool) {
+	s, ok := valueRef(v, ExprRef)
+	if !ok {
+		return nil, false
+	}
+	a, err := parseArithmetic(s)
+	return a, err == nil
+}
+
+// sum will parse products joined by + and -
+func (p *parser) sum() (*arithmetic, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokenSymbol || (t.text != "+" && t.text != "-") {
+			return left, nil
+		}
+		p.next()
+		right, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmetic{op: t.text, left: left, right: right}
+	}
+}
+
+// product will parse factors joined by *, / and %
+func (p *parser) product() (*arithmetic, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokenSymbol || (t.text != "*" && t.text != "/" && t.text != "%") {
+			return left, nil
+		}
+		p.next()
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmetic{op: t.text, left: left, right: right}
+	}
+}
+
+// factor will parse a number, a $path, a negated factor or a sum in
+// parentheses
+func (p *parser) factor() (*arithmetic, error) {
+	switch {
+	case p.accept("-"):
+		operand, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return &arithmetic{op: "-", right: operand}, nil
+	case p.accept("("):
+		a, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		return a, p.expect(")")
+	case p.accept("$"):
+		t := p.peek()
+		if t.kind != tokenIdent {
+			return nil, p.unexpected()
+		}
+		p.next()
+		return &arithmetic{path: t.text}, nil
+	}
+	t := p.peek()
+	if t.kind != tokenNumber {
+		return nil, p.unexpected()
+	}
+	n, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+	return &arithmetic{num: n.(float64)}, nil
+}
+
+// eval will return the value of the expression, ok is false if a path
+// does not exist or is not a number, or the result is not a finite
+// number, like after dividing by zero
+func (a *arithmetic) eval(props map[string]interface{}, e *Engine) (float64, bool) {
+	if a.path != "" {
+		return toFloat64(e.pluck(props, a.path))
+	}
+	if a.op == "" {
+		return a.num, true
+	}
+	right, ok := a.right.eval(props, e)
+	if !ok {
+		return 0, false
+	}
+	if a.left == nil {
+		return -right, true
+	}
+	left, ok := a.left.eval(props, e)
+	if !ok {
+		return 0, false
+	}
+
+	var res float64
+	switch a.op {
+	case "+":
+		res = left + right
+	case "-":
+		res = left - right
+	case "*":
+		res = left * right
+	case "/":
+		res = left / right
+	case "%":
+		res = math.Mod(left, right)
+	}
+	return res, !math.IsNaN(res) && !math.IsInf(res, 0)
+}
+
+// paths will call fn with every path in the expression
+func (a *arithmetic) paths(fn func(path string)) {
+	if a == nil {
+		return
+	}
+	if a.path != "" {
+		fn(a.path)
+	}
+	a.left.paths(fn)
+	a.right.paths(fn)
+}
@@ -0,0 +1,118 @@
+package grules
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestArithmetic(t *testing.T) {
+	props := map[string]interface{}{
+		"order": map[string]interface{}{
+			"subtotal": float64(100),
+			"shipping": float64(5),
+			"items":    float64(4),
+			"note":     "gift",
+		},
+		"zero": float64(0),
+	}
+	cases := []struct {
+		expr     string
+		expected float64
+		ok       bool
+	}{
+		{expr: "$order.subtotal * 1.2", expected: 120, ok: true},
+		{expr: "$order.subtotal+$order.shipping", expected: 105, ok: true},
+		{expr: "$order.subtotal + $order.shipping * 2", expected: 110, ok: true},
+		{expr: "$order.shipping * (20 + 1) + 5", expected: 110, ok: true},
+		{expr: "$order.subtotal / $order.items - 5", expected: 20, ok: true},
+		{expr: "$order.subtotal * -1", expected: -100, ok: true},
+		{expr: "$order.subtotal - -$order.shipping", expected: 105, ok: true},
+		{expr: "$order.subtotal % 30", expected: 10, ok: true},
+		{expr: "$order.subtotal", expected: 100, ok: true},
+		{expr: "$order.subtotal / $zero", ok: false},
+		{expr: "$order.missing * 2", ok: false},
+		{expr: "$order.note * 2", ok: false},
+	}
+	for i, c := range cases {
+		a, err := parseArithmetic(c.expr)
+		if err != nil {
+			t.Fatalf("expected case %d to parse, got %v", i, err)
+		}
+		e := NewEngine()
+		res, ok := a.eval(props, &e)
+		if ok != c.ok || (ok && math.Abs(res-c.expected) > 1e-9) {
+			t.Fatalf("expected case %d to be %v %v, got %v %v", i, c.expected, c.ok, res, ok)
+		}
+	}
+
+	for _, expr := range []string{"$5 off", "$order.subtotal *", "$order.subtotal 1", "USD", "$", "$(1)"} {
+		if _, err := parseArithmetic(expr); !errors.Is(err, ErrSyntax) {
+			t.Fatalf("expected %q to be a syntax error, got %v", expr, err)
+		}
+	}
+}
+
+func BenchmarkArithmetic(b *testing.B) {
+	props := map[string]interface{}{
+		"order": map[string]interface{}{
+			"subtotal": float64(100),
+			"shipping": float64(5),
+		},
+	}
+	e := NewEngine()
+	for i := 0; i < b.N; i++ {
+		a, _ := parseArithmetic("$order.subtotal * 1.2 + $order.shipping")
+		a.eval(props, &e)
+	}
+}
+
+func TestArithmeticRule(t *testing.T) {
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[
+		{"comparator":"gt","path":"order.total","value":{"$expr":"$order.subtotal * 1.2"}},
+		{"comparator":"eq","path":"order.currency","value":"$USD"}
+	]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		total    float64
+		expected bool
+	}{
+		{total: 130, expected: true},
+		{total: 110, expected: false},
+	}
+	for i, c := range cases {
+		props := map[string]interface{}{
+			"order": map[string]interface{}{
+				"total":    c.total,
+				"subtotal": float64(100),
+				"currency": "$USD",
+			},
+		}
+		if res := e.Evaluate(props); res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+		if res := e.Compile().Evaluate(props); res != c.expected {
+			t.Fatalf("expected compiled case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	props := map[string]interface{}{
+		"order": map[string]interface{}{"total": float64(130), "currency": "$USD"},
+	}
+	if e.Evaluate(props) {
+		t.Fatal("expected a missing path in the expression to fail")
+	}
+
+	e, err = NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[
+		{"comparator":"gt","path":"order.total","value":{"$expr":"$order.subtotal *"}}
+	]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ruleErr *RuleError
+	if err := e.Validate(); !errors.As(err, &ruleErr) || ruleErr.Node != "composites[0].rules[0]" || !errors.Is(err, ErrSyntax) {
+		t.Fatalf("expected an invalid expression to be a syntax error, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package grules
+
+import (
+	"sync"
+)
+
+// cacheSize is the most entries a cache holds
+const cacheSize = 1024
+
+// cache is a cache that is safe to use from multiple goroutines and
+// holds at most cacheSize entries. When it is full a random entry is
+// evicted, so keys that come from props, such as a pattern a rule's
+// value refers to with $path, can not make it grow without limit
+type cache struct {
+	mu      sync.RWMutex
+	entries map[string]interface{}
+}
+
+// Load will return the value stored for the key, ok is false if there
+// is none
+func (c *cache) Load(key string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.entries[key]
+	return v, ok
+}
+
+// Store will store the value for the key, evicting a random entry if
+// the cache is full
+func (c *cache) Store(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]interface{}{}
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= cacheSize {
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = value
+}
@@ -0,0 +1,35 @@
+package grules
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	var c cache
+	if _, ok := c.Load("a"); ok {
+		t.Fatal("expected an empty cache to have nothing")
+	}
+	for i := 0; i < cacheSize*2; i++ {
+		c.Store(strconv.Itoa(i), i)
+	}
+	if len(c.entries) != cacheSize {
+		t.Fatalf("expected the cache to hold %d entries, got %d", cacheSize, len(c.entries))
+	}
+	last := strconv.Itoa(cacheSize*2 - 1)
+	if v, ok := c.Load(last); !ok || v != cacheSize*2-1 {
+		t.Fatalf("expected the last entry to be stored, got %v %v", v, ok)
+	}
+	c.Store(last, "again")
+	if v, _ := c.Load(last); len(c.entries) != cacheSize || v != "again" {
+		t.Fatalf("expected storing a key again to replace it, got %v with %d entries", v, len(c.entries))
+	}
+}
+
+func BenchmarkCache(b *testing.B) {
+	var c cache
+	c.Store("^[a-z]+$", compilePattern("^[a-z]+$"))
+	for i := 0; i < b.N; i++ {
+		c.Load("^[a-z]+$")
+	}
+}
@@ -1,7 +1,14 @@
 package grules
 
 import (
+	"math"
+	"net"
+	"path"
 	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Comparator is a function that should evaluate two values and return
@@ -9,6 +16,31 @@ import (
 // false
 type Comparator func(a, b interface{}) bool
 
+// ContextComparator is a comparator that is given the whole props and
+// the rule being evaluated as well as the value at the rule's path, so
+// it can compare fields with each other. It is called even if the
+// value at the path is nil
+type ContextComparator func(a interface{}, r Rule, props map[string]interface{}) bool
+
+// VariadicComparator is a comparator that takes the value of a rule as
+// separate arguments. The value of the rule should be an array with an
+// element for each argument
+type VariadicComparator func(a interface{}, args ...interface{}) bool
+
+// variadic will turn a variadic comparator into a comparator. arity is
+// the number of arguments the comparator takes, or -1 for any number.
+// The comparator is not called, and false is returned, if the rule's
+// value is not an array of arity elements
+func variadic(c VariadicComparator, arity int) Comparator {
+	return func(a, b interface{}) bool {
+		args, ok := b.([]interface{})
+		if !ok || (arity >= 0 && len(args) != arity) {
+			return false
+		}
+		return c(a, args...)
+	}
+}
+
 // equal will return true if a == b
 func equal(a, b interface{}) bool {
 	return a == b
@@ -19,6 +51,66 @@ func notEqual(a, b interface{}) bool {
 	return !equal(a, b)
 }
 
+// equalFold will return true if a == b, ignoring case if a and b are
+// strings
+func equalFold(a, b interface{}) bool {
+	sa, ok := a.(string)
+	if !ok {
+		return equal(a, b)
+	}
+	sb, ok := b.(string)
+	if !ok {
+		return false
+	}
+	return strings.EqualFold(sa, sb)
+}
+
+// notEqualFold will return true if a != b, ignoring case if a and b
+// are strings
+func notEqualFold(a, b interface{}) bool {
+	return !equalFold(a, b)
+}
+
+// startsWith will return true if the string a starts with the string b
+func startsWith(a, b interface{}) bool {
+	sa, sb, ok := stringArgs(a, b)
+	return ok && strings.HasPrefix(sa, sb)
+}
+
+// notStartsWith will return true if the string a does not start with
+// the string b. It will return false if either argument is not a string
+func notStartsWith(a, b interface{}) bool {
+	sa, sb, ok := stringArgs(a, b)
+	return ok && !strings.HasPrefix(sa, sb)
+}
+
+// endsWith will return true if the string a ends with the string b
+func endsWith(a, b interface{}) bool {
+	sa, sb, ok := stringArgs(a, b)
+	return ok && strings.HasSuffix(sa, sb)
+}
+
+// notEndsWith will return true if the string a does not end with the
+// string b. It will return false if either argument is not a string
+func notEndsWith(a, b interface{}) bool {
+	sa, sb, ok := stringArgs(a, b)
+	return ok && !strings.HasSuffix(sa, sb)
+}
+
+// stringArgs will return both arguments as strings, ok is false if
+// either of them is not a string
+func stringArgs(a, b interface{}) (string, string, bool) {
+	sa, ok := a.(string)
+	if !ok {
+		return "", "", false
+	}
+	sb, ok := b.(string)
+	if !ok {
+		return "", "", false
+	}
+	return sa, sb, true
+}
+
 // lessThan will return true if a < b
 func lessThan(a, b interface{}) bool {
 	ta := reflect.TypeOf(a)
@@ -117,6 +209,38 @@ func greaterThanEqual(a, b interface{}) bool {
 	return false
 }
 
+// between will return true if min <= a <= max, where b is a two
+// element slice of [min, max]
+func between(a, b interface{}) bool {
+	min, max, ok := bounds(a, b)
+	return ok && greaterThanEqual(a, min) && lessThanEqual(a, max)
+}
+
+// notBetween will return true if a < min or a > max, where b is a two
+// element slice of [min, max]. It will return false if b is not a two
+// element slice, or its elements are not the same type as a
+func notBetween(a, b interface{}) bool {
+	min, max, ok := bounds(a, b)
+	return ok && !(greaterThanEqual(a, min) && lessThanEqual(a, max))
+}
+
+// bounds will return the min and max of a range, ok is false if b is
+// not a two element slice of strings or float64s of the same type as a
+func bounds(a, b interface{}) (interface{}, interface{}, bool) {
+	bs, ok := b.([]interface{})
+	if !ok || len(bs) != 2 {
+		return nil, nil, false
+	}
+	ta := reflect.TypeOf(a)
+	if ta == nil || (ta.Kind() != reflect.String && ta.Kind() != reflect.Float64) {
+		return nil, nil, false
+	}
+	if reflect.TypeOf(bs[0]) != ta || reflect.TypeOf(bs[1]) != ta {
+		return nil, nil, false
+	}
+	return bs[0], bs[1], true
+}
+
 // contains will return true if a contains b. We assume
 // that the first interface is a slice. If you need b to be a slice
 // consider using oneOf
@@ -164,6 +288,25 @@ func containsFloat64(a, b interface{}) bool {
 	return false
 }
 
+// containsFold will return true if a contains b, ignoring case. We
+// assume that the first interface is a slice and the second is a string
+func containsFold(a, b interface{}) bool {
+	as, ok := a.([]interface{})
+	if !ok {
+		return false
+	}
+	bs, ok := b.(string)
+	if !ok {
+		return false
+	}
+	for _, elem := range as {
+		if val, ok := elem.(string); ok && strings.EqualFold(val, bs) {
+			return true
+		}
+	}
+	return false
+}
+
 // notContains will return true if the b is not contained a. This will also return
 // true if a is a slice of different types than b. It will return false if a
 // is not a slice.
@@ -211,7 +354,578 @@ func notContainsFloat64(a, b interface{}) bool {
 	return true
 }
 
+// in will return true if a is one of the elements of the slice b. Unlike
+// oneOf, b may mix strings and numbers, and numbers of any type are
+// compared by value, so int(1) is in []interface{}{float64(1)}
+func in(a, b interface{}) bool {
+	vb := reflect.ValueOf(b)
+	if vb.Kind() != reflect.Slice && vb.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < vb.Len(); i++ {
+		if sameValue(a, vb.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// notIn will return true if a is not one of the elements of the slice
+// b. It will return false if b is not a slice
+func notIn(a, b interface{}) bool {
+	vb := reflect.ValueOf(b)
+	if vb.Kind() != reflect.Slice && vb.Kind() != reflect.Array {
+		return false
+	}
+	return !in(a, b)
+}
+
+// sameValue will return true if a and b are equal numbers, regardless
+// of their types, or are otherwise equal
+func sameValue(a, b interface{}) bool {
+	fa, aok := toFloat64(a)
+	fb, bok := toFloat64(b)
+	if aok || bok {
+		return aok && bok && fa == fb
+	}
+	ta := reflect.TypeOf(a)
+	if ta == nil {
+		return b == nil
+	}
+	if ta != reflect.TypeOf(b) || !ta.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// toFloat64 will convert any integer or floating point number to a
+// float64, ok is false if v is not a number
+func toFloat64(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// empty will return true if a is an empty string, slice, array or map.
+// b is ignored
+func empty(a, b interface{}) bool {
+	n, ok := length(a)
+	return ok && n == 0
+}
+
+// notEmpty will return true if a is a string, slice, array or map with
+// at least one element. b is ignored
+func notEmpty(a, b interface{}) bool {
+	n, ok := length(a)
+	return ok && n > 0
+}
+
+// length will return the length of a slice, array or map, or the
+// number of runes in a string, ok is false if a is none of those
+func length(a interface{}) (int, bool) {
+	v := reflect.ValueOf(a)
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), true
+	}
+	return 0, false
+}
+
+// lengthEqual will return true if the length of a equals the number b.
+// a can be a string, array or object
+func lengthEqual(a, b interface{}) bool {
+	n, ok := length(a)
+	if !ok {
+		return false
+	}
+	want, ok := toFloat64(b)
+	return ok && float64(n) == want
+}
+
+// lengthGreaterThan will return true if the length of a is greater
+// than the number b. a can be a string, array or object
+func lengthGreaterThan(a, b interface{}) bool {
+	n, ok := length(a)
+	if !ok {
+		return false
+	}
+	want, ok := toFloat64(b)
+	return ok && float64(n) > want
+}
+
+// lengthLessThan will return true if the length of a is less than the
+// number b. a can be a string, array or object
+func lengthLessThan(a, b interface{}) bool {
+	n, ok := length(a)
+	if !ok {
+		return false
+	}
+	want, ok := toFloat64(b)
+	return ok && float64(n) < want
+}
+
+// exists will return true if a is not nil, meaning the path of the
+// rule is present in the props. b is ignored
+func exists(a, b interface{}) bool {
+	return a != nil
+}
+
+// notExists will return true if a is nil, meaning the path of the rule
+// is missing from the props. b is ignored
+func notExists(a, b interface{}) bool {
+	return a == nil
+}
+
+// before will return true if the time a is before the time b. Times
+// may be time.Time values or RFC3339 strings
+func before(a, b interface{}) bool {
+	ta, ok := toTime(a)
+	if !ok {
+		return false
+	}
+	tb, ok := toTime(b)
+	return ok && ta.Before(tb)
+}
+
+// after will return true if the time a is after the time b. Times may
+// be time.Time values or RFC3339 strings
+func after(a, b interface{}) bool {
+	ta, ok := toTime(a)
+	if !ok {
+		return false
+	}
+	tb, ok := toTime(b)
+	return ok && ta.After(tb)
+}
+
+// dateEqual will return true if the times a and b fall on the same
+// day, in the time zone of a. Times may be time.Time values, RFC3339
+// strings or dates like "2006-01-02"
+func dateEqual(a, b interface{}) bool {
+	ta, ok := toTime(a)
+	if !ok {
+		return false
+	}
+	tb, ok := toTime(b)
+	if !ok {
+		return false
+	}
+	y1, m1, d1 := ta.Date()
+	y2, m2, d2 := tb.In(ta.Location()).Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+// Now is the clock used by within and olderthan, and to check the
+// expiry of tokens. It can be replaced to make tests independent of the
+// current time
+var Now = time.Now
+
+// within will return true if the time a is no further in the past than
+// the duration b, like "24h", and is not in the future
+func within(a, b interface{}) bool {
+	t, d, ok := timeAndDuration(a, b)
+	if !ok {
+		return false
+	}
+	now := Now()
+	return !t.Before(now.Add(-d)) && !t.After(now)
+}
+
+// olderThan will return true if the time a is further in the past than
+// the duration b, like "24h"
+func olderThan(a, b interface{}) bool {
+	t, d, ok := timeAndDuration(a, b)
+	return ok && t.Before(Now().Add(-d))
+}
+
+// timeAndDuration will convert a to a time and b, a string like "24h",
+// to a duration, ok is false if either can not be converted
+func timeAndDuration(a, b interface{}) (time.Time, time.Duration, bool) {
+	t, ok := toTime(a)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	s, ok := b.(string)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return t, d, true
+}
+
+// timeLayouts is a list of the layouts a string is parsed with to
+// become a time, in order
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02",
+}
+
+// toTime will convert a time.Time or a string in one of timeLayouts to
+// a time.Time, ok is false if v is neither
+func toTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// networks is a cache of parsed CIDR blocks, keyed by the block, so a
+// rule's block is only parsed the first time it is evaluated
+var networks cache
+
+// parseCIDR will return the network of a CIDR block like "10.0.0.0/8",
+// or nil if the block is invalid
+func parseCIDR(block string) *net.IPNet {
+	if n, ok := networks.Load(block); ok {
+		return n.(*net.IPNet)
+	}
+	_, n, err := net.ParseCIDR(block)
+	if err != nil {
+		return nil
+	}
+	networks.Store(block, n)
+	return n
+}
+
+// cidrContains will return true if the IP address a is in the CIDR
+// block b, or in any of the blocks if b is a slice. Invalid addresses
+// and blocks never match
+func cidrContains(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+	blocks, ok := b.([]interface{})
+	if !ok {
+		blocks = []interface{}{b}
+	}
+	for _, block := range blocks {
+		block, ok := block.(string)
+		if !ok {
+			continue
+		}
+		if n := parseCIDR(block); n != nil && n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipEqual will return true if a and b are the same IP address, even if
+// they are written differently, like "::ffff:10.0.0.1" and "10.0.0.1"
+func ipEqual(a, b interface{}) bool {
+	sa, ok := a.(string)
+	if !ok {
+		return false
+	}
+	sb, ok := b.(string)
+	if !ok {
+		return false
+	}
+	ipa, ipb := net.ParseIP(sa), net.ParseIP(sb)
+	return ipa != nil && ipb != nil && ipa.Equal(ipb)
+}
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// geoWithin will return true if the point a is within radius_m meters
+// of the point in b, where b is a map of "lat", "lon" and "radius_m".
+// a can be a map of "lat" and "lon" or a [lat, lon] slice
+func geoWithin(a, b interface{}) bool {
+	lat1, lon1, ok := latLon(a)
+	if !ok {
+		return false
+	}
+	area, ok := b.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	lat2, lon2, ok := latLon(area)
+	if !ok {
+		return false
+	}
+	radius, ok := toFloat64(area["radius_m"])
+	if !ok {
+		return false
+	}
+
+	// The haversine formula for the great circle distance
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dphi := (lat2 - lat1) * math.Pi / 180
+	dlambda := (lon2 - lon1) * math.Pi / 180
+	h := math.Sin(dphi/2)*math.Sin(dphi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dlambda/2)*math.Sin(dlambda/2)
+	distance := 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+	return distance <= radius
+}
+
+// latLon will return the latitude and longitude of a point, which can
+// be a map of "lat" and "lon" or a [lat, lon] slice
+func latLon(v interface{}) (float64, float64, bool) {
+	var lat, lon interface{}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		lat, lon = v["lat"], v["lon"]
+	case []interface{}:
+		if len(v) != 2 {
+			return 0, 0, false
+		}
+		lat, lon = v[0], v[1]
+	default:
+		return 0, 0, false
+	}
+	flat, ok := toFloat64(lat)
+	if !ok {
+		return 0, 0, false
+	}
+	flon, ok := toFloat64(lon)
+	return flat, flon, ok
+}
+
+// glob will return true if the string a matches the glob pattern b,
+// using the syntax of path.Match: * matches any characters other than
+// '/', ? matches one and [a-z] matches a range. It will return false
+// if b is not a valid pattern
+func glob(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	pattern, ok := b.(string)
+	if !ok {
+		return false
+	}
+	matched, err := path.Match(pattern, s)
+	return err == nil && matched
+}
+
+// containsAll will return true if the slice a contains every element
+// of the slice b. Elements are compared the same way as in
+func containsAll(a, b interface{}) bool {
+	as, ok := a.([]interface{})
+	if !ok {
+		return false
+	}
+	bs, ok := b.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, elem := range bs {
+		if !in(elem, as) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAny will return true if the slice a contains at least one
+// element of the slice b. Elements are compared the same way as in
+func containsAny(a, b interface{}) bool {
+	as, ok := a.([]interface{})
+	if !ok {
+		return false
+	}
+	bs, ok := b.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, elem := range bs {
+		if in(elem, as) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsNone will return true if the slice a contains none of the
+// elements of the slice b. It will return false if either is not a
+// slice
+func containsNone(a, b interface{}) bool {
+	if _, ok := a.([]interface{}); !ok {
+		return false
+	}
+	if _, ok := b.([]interface{}); !ok {
+		return false
+	}
+	return !containsAny(a, b)
+}
+
+// subset will return true if every element of the slice a is in the
+// slice b
+func subset(a, b interface{}) bool {
+	return containsAll(b, a)
+}
+
+// superset will return true if every element of the slice b is in the
+// slice a
+func superset(a, b interface{}) bool {
+	return containsAll(a, b)
+}
+
+// intersects will return true if the slices a and b have at least one
+// element in common
+func intersects(a, b interface{}) bool {
+	return containsAny(a, b)
+}
+
+// stringLengthEqual will return true if the number of runes in the
+// string a equals the number b
+func stringLengthEqual(a, b interface{}) bool {
+	n, want, ok := runeCount(a, b)
+	return ok && n == want
+}
+
+// stringLengthGreaterThan will return true if the number of runes in
+// the string a is greater than the number b
+func stringLengthGreaterThan(a, b interface{}) bool {
+	n, want, ok := runeCount(a, b)
+	return ok && n > want
+}
+
+// stringLengthLessThan will return true if the number of runes in the
+// string a is less than the number b
+func stringLengthLessThan(a, b interface{}) bool {
+	n, want, ok := runeCount(a, b)
+	return ok && n < want
+}
+
+// runeCount will return the number of runes in the string a along with
+// the number b, ok is false if a is not a string or b is not a number
+func runeCount(a, b interface{}) (float64, float64, bool) {
+	s, ok := a.(string)
+	if !ok {
+		return 0, 0, false
+	}
+	want, ok := toFloat64(b)
+	if !ok {
+		return 0, 0, false
+	}
+	return float64(utf8.RuneCountInString(s)), want, true
+}
+
+// divisibleBy will return true if the number a is divisible by the
+// number b, with no remainder. It will return false if b is zero
+func divisibleBy(a, b interface{}) bool {
+	fa, ok := toFloat64(a)
+	if !ok {
+		return false
+	}
+	fb, ok := toFloat64(b)
+	if !ok || fb == 0 {
+		return false
+	}
+	return math.Mod(fa, fb) == 0
+}
+
+// maskSet will return true if every bit set in the number b is also
+// set in the number a. Both must be non-negative integers
+func maskSet(a, b interface{}) bool {
+	value, mask, ok := bitfields(a, b)
+	return ok && value&mask == mask
+}
+
+// maskClear will return true if no bit set in the number b is set in
+// the number a. Both must be non-negative integers
+func maskClear(a, b interface{}) bool {
+	value, mask, ok := bitfields(a, b)
+	return ok && value&mask == 0
+}
+
+// bitfields will convert the numbers a and b to unsigned integers, ok
+// is false if either is not a non-negative integer
+func bitfields(a, b interface{}) (uint64, uint64, bool) {
+	fa, ok := toFloat64(a)
+	if !ok || fa < 0 || fa != math.Trunc(fa) || fa >= 1<<64 {
+		return 0, 0, false
+	}
+	fb, ok := toFloat64(b)
+	if !ok || fb < 0 || fb != math.Trunc(fb) || fb >= 1<<64 {
+		return 0, 0, false
+	}
+	return uint64(fa), uint64(fb), true
+}
+
 // oneOf will return true if b contains a
 func oneOf(a, b interface{}) bool {
 	return contains(b, a)
 }
+
+// patterns is a cache of compiled regular expressions, keyed by the
+// pattern, so a rule's pattern is only compiled the first time it is
+// evaluated
+var patterns cache
+
+// compilePattern will return the compiled regular expression for the
+// pattern, or nil if the pattern is invalid
+func compilePattern(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	patterns.Store(pattern, re)
+	return re
+}
+
+// regex will return true if a matches the regular expression b. Both
+// arguments must be strings, and b must be a valid pattern
+func regex(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	pattern, ok := b.(string)
+	if !ok {
+		return false
+	}
+	re := compilePattern(pattern)
+	if re == nil {
+		return false
+	}
+	return re.MatchString(s)
+}
+
+// notRegex will return true if a does not match the regular expression
+// b. It will return false if either argument is not a string, or b is
+// not a valid pattern
+func notRegex(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	pattern, ok := b.(string)
+	if !ok {
+		return false
+	}
+	re := compilePattern(pattern)
+	if re == nil {
+		return false
+	}
+	return !re.MatchString(s)
+}
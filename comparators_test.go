@@ -3,6 +3,7 @@ package grules
 import (
 	"fmt"
 	"testing"
+	"time"
 )
 
 type testCase struct {
@@ -58,6 +59,139 @@ func BenchmarkNotEqual(b *testing.B) {
 	}
 }
 
+func TestEqualFold(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"a", "a"}, expected: true},
+		testCase{args: []interface{}{"Trevor", "tREVOR"}, expected: true},
+		testCase{args: []interface{}{"a", "b"}, expected: false},
+		testCase{args: []interface{}{"1", float64(1)}, expected: false},
+		testCase{args: []interface{}{float64(1), float64(1)}, expected: true},
+		testCase{args: []interface{}{float64(1), float64(0)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := equalFold(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkEqualFold(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		equalFold("Benchmark", "bENCHMARK")
+	}
+}
+
+func TestNotEqualFold(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"a", "a"}, expected: false},
+		testCase{args: []interface{}{"Trevor", "tREVOR"}, expected: false},
+		testCase{args: []interface{}{"a", "b"}, expected: true},
+		testCase{args: []interface{}{float64(1), float64(0)}, expected: true},
+	}
+
+	for i, c := range cases {
+		res := notEqualFold(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkNotEqualFold(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		notEqualFold("Benchmark", "not-benchmark")
+	}
+}
+
+func TestStartsWith(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"/api/users", "/api/"}, expected: true},
+		testCase{args: []interface{}{"/api/users", "/admin/"}, expected: false},
+		testCase{args: []interface{}{"/api", ""}, expected: true},
+		testCase{args: []interface{}{float64(12), "1"}, expected: false},
+		testCase{args: []interface{}{"12", float64(1)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := startsWith(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkStartsWith(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		startsWith("/api/users", "/api/")
+	}
+}
+
+func TestNotStartsWith(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"/api/users", "/api/"}, expected: false},
+		testCase{args: []interface{}{"/api/users", "/admin/"}, expected: true},
+		testCase{args: []interface{}{float64(12), "1"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := notStartsWith(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkNotStartsWith(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		notStartsWith("/api/users", "/admin/")
+	}
+}
+
+func TestEndsWith(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"report.pdf", ".pdf"}, expected: true},
+		testCase{args: []interface{}{"report.pdf", ".png"}, expected: false},
+		testCase{args: []interface{}{float64(12), "2"}, expected: false},
+		testCase{args: []interface{}{"12", float64(2)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := endsWith(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkEndsWith(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		endsWith("report.pdf", ".pdf")
+	}
+}
+
+func TestNotEndsWith(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"report.pdf", ".pdf"}, expected: false},
+		testCase{args: []interface{}{"report.pdf", ".png"}, expected: true},
+		testCase{args: []interface{}{float64(12), "2"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := notEndsWith(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkNotEndsWith(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		notEndsWith("report.pdf", ".png")
+	}
+}
+
 func TestLessThan(t *testing.T) {
 	cases := []testCase{
 		testCase{args: []interface{}{"a", "a"}, expected: false},
@@ -160,6 +294,61 @@ func BenchmarkGreaterThanEqual(b *testing.B) {
 	}
 }
 
+func TestBetween(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{float64(18), []interface{}{float64(18), float64(65)}}, expected: true},
+		testCase{args: []interface{}{float64(30), []interface{}{float64(18), float64(65)}}, expected: true},
+		testCase{args: []interface{}{float64(65), []interface{}{float64(18), float64(65)}}, expected: true},
+		testCase{args: []interface{}{float64(17), []interface{}{float64(18), float64(65)}}, expected: false},
+		testCase{args: []interface{}{float64(66), []interface{}{float64(18), float64(65)}}, expected: false},
+		testCase{args: []interface{}{"b", []interface{}{"a", "c"}}, expected: true},
+		testCase{args: []interface{}{"d", []interface{}{"a", "c"}}, expected: false},
+		testCase{args: []interface{}{float64(30), []interface{}{"18", "65"}}, expected: false},
+		testCase{args: []interface{}{float64(30), []interface{}{float64(18)}}, expected: false},
+		testCase{args: []interface{}{float64(30), float64(18)}, expected: false},
+		testCase{args: []interface{}{true, []interface{}{false, true}}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := between(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkBetween(b *testing.B) {
+	bounds := []interface{}{float64(18), float64(65)}
+	for i := 0; i < b.N; i++ {
+		between(float64(30), bounds)
+	}
+}
+
+func TestNotBetween(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{float64(18), []interface{}{float64(18), float64(65)}}, expected: false},
+		testCase{args: []interface{}{float64(17), []interface{}{float64(18), float64(65)}}, expected: true},
+		testCase{args: []interface{}{float64(66), []interface{}{float64(18), float64(65)}}, expected: true},
+		testCase{args: []interface{}{"d", []interface{}{"a", "c"}}, expected: true},
+		testCase{args: []interface{}{float64(30), []interface{}{"18", "65"}}, expected: false},
+		testCase{args: []interface{}{float64(30), []interface{}{float64(18)}}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := notBetween(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkNotBetween(b *testing.B) {
+	bounds := []interface{}{float64(18), float64(65)}
+	for i := 0; i < b.N; i++ {
+		notBetween(float64(70), bounds)
+	}
+}
+
 func TestContains(t *testing.T) {
 	cases := []testCase{
 		testCase{args: []interface{}{[]interface{}{"a", "b"}, "a"}, expected: true},
@@ -199,6 +388,30 @@ func BenchmarkContainsLong50000(b *testing.B) {
 	}
 }
 
+func TestContainsFold(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"Admin", "Owner"}, "admin"}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"Admin", "Owner"}, "OWNER"}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"Admin", "Owner"}, "guest"}, expected: false},
+		testCase{args: []interface{}{[]interface{}{float64(1)}, "1"}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"1"}, float64(1)}, expected: false},
+		testCase{args: []interface{}{"admin", "admin"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := containsFold(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkContainsFold(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		containsFold([]interface{}{"Admin", "Owner"}, "owner")
+	}
+}
+
 func TestNotContains(t *testing.T) {
 	cases := []testCase{
 		testCase{args: []interface{}{[]interface{}{"a", "b"}, "a"}, expected: false},
@@ -254,3 +467,803 @@ func TestOneOf(t *testing.T) {
 		}
 	}
 }
+
+func TestRegex(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"test@test.com", `^.+@test\.com$`}, expected: true},
+		testCase{args: []interface{}{"test@example.com", `^.+@test\.com$`}, expected: false},
+		testCase{args: []interface{}{"abc", "b"}, expected: true},
+		testCase{args: []interface{}{"abc", "("}, expected: false},
+		testCase{args: []interface{}{float64(1), "1"}, expected: false},
+		testCase{args: []interface{}{"1", float64(1)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := regex(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkRegex(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		regex("test@test.com", `^.+@test\.com$`)
+	}
+}
+
+func TestNotRegex(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"test@test.com", `^.+@test\.com$`}, expected: false},
+		testCase{args: []interface{}{"test@example.com", `^.+@test\.com$`}, expected: true},
+		testCase{args: []interface{}{"abc", "("}, expected: false},
+		testCase{args: []interface{}{float64(1), "2"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := notRegex(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkNotRegex(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		notRegex("test@example.com", `^.+@test\.com$`)
+	}
+}
+
+func TestIn(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"a", []interface{}{"a", float64(1)}}, expected: true},
+		testCase{args: []interface{}{float64(1), []interface{}{"a", float64(1)}}, expected: true},
+		testCase{args: []interface{}{int(1), []interface{}{"a", float64(1)}}, expected: true},
+		testCase{args: []interface{}{float64(2), []interface{}{int64(1), uint8(2)}}, expected: true},
+		testCase{args: []interface{}{float64(2), []int{1, 2}}, expected: true},
+		testCase{args: []interface{}{"1", []interface{}{float64(1)}}, expected: false},
+		testCase{args: []interface{}{float64(1), []interface{}{"1"}}, expected: false},
+		testCase{args: []interface{}{true, []interface{}{true, "a"}}, expected: true},
+		testCase{args: []interface{}{"c", []interface{}{"a", "b"}}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"a"}, []interface{}{"a"}}, expected: false},
+		testCase{args: []interface{}{"a", "a"}, expected: false},
+		testCase{args: []interface{}{nil, []interface{}{"a", nil}}, expected: true},
+	}
+
+	for i, c := range cases {
+		res := in(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkIn(b *testing.B) {
+	list := []interface{}{"a", float64(1), float64(2), "b"}
+	for i := 0; i < b.N; i++ {
+		in(2, list)
+	}
+}
+
+func TestNotIn(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"a", []interface{}{"a", float64(1)}}, expected: false},
+		testCase{args: []interface{}{int(1), []interface{}{"a", float64(1)}}, expected: false},
+		testCase{args: []interface{}{"c", []interface{}{"a", float64(1)}}, expected: true},
+		testCase{args: []interface{}{float64(3), []interface{}{"a", float64(1)}}, expected: true},
+		testCase{args: []interface{}{"a", "a"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := notIn(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkNotIn(b *testing.B) {
+	list := []interface{}{"a", float64(1), float64(2), "b"}
+	for i := 0; i < b.N; i++ {
+		notIn(3, list)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"", nil}, expected: true},
+		testCase{args: []interface{}{[]interface{}{}, nil}, expected: true},
+		testCase{args: []interface{}{map[string]interface{}{}, nil}, expected: true},
+		testCase{args: []interface{}{"a", nil}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"a"}, nil}, expected: false},
+		testCase{args: []interface{}{map[string]interface{}{"a": "b"}, nil}, expected: false},
+		testCase{args: []interface{}{float64(0), nil}, expected: false},
+		testCase{args: []interface{}{false, nil}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := empty(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkEmpty(b *testing.B) {
+	list := []interface{}{"a", "b"}
+	for i := 0; i < b.N; i++ {
+		empty(list, nil)
+	}
+}
+
+func TestNotEmpty(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"", nil}, expected: false},
+		testCase{args: []interface{}{[]interface{}{}, nil}, expected: false},
+		testCase{args: []interface{}{map[string]interface{}{}, nil}, expected: false},
+		testCase{args: []interface{}{"a", nil}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"a"}, nil}, expected: true},
+		testCase{args: []interface{}{map[string]interface{}{"a": "b"}, nil}, expected: true},
+		testCase{args: []interface{}{float64(0), nil}, expected: false},
+		testCase{args: []interface{}{true, nil}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := notEmpty(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkNotEmpty(b *testing.B) {
+	list := []interface{}{"a", "b"}
+	for i := 0; i < b.N; i++ {
+		notEmpty(list, nil)
+	}
+}
+
+func TestExists(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"a", nil}, expected: true},
+		testCase{args: []interface{}{"", nil}, expected: true},
+		testCase{args: []interface{}{false, nil}, expected: true},
+		testCase{args: []interface{}{nil, nil}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := exists(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkExists(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		exists("a", nil)
+	}
+}
+
+func TestNotExists(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"a", nil}, expected: false},
+		testCase{args: []interface{}{"", nil}, expected: false},
+		testCase{args: []interface{}{false, nil}, expected: false},
+		testCase{args: []interface{}{nil, nil}, expected: true},
+	}
+
+	for i, c := range cases {
+		res := notExists(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkNotExists(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		notExists("a", nil)
+	}
+}
+
+func TestBefore(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"2020-01-01T10:00:00Z", "2020-01-01T11:00:00Z"}, expected: true},
+		testCase{args: []interface{}{"2020-01-01T12:00:00+02:00", "2020-01-01T11:00:00Z"}, expected: true},
+		testCase{args: []interface{}{"2020-01-01T11:00:00Z", "2020-01-01T11:00:00Z"}, expected: false},
+		testCase{args: []interface{}{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "2020-01-02"}, expected: true},
+		testCase{args: []interface{}{"2020-01-03", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)}, expected: false},
+		testCase{args: []interface{}{"yesterday", "2020-01-01T11:00:00Z"}, expected: false},
+		testCase{args: []interface{}{"2020-01-01T10:00:00Z", float64(1)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := before(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkBefore(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		before("2020-01-01T10:00:00Z", "2020-01-01T11:00:00Z")
+	}
+}
+
+func TestAfter(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"2020-01-01T10:00:00Z", "2020-01-01T11:00:00Z"}, expected: false},
+		testCase{args: []interface{}{"2020-01-01T12:00:00-02:00", "2020-01-01T11:00:00Z"}, expected: true},
+		testCase{args: []interface{}{"2020-01-01T11:00:00Z", "2020-01-01T11:00:00Z"}, expected: false},
+		testCase{args: []interface{}{time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), "2020-01-02"}, expected: true},
+		testCase{args: []interface{}{"2020-01-01T10:00:00Z", "tomorrow"}, expected: false},
+		testCase{args: []interface{}{float64(1), "2020-01-01T11:00:00Z"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := after(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkAfter(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		after("2020-01-01T10:00:00Z", "2020-01-01T11:00:00Z")
+	}
+}
+
+func TestDateEqual(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"2020-01-01T10:00:00Z", "2020-01-01"}, expected: true},
+		testCase{args: []interface{}{"2020-01-01T23:00:00Z", "2020-01-01T01:00:00Z"}, expected: true},
+		testCase{args: []interface{}{"2020-01-01T23:00:00-05:00", "2020-01-02T03:00:00Z"}, expected: true},
+		testCase{args: []interface{}{"2020-01-01T23:00:00Z", "2020-01-02T03:00:00Z"}, expected: false},
+		testCase{args: []interface{}{time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC), "2020-01-01"}, expected: true},
+		testCase{args: []interface{}{"2020-01-01", "someday"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := dateEqual(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkDateEqual(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		dateEqual("2020-01-01T10:00:00Z", "2020-01-01")
+	}
+}
+
+func TestWithin(t *testing.T) {
+	defer func(now func() time.Time) { Now = now }(Now)
+	Now = func() time.Time {
+		return time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC)
+	}
+
+	cases := []testCase{
+		testCase{args: []interface{}{"2020-01-02T11:00:00Z", "2h"}, expected: true},
+		testCase{args: []interface{}{"2020-01-02T10:00:00Z", "2h"}, expected: true},
+		testCase{args: []interface{}{"2020-01-02T09:59:59Z", "2h"}, expected: false},
+		testCase{args: []interface{}{"2020-01-02T13:00:00Z", "2h"}, expected: false},
+		testCase{args: []interface{}{time.Date(2020, 1, 1, 13, 0, 0, 0, time.UTC), "24h"}, expected: true},
+		testCase{args: []interface{}{"2020-01-02T11:00:00Z", "two hours"}, expected: false},
+		testCase{args: []interface{}{"2020-01-02T11:00:00Z", float64(2)}, expected: false},
+		testCase{args: []interface{}{"noon", "2h"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := within(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkWithin(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		within("2020-01-02T11:00:00Z", "24h")
+	}
+}
+
+func TestOlderThan(t *testing.T) {
+	defer func(now func() time.Time) { Now = now }(Now)
+	Now = func() time.Time {
+		return time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC)
+	}
+
+	cases := []testCase{
+		testCase{args: []interface{}{"2020-01-02T11:00:00Z", "2h"}, expected: false},
+		testCase{args: []interface{}{"2020-01-02T10:00:00Z", "2h"}, expected: false},
+		testCase{args: []interface{}{"2020-01-02T09:59:59Z", "2h"}, expected: true},
+		testCase{args: []interface{}{"2019-12-01", "720h"}, expected: true},
+		testCase{args: []interface{}{"2020-01-01T11:00:00Z", "a day"}, expected: false},
+		testCase{args: []interface{}{float64(0), "2h"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := olderThan(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkOlderThan(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		olderThan("2020-01-02T11:00:00Z", "24h")
+	}
+}
+
+func TestCIDRContains(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"10.1.2.3", "10.0.0.0/8"}, expected: true},
+		testCase{args: []interface{}{"11.1.2.3", "10.0.0.0/8"}, expected: false},
+		testCase{args: []interface{}{"192.168.1.20", []interface{}{"10.0.0.0/8", "192.168.1.0/24"}}, expected: true},
+		testCase{args: []interface{}{"192.168.2.20", []interface{}{"10.0.0.0/8", "192.168.1.0/24"}}, expected: false},
+		testCase{args: []interface{}{"2001:db8::1", "2001:db8::/32"}, expected: true},
+		testCase{args: []interface{}{"::ffff:10.0.0.1", "10.0.0.0/8"}, expected: true},
+		testCase{args: []interface{}{"10.1.2.3", "10.0.0.0"}, expected: false},
+		testCase{args: []interface{}{"localhost", "10.0.0.0/8"}, expected: false},
+		testCase{args: []interface{}{float64(1), "10.0.0.0/8"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := cidrContains(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkCIDRContains(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		cidrContains("10.1.2.3", "10.0.0.0/8")
+	}
+}
+
+func TestIPEqual(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"10.0.0.1", "10.0.0.1"}, expected: true},
+		testCase{args: []interface{}{"::ffff:10.0.0.1", "10.0.0.1"}, expected: true},
+		testCase{args: []interface{}{"2001:db8:0:0::1", "2001:db8::1"}, expected: true},
+		testCase{args: []interface{}{"10.0.0.1", "10.0.0.2"}, expected: false},
+		testCase{args: []interface{}{"localhost", "localhost"}, expected: false},
+		testCase{args: []interface{}{"10.0.0.1", float64(1)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := ipEqual(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkIPEqual(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ipEqual("::ffff:10.0.0.1", "10.0.0.1")
+	}
+}
+
+func TestGeoWithin(t *testing.T) {
+	london := map[string]interface{}{"lat": float64(51.5074), "lon": float64(-0.1278), "radius_m": float64(50000)}
+	cases := []testCase{
+		// Westminster and Heathrow are both in London
+		testCase{args: []interface{}{map[string]interface{}{"lat": float64(51.4995), "lon": float64(-0.1248)}, london}, expected: true},
+		testCase{args: []interface{}{[]interface{}{float64(51.47), float64(-0.4543)}, london}, expected: true},
+		// Paris is about 344km away
+		testCase{args: []interface{}{[]interface{}{float64(48.8566), float64(2.3522)}, london}, expected: false},
+		testCase{args: []interface{}{[]interface{}{float64(48.8566), float64(2.3522)}, map[string]interface{}{"lat": float64(51.5074), "lon": float64(-0.1278), "radius_m": float64(350000)}}, expected: true},
+		testCase{args: []interface{}{[]interface{}{float64(51.5)}, london}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"51.5", "-0.12"}, london}, expected: false},
+		testCase{args: []interface{}{[]interface{}{float64(51.5), float64(-0.12)}, map[string]interface{}{"lat": float64(51.5), "lon": float64(-0.12)}}, expected: false},
+		testCase{args: []interface{}{"London", london}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := geoWithin(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkGeoWithin(b *testing.B) {
+	point := []interface{}{float64(51.47), float64(-0.4543)}
+	london := map[string]interface{}{"lat": float64(51.5074), "lon": float64(-0.1278), "radius_m": float64(50000)}
+	for i := 0; i < b.N; i++ {
+		geoWithin(point, london)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"orders.created", "orders.*"}, expected: true},
+		testCase{args: []interface{}{"api-1.example.com", "api-?.example.com"}, expected: true},
+		testCase{args: []interface{}{"/var/log/app.log", "/var/log/*.log"}, expected: true},
+		testCase{args: []interface{}{"/var/log/app/app.log", "/var/log/*.log"}, expected: false},
+		testCase{args: []interface{}{"users.created", "orders.*"}, expected: false},
+		testCase{args: []interface{}{"orders.created", "orders.["}, expected: false},
+		testCase{args: []interface{}{float64(1), "*"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := glob(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkGlob(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		glob("api-1.example.com", "api-?.example.*")
+	}
+}
+
+func TestLengthEqual(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, float64(2)}, expected: true},
+		testCase{args: []interface{}{map[string]interface{}{"a": "b"}, float64(1)}, expected: true},
+		testCase{args: []interface{}{"héllo", float64(5)}, expected: true},
+		testCase{args: []interface{}{"", int(0)}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, float64(3)}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, "2"}, expected: false},
+		testCase{args: []interface{}{float64(2), float64(2)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := lengthEqual(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkLengthEqual(b *testing.B) {
+	list := []interface{}{"a", "b", "c"}
+	for i := 0; i < b.N; i++ {
+		lengthEqual(list, float64(3))
+	}
+}
+
+func TestLengthGreaterThan(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"a", "b", "c", "d"}, float64(3)}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"a", "b", "c"}, float64(3)}, expected: false},
+		testCase{args: []interface{}{map[string]interface{}{"a": "b"}, float64(0)}, expected: true},
+		testCase{args: []interface{}{"héllo", float64(5)}, expected: false},
+		testCase{args: []interface{}{float64(4), float64(3)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := lengthGreaterThan(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkLengthGreaterThan(b *testing.B) {
+	list := []interface{}{"a", "b", "c"}
+	for i := 0; i < b.N; i++ {
+		lengthGreaterThan(list, float64(2))
+	}
+}
+
+func TestLengthLessThan(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, float64(3)}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"a", "b", "c"}, float64(3)}, expected: false},
+		testCase{args: []interface{}{"héllo", float64(6)}, expected: true},
+		testCase{args: []interface{}{"", float64(1)}, expected: true},
+		testCase{args: []interface{}{true, float64(3)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := lengthLessThan(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkLengthLessThan(b *testing.B) {
+	list := []interface{}{"a", "b", "c"}
+	for i := 0; i < b.N; i++ {
+		lengthLessThan(list, float64(4))
+	}
+}
+
+func TestContainsAll(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"a", "b", float64(1)}, []interface{}{"a", float64(1)}}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, []interface{}{}}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, []interface{}{"a", "c"}}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, "a"}, expected: false},
+		testCase{args: []interface{}{"a", []interface{}{"a"}}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := containsAll(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkContainsAll(b *testing.B) {
+	list := []interface{}{"a", "b", "c", "d"}
+	want := []interface{}{"b", "d"}
+	for i := 0; i < b.N; i++ {
+		containsAll(list, want)
+	}
+}
+
+func TestContainsAny(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, []interface{}{"c", "b"}}, expected: true},
+		testCase{args: []interface{}{[]interface{}{float64(1), float64(2)}, []interface{}{float64(2)}}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, []interface{}{"c", "d"}}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, []interface{}{}}, expected: false},
+		testCase{args: []interface{}{"a", []interface{}{"a"}}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := containsAny(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkContainsAny(b *testing.B) {
+	list := []interface{}{"a", "b", "c", "d"}
+	want := []interface{}{"e", "d"}
+	for i := 0; i < b.N; i++ {
+		containsAny(list, want)
+	}
+}
+
+func TestContainsNone(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, []interface{}{"c", "d"}}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, []interface{}{}}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, []interface{}{"c", "b"}}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"a", "b"}, "c"}, expected: false},
+		testCase{args: []interface{}{"a", []interface{}{"c"}}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := containsNone(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkContainsNone(b *testing.B) {
+	list := []interface{}{"a", "b", "c", "d"}
+	want := []interface{}{"e", "f"}
+	for i := 0; i < b.N; i++ {
+		containsNone(list, want)
+	}
+}
+
+func TestSubset(t *testing.T) {
+	allowed := []interface{}{"admin", "editor", "viewer"}
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"editor", "viewer"}, allowed}, expected: true},
+		testCase{args: []interface{}{[]interface{}{}, allowed}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"editor", "owner"}, allowed}, expected: false},
+		testCase{args: []interface{}{"editor", allowed}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := subset(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkSubset(b *testing.B) {
+	roles := []interface{}{"editor", "viewer"}
+	allowed := []interface{}{"admin", "editor", "viewer"}
+	for i := 0; i < b.N; i++ {
+		subset(roles, allowed)
+	}
+}
+
+func TestSuperset(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"admin", "editor"}, []interface{}{"editor"}}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"admin"}, []interface{}{"admin", "editor"}}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"admin"}, "admin"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := superset(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkSuperset(b *testing.B) {
+	roles := []interface{}{"admin", "editor", "viewer"}
+	required := []interface{}{"editor", "viewer"}
+	for i := 0; i < b.N; i++ {
+		superset(roles, required)
+	}
+}
+
+func TestIntersects(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{[]interface{}{"admin", "editor"}, []interface{}{"editor", "owner"}}, expected: true},
+		testCase{args: []interface{}{[]interface{}{"admin"}, []interface{}{"editor", "owner"}}, expected: false},
+		testCase{args: []interface{}{[]interface{}{}, []interface{}{}}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := intersects(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkIntersects(b *testing.B) {
+	roles := []interface{}{"admin", "editor"}
+	other := []interface{}{"viewer", "editor"}
+	for i := 0; i < b.N; i++ {
+		intersects(roles, other)
+	}
+}
+
+func TestStringLengthEqual(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"héllo", float64(5)}, expected: true},
+		testCase{args: []interface{}{"日本語", float64(3)}, expected: true},
+		testCase{args: []interface{}{"", float64(0)}, expected: true},
+		testCase{args: []interface{}{"hello", float64(4)}, expected: false},
+		testCase{args: []interface{}{[]interface{}{"a"}, float64(1)}, expected: false},
+		testCase{args: []interface{}{"hello", "5"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := stringLengthEqual(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkStringLengthEqual(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stringLengthEqual("héllo", float64(5))
+	}
+}
+
+func TestStringLengthGreaterThan(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"héllo", float64(4)}, expected: true},
+		testCase{args: []interface{}{"日本語", float64(3)}, expected: false},
+		testCase{args: []interface{}{map[string]interface{}{"a": "b"}, float64(0)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := stringLengthGreaterThan(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkStringLengthGreaterThan(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stringLengthGreaterThan("héllo", float64(4))
+	}
+}
+
+func TestStringLengthLessThan(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"日本語", float64(4)}, expected: true},
+		testCase{args: []interface{}{"héllo", float64(5)}, expected: false},
+		testCase{args: []interface{}{float64(1), float64(5)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := stringLengthLessThan(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkStringLengthLessThan(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stringLengthLessThan("héllo", float64(6))
+	}
+}
+
+func TestDivisibleBy(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{float64(120), float64(10)}, expected: true},
+		testCase{args: []interface{}{int(120), float64(10)}, expected: true},
+		testCase{args: []interface{}{float64(125), float64(10)}, expected: false},
+		testCase{args: []interface{}{float64(-20), float64(10)}, expected: true},
+		testCase{args: []interface{}{float64(0), float64(10)}, expected: true},
+		testCase{args: []interface{}{float64(1.5), float64(0.5)}, expected: true},
+		testCase{args: []interface{}{float64(10), float64(0)}, expected: false},
+		testCase{args: []interface{}{"120", float64(10)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := divisibleBy(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkDivisibleBy(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		divisibleBy(float64(120), float64(10))
+	}
+}
+
+func TestMaskSet(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{float64(0x0F), float64(0x05)}, expected: true},
+		testCase{args: []interface{}{int(0x0F), int(0x0F)}, expected: true},
+		testCase{args: []interface{}{float64(0x0A), float64(0x05)}, expected: false},
+		testCase{args: []interface{}{float64(0x0B), float64(0x03)}, expected: true},
+		testCase{args: []interface{}{float64(0x0B), float64(0)}, expected: true},
+		testCase{args: []interface{}{float64(1.5), float64(1)}, expected: false},
+		testCase{args: []interface{}{float64(-1), float64(1)}, expected: false},
+		testCase{args: []interface{}{"15", float64(1)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := maskSet(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkMaskSet(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		maskSet(float64(0x0F), float64(0x05))
+	}
+}
+
+func TestMaskClear(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{float64(0x0A), float64(0x05)}, expected: true},
+		testCase{args: []interface{}{float64(0x0B), float64(0x05)}, expected: false},
+		testCase{args: []interface{}{float64(0), float64(0xFF)}, expected: true},
+		testCase{args: []interface{}{float64(0x0A), float64(-1)}, expected: false},
+		testCase{args: []interface{}{float64(0x0A), "5"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := maskClear(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkMaskClear(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		maskClear(float64(0x0A), float64(0x05))
+	}
+}
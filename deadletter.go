@@ -0,0 +1,163 @@
+package grules
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeadLetter is an action that failed. Webhook is set when the action
+// was a webhook, so it can be sent again after it was stored in a file
+type DeadLetter struct {
+	Action   Action    `json:"-"`
+	Webhook  *Webhook  `json:"webhook,omitempty"`
+	Result   Result    `json:"result"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	Time     time.Time `json:"time"`
+}
+
+// DeadLetterQueue stores the actions a Dispatcher could not run, so
+// they are not lost. Drain will remove and return every dead letter in
+// the order they were pushed. Purge will delete every dead letter that
+// match returns true for, and return how many were deleted. It must be
+// safe to use from multiple goroutines
+type DeadLetterQueue interface {
+	Push(l DeadLetter) error
+	Drain() ([]DeadLetter, error)
+	Purge(match func(l DeadLetter) bool) (int, error)
+}
+
+// newDeadLetter will create the dead letter for an action that failed
+func newDeadLetter(a Action, r Result, err error, attempts int) DeadLetter {
+	l := DeadLetter{Action: a, Result: r, Error: err.Error(), Attempts: attempts, Time: Now()}
+	if pa, ok := a.(policyAction); ok {
+		a = pa.Action
+	}
+	if w, ok := a.(Webhook); ok {
+		l.Webhook = &w
+	}
+	return l
+}
+
+// MemoryDeadLetterQueue is a DeadLetterQueue that keeps dead letters in
+// memory
+type MemoryDeadLetterQueue struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+// NewMemoryDeadLetterQueue will create a new empty in memory queue
+func NewMemoryDeadLetterQueue() *MemoryDeadLetterQueue {
+	return &MemoryDeadLetterQueue{}
+}
+
+// Push will add the dead letter to the queue
+func (q *MemoryDeadLetterQueue) Push(l DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.letters = append(q.letters, l)
+	return nil
+}
+
+// Drain will remove and return every dead letter
+func (q *MemoryDeadLetterQueue) Drain() ([]DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	letters := q.letters
+	q.letters = nil
+	if letters == nil {
+		letters = []DeadLetter{}
+	}
+	return letters, nil
+}
+
+// Purge will delete the dead letters match returns true for
+func (q *MemoryDeadLetterQueue) Purge(match func(l DeadLetter) bool) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.letters[:0]
+	for _, l := range q.letters {
+		if !match(l) {
+			kept = append(kept, l)
+		}
+	}
+	n := len(q.letters) - len(kept)
+	for i := len(kept); i < len(q.letters); i++ {
+		q.letters[i] = DeadLetter{}
+	}
+	q.letters = kept
+	return n, nil
+}
+
+// FileDeadLetterQueue is a DeadLetterQueue that appends dead letters to
+// a file as lines of JSON, so they survive restarts. Drained dead
+// letters have an Action only if they were webhooks
+type FileDeadLetterQueue struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileDeadLetterQueue will create a queue stored in the file at the
+// path, which is created when the first dead letter is pushed
+func NewFileDeadLetterQueue(path string) *FileDeadLetterQueue {
+	return &FileDeadLetterQueue{path: path}
+}
+
+// Push will append the dead letter to the file
+func (q *FileDeadLetterQueue) Push(l DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	f, err := os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(l); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Drain will read every dead letter from the file and remove it
+func (q *FileDeadLetterQueue) Drain() ([]DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	letters := []DeadLetter{}
+	err := readJSONLines(q.path, func(line []byte) error {
+		var l DeadLetter
+		if err := json.Unmarshal(line, &l); err != nil {
+			return err
+		}
+		if l.Webhook != nil {
+			l.Action = *l.Webhook
+		}
+		letters = append(letters, l)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(letters) == 0 {
+		return letters, nil
+	}
+	return letters, os.Remove(q.path)
+}
+
+// Purge will delete the dead letters match returns true for, by
+// rewriting the file without them
+func (q *FileDeadLetterQueue) Purge(match func(l DeadLetter) bool) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return purgeJSONLines(q.path, func(line []byte) (bool, error) {
+		var l DeadLetter
+		if err := json.Unmarshal(line, &l); err != nil {
+			return false, err
+		}
+		if l.Webhook != nil {
+			l.Action = *l.Webhook
+		}
+		return match(l), nil
+	})
+}
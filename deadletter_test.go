@@ -0,0 +1,72 @@
+package grules
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeadLetters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "grules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	queues := []DeadLetterQueue{
+		NewMemoryDeadLetterQueue(),
+		NewFileDeadLetterQueue(filepath.Join(dir, "dead-letters.jsonl")),
+	}
+	for i, q := range queues {
+		e := NewEngine()
+		e.Composites = []Composite{
+			Composite{
+				ID:       "a",
+				Operator: OperatorAnd,
+				Rules:    []Rule{Rule{Comparator: "exists", Path: "id"}},
+				Webhooks: []Webhook{Webhook{URL: server.URL}},
+			},
+		}
+		d := NewDispatcher(DispatcherConfig{
+			Retries:     2,
+			Backoff:     time.Millisecond,
+			DeadLetters: q,
+			Actions: map[string][]Action{"a": []Action{
+				ActionFunc(func(ctx context.Context, r Result) error { return Permanent(errors.New("failed")) }),
+				WithPolicy(ActionFunc(func(ctx context.Context, r Result) error { return errors.New("ignored") }), PolicyIgnore),
+			}},
+		})
+		d.Dispatch(e, map[string]interface{}{"id": "1"})
+		d.Close()
+
+		letters, err := q.Drain()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(letters) != 2 {
+			t.Fatalf("expected case %d to have 2 dead letters, got %+v", i, letters)
+		}
+		webhook, action := letters[0], letters[1]
+		if webhook.Webhook == nil || webhook.Webhook.URL != server.URL || webhook.Attempts != 3 || !errors.Is(webhook.Action.Execute(context.Background(), webhook.Result), errWebhookStatus) {
+			t.Fatalf("expected case %d to keep the webhook, got %+v", i, webhook)
+		}
+		if action.Webhook != nil || action.Error != "failed" || action.Attempts != 1 || action.Result.Props["id"] != "1" || action.Result.Composite.ID != "a" {
+			t.Fatalf("expected case %d to keep the action's result, got %+v", i, action)
+		}
+
+		if letters, err := q.Drain(); err != nil || len(letters) != 0 {
+			t.Fatalf("expected case %d to be empty after it was drained, got %v %v", i, letters, err)
+		}
+	}
+}
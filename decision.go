@@ -0,0 +1,324 @@
+package grules
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Decision is a record of an engine being evaluated for a subject, such
+// as a customer. InputHash is the SHA-256 of the props as JSON and
+// Version is the SHA-256 of the engine's stable JSON, so the decision
+// can be matched to the props and rules it was made with without
+// storing them. Matched is the IDs of the top level composites that
+// were true
+type Decision struct {
+	Subject   string    `json:"subject"`
+	Time      time.Time `json:"time"`
+	InputHash string    `json:"input_hash"`
+	Version   string    `json:"version"`
+	Result    bool      `json:"result"`
+	Matched   []string  `json:"matched"`
+}
+
+// DecisionStore stores decisions. Query will return the decisions for
+// a subject made from from, inclusive, until to, exclusive, in the
+// order they were recorded. A zero from or to is unbounded. Purge will
+// delete every decision that match returns true for, and return how
+// many were deleted. It must be safe to use from multiple goroutines
+type DecisionStore interface {
+	Record(d Decision) error
+	Query(subject string, from, to time.Time) ([]Decision, error)
+	Purge(match func(d Decision) bool) (int, error)
+}
+
+// DecisionLog evaluates an engine and records every decision to a
+// store
+type DecisionLog struct {
+	engine      Engine
+	version     string
+	store       DecisionStore
+	deadLetters []subjectQueue
+}
+
+// SubjectFunc returns the subject that props are about, such as the
+// value at customer.id
+type SubjectFunc func(props map[string]interface{}) string
+
+// subjectQueue is a dead letter queue purged along with the decisions
+// of a subject
+type subjectQueue struct {
+	queue   DeadLetterQueue
+	subject SubjectFunc
+}
+
+// NewDecisionLog will create a decision log for the engine. Create a
+// new one when the engine changes, so the decisions record the new
+// version
+func NewDecisionLog(e Engine, store DecisionStore) (*DecisionLog, error) {
+	b, err := e.MarshalIndentStable()
+	if err != nil {
+		return nil, err
+	}
+	return &DecisionLog{engine: e, version: hashHex(b), store: store}, nil
+}
+
+// Version will return the version of the engine recorded in decisions
+func (l *DecisionLog) Version() string {
+	return l.version
+}
+
+// Evaluate will evaluate the engine against the props like Evaluate,
+// and record the decision for the subject. The result is returned
+// along with any error recording it
+func (l *DecisionLog) Evaluate(subject string, props map[string]interface{}) (bool, error) {
+	results, passed := l.engine.results(props)
+	input, err := json.Marshal(props)
+	if err != nil {
+		return passed, err
+	}
+	d := Decision{
+		Subject:   subject,
+		Time:      Now(),
+		InputHash: hashHex(input),
+		Version:   l.version,
+		Result:    passed,
+		Matched:   make([]string, len(results)),
+	}
+	for i, r := range results {
+		d.Matched[i] = r.Composite.ID
+	}
+	return passed, l.store.Record(d)
+}
+
+// Query will return the decisions for the subject made from from until
+// to, see DecisionStore
+func (l *DecisionLog) Query(subject string, from, to time.Time) ([]Decision, error) {
+	return l.store.Query(subject, from, to)
+}
+
+// AddDeadLetters will make PurgeSubject also delete the dead letters in
+// the queue whose props are about the subject, as told by subject.
+// Dead letters keep the props of the result whose action failed, so
+// add every queue a Dispatcher using the same props pushes to. Queues
+// must be added before the log is used
+func (l *DecisionLog) AddDeadLetters(q DeadLetterQueue, subject SubjectFunc) {
+	l.deadLetters = append(l.deadLetters, subjectQueue{queue: q, subject: subject})
+}
+
+// PurgeSubject will delete every decision for the subject, and every
+// dead letter about it in the queues added with AddDeadLetters, for
+// when their data has to be erased, and return how many were deleted
+func (l *DecisionLog) PurgeSubject(subject string) (int, error) {
+	n, err := l.store.Purge(func(d Decision) bool {
+		return d.Subject == subject
+	})
+	if err != nil {
+		return n, err
+	}
+	for _, sq := range l.deadLetters {
+		purged, err := sq.queue.Purge(func(dl DeadLetter) bool {
+			return sq.subject(dl.Result.Props) == subject
+		})
+		n += purged
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Expire will delete every decision older than maxAge, and return how
+// many were deleted. Call it periodically to keep decisions for a
+// retention period
+func (l *DecisionLog) Expire(maxAge time.Duration) (int, error) {
+	cutoff := Now().Add(-maxAge)
+	return l.store.Purge(func(d Decision) bool {
+		return d.Time.Before(cutoff)
+	})
+}
+
+// hashHex will return the SHA-256 of b in hex
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// inRange will return true if the decision is for the subject and was
+// made in the time range
+func (d Decision) inRange(subject string, from, to time.Time) bool {
+	return d.Subject == subject && (from.IsZero() || !d.Time.Before(from)) && (to.IsZero() || d.Time.Before(to))
+}
+
+// MemoryDecisionStore is a DecisionStore that keeps decisions in memory
+type MemoryDecisionStore struct {
+	mu        sync.RWMutex
+	decisions []Decision
+}
+
+// NewMemoryDecisionStore will create a new empty in memory store
+func NewMemoryDecisionStore() *MemoryDecisionStore {
+	return &MemoryDecisionStore{}
+}
+
+// Record will add the decision to the store
+func (s *MemoryDecisionStore) Record(d Decision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions = append(s.decisions, d)
+	return nil
+}
+
+// Query will return the decisions for the subject in the time range
+func (s *MemoryDecisionStore) Query(subject string, from, to time.Time) ([]Decision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	decisions := []Decision{}
+	for _, d := range s.decisions {
+		if d.inRange(subject, from, to) {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions, nil
+}
+
+// Purge will delete the decisions match returns true for
+func (s *MemoryDecisionStore) Purge(match func(d Decision) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.decisions[:0]
+	for _, d := range s.decisions {
+		if !match(d) {
+			kept = append(kept, d)
+		}
+	}
+	n := len(s.decisions) - len(kept)
+	for i := len(kept); i < len(s.decisions); i++ {
+		s.decisions[i] = Decision{}
+	}
+	s.decisions = kept
+	return n, nil
+}
+
+// FileDecisionStore is a DecisionStore that appends decisions to a file
+// as lines of JSON. Queries read the whole file
+type FileDecisionStore struct {
+	mu   sync.RWMutex
+	path string
+}
+
+// NewFileDecisionStore will create a store in the file at the path,
+// which is created when the first decision is recorded
+func NewFileDecisionStore(path string) *FileDecisionStore {
+	return &FileDecisionStore{path: path}
+}
+
+// Record will append the decision to the file
+func (s *FileDecisionStore) Record(d Decision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(d); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Query will return the decisions in the file for the subject in the
+// time range
+func (s *FileDecisionStore) Query(subject string, from, to time.Time) ([]Decision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	decisions := []Decision{}
+	err := readJSONLines(s.path, func(line []byte) error {
+		var d Decision
+		if err := json.Unmarshal(line, &d); err != nil {
+			return err
+		}
+		if d.inRange(subject, from, to) {
+			decisions = append(decisions, d)
+		}
+		return nil
+	})
+	return decisions, err
+}
+
+// Purge will delete the decisions match returns true for, by
+// rewriting the file without them
+func (s *FileDecisionStore) Purge(match func(d Decision) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return purgeJSONLines(s.path, func(line []byte) (bool, error) {
+		var d Decision
+		if err := json.Unmarshal(line, &d); err != nil {
+			return false, err
+		}
+		return match(d), nil
+	})
+}
+
+// readJSONLines will call fn with every line of the file, a file that
+// does not exist has no lines
+func readJSONLines(path string, fn func(line []byte) error) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// purgeJSONLines will delete the lines of the file that match returns
+// true for, by writing the lines that are kept to a new file that
+// replaces the old one, and return how many were deleted
+func purgeJSONLines(path string, match func(line []byte) (bool, error)) (int, error) {
+	var kept bytes.Buffer
+	n := 0
+	err := readJSONLines(path, func(line []byte) error {
+		purge, err := match(line)
+		if err != nil {
+			return err
+		}
+		if purge {
+			n++
+			return nil
+		}
+		kept.Write(line)
+		kept.WriteByte('\n')
+		return nil
+	})
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, kept.Bytes(), 0600); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return n, nil
+}
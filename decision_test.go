@@ -0,0 +1,154 @@
+package grules
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDecisionLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{ID: "adult", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "gte", Path: "age", Value: float64(18)}}},
+		Composite{ID: "resident", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "eq", Path: "country", Value: "NZ"}}},
+	}
+
+	stores := []DecisionStore{
+		NewMemoryDecisionStore(),
+		NewFileDecisionStore(filepath.Join(dir, "decisions.jsonl")),
+	}
+	for i, store := range stores {
+		log, err := NewDecisionLog(e, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		if passed, err := log.Evaluate("ann", map[string]interface{}{"age": float64(30), "country": "NZ"}); !passed || err != nil {
+			t.Fatalf("expected case %d to pass, got %v %v", i, passed, err)
+		}
+		if passed, err := log.Evaluate("bob", map[string]interface{}{"age": float64(30), "country": "AU"}); passed || err != nil {
+			t.Fatalf("expected case %d to fail, got %v %v", i, passed, err)
+		}
+		middle := time.Now()
+		if _, err := log.Evaluate("ann", map[string]interface{}{"country": "NZ", "age": float64(30)}); err != nil {
+			t.Fatal(err)
+		}
+
+		decisions, err := log.Query("ann", time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(decisions) != 2 {
+			t.Fatalf("expected case %d to have 2 decisions for ann, got %+v", i, decisions)
+		}
+		d := decisions[0]
+		if d.Subject != "ann" || !d.Result || len(d.Matched) != 2 || d.Matched[1] != "resident" || d.Version != log.Version() || d.Time.Before(start) {
+			t.Fatalf("expected case %d to record the decision, got %+v", i, d)
+		}
+		if d.InputHash != decisions[1].InputHash || len(d.InputHash) != 64 {
+			t.Fatalf("expected case %d to hash the same props the same, got %s and %s", i, d.InputHash, decisions[1].InputHash)
+		}
+
+		decisions, err = log.Query("bob", start, middle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(decisions) != 1 || decisions[0].Result || len(decisions[0].Matched) != 1 || decisions[0].Matched[0] != "adult" {
+			t.Fatalf("expected case %d to have bob's decision, got %+v", i, decisions)
+		}
+		if decisions, err := log.Query("ann", middle, time.Time{}); err != nil || len(decisions) != 1 {
+			t.Fatalf("expected case %d to have 1 decision for ann after the middle, got %+v %v", i, decisions, err)
+		}
+	}
+
+	changed := e
+	changed.Composites = changed.Composites[:1]
+	log, err := NewDecisionLog(changed, NewMemoryDecisionStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if original, _ := NewDecisionLog(e, NewMemoryDecisionStore()); original.Version() == log.Version() {
+		t.Fatal("expected a changed engine to have a new version")
+	}
+}
+
+func TestDecisionPurge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	defer func(now func() time.Time) { Now = now }(Now)
+	Now = func() time.Time {
+		return now
+	}
+
+	stores := []DecisionStore{
+		NewMemoryDecisionStore(),
+		NewFileDecisionStore(filepath.Join(dir, "decisions.jsonl")),
+	}
+	queues := []DeadLetterQueue{
+		NewMemoryDeadLetterQueue(),
+		NewFileDeadLetterQueue(filepath.Join(dir, "dead-letters.jsonl")),
+	}
+	old := now.AddDate(0, 0, -40)
+	for i, store := range stores {
+		for _, d := range []Decision{
+			Decision{Subject: "ann", Time: old},
+			Decision{Subject: "bob", Time: old},
+			Decision{Subject: "ann", Time: now},
+			Decision{Subject: "bob", Time: now},
+		} {
+			if err := store.Record(d); err != nil {
+				t.Fatal(err)
+			}
+		}
+		q := queues[i]
+		for _, subject := range []string{"ann", "bob"} {
+			l := newDeadLetter(LogAction(nil), Result{Props: map[string]interface{}{"customer": subject}}, errors.New("failed"), 1)
+			if err := q.Push(l); err != nil {
+				t.Fatal(err)
+			}
+		}
+		log, err := NewDecisionLog(NewEngine(), store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		log.AddDeadLetters(q, func(props map[string]interface{}) string {
+			subject, _ := props["customer"].(string)
+			return subject
+		})
+
+		if n, err := log.PurgeSubject("ann"); n != 3 || err != nil {
+			t.Fatalf("expected case %d to purge 2 decisions and 1 dead letter for ann, got %d %v", i, n, err)
+		}
+		if decisions, err := log.Query("ann", time.Time{}, time.Time{}); len(decisions) != 0 || err != nil {
+			t.Fatalf("expected case %d to have no decisions for ann, got %+v %v", i, decisions, err)
+		}
+		if n, err := log.Expire(30 * 24 * time.Hour); n != 1 || err != nil {
+			t.Fatalf("expected case %d to expire 1 decision, got %d %v", i, n, err)
+		}
+		decisions, err := log.Query("bob", time.Time{}, time.Time{})
+		if len(decisions) != 1 || err != nil || !decisions[0].Time.Equal(now) {
+			t.Fatalf("expected case %d to keep bob's recent decision, got %+v %v", i, decisions, err)
+		}
+		if n, err := log.PurgeSubject("carol"); n != 0 || err != nil {
+			t.Fatalf("expected case %d to purge nothing for carol, got %d %v", i, n, err)
+		}
+		letters, err := q.Drain()
+		if len(letters) != 1 || err != nil || letters[0].Result.Props["customer"] != "bob" || !letters[0].Time.Equal(now) {
+			t.Fatalf("expected case %d to keep bob's dead letter, got %+v %v", i, letters, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package grules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StringifyDiff will generate a human readable diff between two
+// versions of an engine, in the style of a unified diff. Each composite
+// and rule is written on its own line, indented under its parent, and
+// lines that were removed or added are prefixed with "- " or "+ ". A
+// changed rule shows as its old line removed and its new line added.
+// Composites also show their aggregate, weights and webhooks, and rules
+// their OnMissing. It will return an empty string if the engines have
+// the same rules
+func StringifyDiff(from, to Engine) string {
+	a, b := diffLines(from), diffLines(to)
+
+	// Lines that are the same at the start and the end are left out of
+	// the table, since most diffs only change a few lines
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	if prefix == len(a) && len(a) == len(b) {
+		return ""
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// ma[i:] and mb[j:]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			switch {
+			case ma[i] == mb[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for _, line := range a[:prefix] {
+		sb.WriteString("  " + line + "\n")
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			sb.WriteString("  " + ma[i] + "\n")
+			i++
+			j++
+		case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + ma[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + mb[j] + "\n")
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		sb.WriteString("  " + line + "\n")
+	}
+	return sb.String()
+}
+
+// diffLines will write every composite, webhook and rule of the engine
+// on its own line, indented by two spaces for each level of nesting
+func diffLines(e Engine) []string {
+	lines := []string{}
+	for _, c := range e.Composites {
+		lines = appendDiffLines(lines, c, "")
+	}
+	return lines
+}
+
+// appendDiffLines will append the lines of a composite and its children
+func appendDiffLines(lines []string, c Composite, indent string) []string {
+	header := c.Operator
+	if _, quantified := quantifiers[c.Operator]; quantified {
+		header = fmt.Sprintf("%s %d of", c.Operator, c.N)
+	}
+	if c.ID != "" {
+		header += " [" + c.ID + "]"
+	}
+	if c.Aggregate != "" {
+		header += " aggregate:" + c.Aggregate
+	}
+	if len(c.Weights) > 0 {
+		header += fmt.Sprintf(" weights:%v", c.Weights)
+	}
+	lines = append(lines, indent+header)
+
+	for _, w := range c.Webhooks {
+		line := "webhook " + w.URL
+		if w.Method != "" {
+			line += " method:" + w.Method
+		}
+		if len(w.Headers) > 0 {
+			line += fmt.Sprintf(" headers:%v", w.Headers)
+		}
+		if w.Payload != "" {
+			line += fmt.Sprintf(" payload:%q", w.Payload)
+		}
+		lines = append(lines, indent+"  "+line)
+	}
+	for _, r := range c.Rules {
+		line := r.stringify()
+		if r.OnMissing != "" {
+			line += " on_missing:" + r.OnMissing
+		}
+		lines = append(lines, indent+"  "+line)
+	}
+	for _, cc := range c.Composites {
+		lines = appendDiffLines(lines, cc, indent+"  ")
+	}
+	return lines
+}
@@ -0,0 +1,104 @@
+package grules
+
+import (
+	"testing"
+)
+
+func TestStringifyDiff(t *testing.T) {
+	from, err := NewJSONEngine([]byte(`{"composites":[
+		{"id":"age","operator":"and","rules":[
+			{"comparator":"gte","path":"user.age","value":18},
+			{"comparator":"eq","path":"user.active","value":true}
+		]},
+		{"operator":"or","composites":[
+			{"operator":"atleast","n":2,"rules":[
+				{"comparator":"eq","path":"user.country","value":"US"},
+				{"comparator":"oneof","path":"user.role","value":["admin","owner"]}
+			]}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := NewJSONEngine([]byte(`{"composites":[
+		{"id":"age","operator":"and","rules":[
+			{"comparator":"gte","path":"user.age","value":21},
+			{"comparator":"eq","path":"user.active","value":true}
+		]},
+		{"operator":"or","composites":[
+			{"operator":"atleast","n":2,"rules":[
+				{"comparator":"eq","path":"user.country","value":"US"},
+				{"comparator":"oneof","path":"user.role","value":["admin","owner"]},
+				{"comparator":"eq","path":"user.verified","value":true}
+			]}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `  and [age]
+-   {user.age gte 18}
++   {user.age gte 21}
+    {user.active eq true}
+  or
+    atleast 2 of
+      {user.country eq US}
+      {user.role oneof [admin owner]}
++     {user.verified eq true}
+`
+	actual := StringifyDiff(from, to)
+	if expected != actual {
+		t.Fatalf("expected %s but got %s", expected, actual)
+	}
+
+	if diff := StringifyDiff(to, to); diff != "" {
+		t.Fatalf("expected no diff but got %s", diff)
+	}
+
+	expected = `- and [age]
+-   {user.age gte 21}
+-   {user.active eq true}
+- or
+-   atleast 2 of
+-     {user.country eq US}
+-     {user.role oneof [admin owner]}
+-     {user.verified eq true}
+`
+	actual = StringifyDiff(to, NewEngine())
+	if expected != actual {
+		t.Fatalf("expected %s but got %s", expected, actual)
+	}
+
+	// Changes that do not touch the operators, comparators or values
+	// still change how the engine behaves
+	from, err = NewJSONEngine([]byte(`{"composites":[
+		{"operator":"and","rules":[
+			{"comparator":"gte","path":"user.age","value":18},
+			{"comparator":"eq","path":"user.active","value":true}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err = NewJSONEngine([]byte(`{"composites":[
+		{"operator":"and","aggregate":"weighted","weights":[2,1],"webhooks":[{"url":"https://example.com/hook","method":"PUT","payload":"{}"}],"rules":[
+			{"comparator":"gte","path":"user.age","value":18,"on_missing":"skip"},
+			{"comparator":"eq","path":"user.active","value":true}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `- and
+-   {user.age gte 18}
++ and aggregate:weighted weights:[2 1]
++   webhook https://example.com/hook method:PUT payload:"{}"
++   {user.age gte 18} on_missing:skip
+    {user.active eq true}
+`
+	actual = StringifyDiff(from, to)
+	if expected != actual {
+		t.Fatalf("expected %s but got %s", expected, actual)
+	}
+}
@@ -0,0 +1,223 @@
+package grules
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DispatcherConfig is how a Dispatcher runs actions. Zero values use
+// the defaults: 1 worker, a queue of 100, 3 retries, a backoff of
+// 100ms, a client with a 10s timeout for webhooks and a close timeout
+// of 10s, use -1 Retries to never retry. Actions are run for the top level composites with
+// the ID they are keyed by, after the composite's webhooks. OnError is
+// called with every action that failed, after its last retry, unless
+// its policy is PolicyIgnore, and those actions are pushed to
+// DeadLetters when it is set. Errors pushing to DeadLetters are also
+// given to OnError
+type DispatcherConfig struct {
+	Workers      int
+	QueueSize    int
+	Retries      int
+	Backoff      time.Duration
+	CloseTimeout time.Duration
+	Client       *http.Client
+	Actions      map[string][]Action
+	DeadLetters  DeadLetterQueue
+	OnError      func(a Action, r Result, err error)
+}
+
+// Dispatcher evaluates engines and runs the actions of the top level
+// composites that are true, from a pool of workers so evaluation does
+// not wait for them. Failed actions are retried with exponential
+// backoff. Actions are given a context that is canceled when Close
+// times out. It is safe to use from multiple goroutines
+type Dispatcher struct {
+	config DispatcherConfig
+	queue  chan actionJob
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// actionJob is a single action waiting to be run
+type actionJob struct {
+	action Action
+	result Result
+}
+
+// NewDispatcher will create a new dispatcher and start its workers
+func NewDispatcher(config DispatcherConfig) *Dispatcher {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 100
+	}
+	if config.Retries == 0 {
+		config.Retries = 3
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 100 * time.Millisecond
+	}
+	if config.CloseTimeout <= 0 {
+		config.CloseTimeout = 10 * time.Second
+	}
+	if config.Client == nil {
+		config.Client = defaultClient
+	}
+
+	d := &Dispatcher{
+		config: config,
+		queue:  make(chan actionJob, config.QueueSize),
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.wg.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go d.work()
+	}
+	return d
+}
+
+// Dispatch will evaluate the engine like Evaluate, and queue the
+// actions of every top level composite that is true, even if the
+// engine as a whole is false. It blocks while the queue is full. The
+// actions are given a copy of the props, so the props can be changed
+// once it returns
+func (d *Dispatcher) Dispatch(e Engine, props map[string]interface{}) bool {
+	results, passed := e.results(props)
+	if len(results) > 0 {
+		props = copyValue(props).(map[string]interface{})
+	}
+	for _, r := range results {
+		r.Props = props
+		for _, w := range r.Composite.Webhooks {
+			w.client = d.config.Client
+			d.queue <- actionJob{action: w, result: r}
+		}
+		for _, a := range d.config.Actions[r.Composite.ID] {
+			d.queue <- actionJob{action: a, result: r}
+		}
+	}
+	return passed
+}
+
+// Close will stop accepting actions and wait for the queued actions to
+// be run. If they are not done within CloseTimeout the context of the
+// actions is canceled, so the ones that are waiting on it return and
+// the rest are given a canceled context, and Close waits for them to
+// return. Dispatch must not be called after Close
+func (d *Dispatcher) Close() {
+	close(d.queue)
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(d.config.CloseTimeout):
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+}
+
+// work will run queued actions until the queue is closed
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for job := range d.queue {
+		policy := policyOf(job.action)
+		attempts, err := d.run(job, policy)
+		if err == nil || policy == PolicyIgnore {
+			continue
+		}
+		d.fail(job, err)
+		if d.config.DeadLetters != nil {
+			l := newDeadLetter(job.action, job.result, err, attempts)
+			if err := d.config.DeadLetters.Push(l); err != nil {
+				d.fail(job, err)
+			}
+		}
+	}
+}
+
+// fail will give the error of the job to OnError
+func (d *Dispatcher) fail(job actionJob, err error) {
+	if d.config.OnError != nil {
+		d.config.OnError(job.action, job.result, err)
+	}
+}
+
+// run will run the action, retrying it while its policy allows and it
+// may succeed, and return how many times it was run
+func (d *Dispatcher) run(job actionJob, policy ErrorPolicy) (int, error) {
+	backoff := d.config.Backoff
+	for attempt := 0; ; attempt++ {
+		err := job.action.Execute(d.ctx, job.result)
+		if err == nil || policy != PolicyRetry || isPermanent(err) || attempt >= d.config.Retries {
+			return attempt + 1, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-d.ctx.Done():
+			timer.Stop()
+			return attempt + 1, err
+		}
+		backoff *= 2
+	}
+}
+
+// copyValue will return a deep copy of the maps and slices of decoded
+// JSON in v. Other values are shared
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			m[k] = copyValue(elem)
+		}
+		return m
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, elem := range v {
+			values[i] = copyValue(elem)
+		}
+		return values
+	}
+	return v
+}
+
+// Results will return a Result for every top level composite that is
+// true for the props. Skipped composites are left out
+func (e Engine) Results(props map[string]interface{}) []Result {
+	results, _ := e.results(props)
+	return results
+}
+
+// results will return the composites that are true for the props,
+// along with whether the engine passed, without stopping at the first
+// composite that is false
+func (e Engine) results(props map[string]interface{}) ([]Result, bool) {
+	if !e.prepare(props) {
+		return []Result{}, false
+	}
+	results := []Result{}
+	passed := true
+	for _, c := range e.Composites {
+		res, skipped := c.check(props, &e)
+		switch {
+		case skipped:
+		case res:
+			results = append(results, Result{Composite: c, Props: props})
+		default:
+			passed = false
+		}
+	}
+	if e.err != nil {
+		return []Result{}, false
+	}
+	return results, passed
+}
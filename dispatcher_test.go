@@ -0,0 +1,170 @@
+package grules
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dispatcherEngine is an engine with a single composite that is true
+// for props with an id
+func dispatcherEngine() Engine {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{ID: "a", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "exists", Path: "id"}}},
+	}
+	return e
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	errFailed := errors.New("failed")
+	cases := []struct {
+		retries  int
+		failures int
+		attempts int
+		failed   bool
+	}{
+		{retries: 0, failures: 10, attempts: 4, failed: true},
+		{retries: -1, failures: 10, attempts: 1, failed: true},
+		{retries: 2, failures: 1, attempts: 2, failed: false},
+		{retries: 2, failures: 2, attempts: 3, failed: false},
+		{retries: 2, failures: 3, attempts: 3, failed: true},
+	}
+	for i, c := range cases {
+		attempts := 0
+		var reported error
+		action := ActionFunc(func(ctx context.Context, r Result) error {
+			attempts++
+			if attempts <= c.failures {
+				return errFailed
+			}
+			return nil
+		})
+		d := NewDispatcher(DispatcherConfig{
+			Retries: c.retries,
+			Backoff: time.Millisecond,
+			Actions: map[string][]Action{"a": []Action{action}},
+			OnError: func(a Action, r Result, err error) { reported = err },
+		})
+		d.Dispatch(dispatcherEngine(), map[string]interface{}{"id": "1"})
+		d.Close()
+		if attempts != c.attempts || (reported != nil) != c.failed {
+			t.Fatalf("expected case %d to be %v attempts failing %v, got %v failing %v", i, c.attempts, c.failed, attempts, reported)
+		}
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	backoff := 20 * time.Millisecond
+	times := []time.Time{}
+	d := NewDispatcher(DispatcherConfig{
+		Retries: 2,
+		Backoff: backoff,
+		Actions: map[string][]Action{"a": []Action{ActionFunc(func(ctx context.Context, r Result) error {
+			times = append(times, time.Now())
+			return errors.New("failed")
+		})}},
+	})
+	d.Dispatch(dispatcherEngine(), map[string]interface{}{"id": "1"})
+	d.Close()
+
+	if len(times) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < backoff {
+			t.Fatalf("expected attempt %d to wait at least %v, got %v", i, backoff, gap)
+		}
+		backoff *= 2
+	}
+}
+
+func TestDispatcherClose(t *testing.T) {
+	var mu sync.Mutex
+	ran := 0
+	d := NewDispatcher(DispatcherConfig{
+		Actions: map[string][]Action{"a": []Action{ActionFunc(func(ctx context.Context, r Result) error {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			ran++
+			mu.Unlock()
+			return nil
+		})}},
+	})
+	for i := 0; i < 10; i++ {
+		d.Dispatch(dispatcherEngine(), map[string]interface{}{"id": "1"})
+	}
+	d.Close()
+	if ran != 10 {
+		t.Fatalf("expected Close to wait for 10 actions, got %d", ran)
+	}
+
+	// An action that never returns on its own is canceled once Close
+	// times out
+	var reported error
+	d = NewDispatcher(DispatcherConfig{
+		Retries:      -1,
+		CloseTimeout: 10 * time.Millisecond,
+		Actions: map[string][]Action{"a": []Action{ActionFunc(func(ctx context.Context, r Result) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})}},
+		OnError: func(a Action, r Result, err error) { reported = err },
+	})
+	d.Dispatch(dispatcherEngine(), map[string]interface{}{"id": "1"})
+	done := make(chan struct{})
+	go func() {
+		d.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Close to cancel a hung action")
+	}
+	if !errors.Is(reported, context.Canceled) {
+		t.Fatalf("expected the hung action to be canceled, got %v", reported)
+	}
+}
+
+func TestDispatchCopiesProps(t *testing.T) {
+	results := make(chan Result, 1)
+	release := make(chan struct{})
+	d := NewDispatcher(DispatcherConfig{
+		Actions: map[string][]Action{"a": []Action{ActionFunc(func(ctx context.Context, r Result) error {
+			<-release
+			results <- r
+			return nil
+		})}},
+	})
+	props := map[string]interface{}{
+		"id":   "1",
+		"user": map[string]interface{}{"tags": []interface{}{"vip"}},
+	}
+	d.Dispatch(dispatcherEngine(), props)
+	props["id"] = "2"
+	props["user"].(map[string]interface{})["tags"].([]interface{})[0] = "new"
+	close(release)
+	d.Close()
+
+	r := <-results
+	if r.Props["id"] != "1" || r.Props["user"].(map[string]interface{})["tags"].([]interface{})[0] != "vip" {
+		t.Fatalf("expected the action to get the props as they were dispatched, got %v", r.Props)
+	}
+}
+
+func TestResults(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{ID: "a", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "eq", Path: "x", Value: float64(1)}}},
+		Composite{ID: "b", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "eq", Path: "x", Value: float64(2)}}},
+		Composite{ID: "c", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "eq", Path: "y", Value: float64(1), OnMissing: MissingSkip}}},
+		Composite{ID: "d", Operator: OperatorOr, Rules: []Rule{Rule{Comparator: "gt", Path: "x", Value: float64(0)}}},
+	}
+	results := e.Results(map[string]interface{}{"x": float64(1)})
+	if len(results) != 2 || results[0].Composite.ID != "a" || results[1].Composite.ID != "d" {
+		t.Fatalf("expected a and d to be true, got %+v", results)
+	}
+}
@@ -0,0 +1,108 @@
+package grules
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrUnknownComparator is returned when a rule references a
+	// comparator that has not been added to the engine
+	ErrUnknownComparator = errors.New("grules: unknown comparator")
+	// ErrUnknownOperator is returned when a composite references an
+	// operator that has not been added to the engine
+	ErrUnknownOperator = errors.New("grules: unknown operator")
+	// ErrPathNotFound is returned when a rule's path does not exist
+	// in the props
+	ErrPathNotFound = errors.New("grules: path not found")
+	// ErrTypeMismatch is returned when the value at a rule's path can
+	// not be compared with the rule's value
+	ErrTypeMismatch = errors.New("grules: type mismatch")
+	// ErrDepthExceeded is returned when composites are nested deeper
+	// than MaxDepth
+	ErrDepthExceeded = errors.New("grules: max depth exceeded")
+	// ErrCompositeNotFound is returned when there is no composite with
+	// a given ID
+	ErrCompositeNotFound = errors.New("grules: composite not found")
+	// ErrDuplicateComposite is returned when a composite's ID is
+	// already used
+	ErrDuplicateComposite = errors.New("grules: duplicate composite id")
+	// ErrUnknownAggregate is returned when a composite's Aggregate is
+	// not one of the Aggregate constants
+	ErrUnknownAggregate = errors.New("grules: unknown aggregate")
+	// ErrSyntax is returned when an expression can not be parsed
+	ErrSyntax = errors.New("grules: syntax error")
+)
+
+// MaxDepth is the deepest that composites may be nested before
+// Validate will return ErrDepthExceeded
+var MaxDepth = 64
+
+// RuleError is an error that happened at a specific node of an
+// engine. Node is the location of the node in the document, for
+// example "composites[2].rules[0]", and Path is the path of the rule
+// at that node, if the node is a rule. Use errors.Is or errors.As to
+// get at the underlying error
+type RuleError struct {
+	Node string
+	Path string
+	Err  error
+}
+
+// Error will return the location of the node followed by the error
+func (e *RuleError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %v", e.Node, e.Err)
+	}
+	return fmt.Sprintf("%s (%s): %v", e.Node, e.Path, e.Err)
+}
+
+// Unwrap will return the underlying error
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// typeChecks is a map of the comparators that can only compare values
+// of certain types, to a function that returns true if a and b are
+// those types
+var typeChecks = map[string]func(a, b interface{}) bool{
+	"eq":          sameType,
+	"neq":         sameType,
+	"gt":          orderedTypes,
+	"gte":         orderedTypes,
+	"lt":          orderedTypes,
+	"lte":         orderedTypes,
+	"startswith":  stringTypes,
+	"nstartswith": stringTypes,
+	"endswith":    stringTypes,
+	"nendswith":   stringTypes,
+}
+
+// typeMismatch will return true if the comparator can not compare a
+// with b because of their types
+func typeMismatch(comparator string, a, b interface{}) bool {
+	check, ok := typeChecks[comparator]
+	return ok && !check(a, b)
+}
+
+// sameType will return true if a and b have the same type
+func sameType(a, b interface{}) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+// orderedTypes will return true if a and b are both numbers or both
+// strings
+func orderedTypes(a, b interface{}) bool {
+	switch a.(type) {
+	case float64, string:
+		return sameType(a, b)
+	}
+	return false
+}
+
+// stringTypes will return true if a and b are both strings
+func stringTypes(a, b interface{}) bool {
+	_, _, ok := stringArgs(a, b)
+	return ok
+}
@@ -0,0 +1,90 @@
+package grules
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRuleError(t *testing.T) {
+	t.Run("rule", func(t *testing.T) {
+		err := &RuleError{Node: "composites[2].rules[0]", Path: "user.age", Err: ErrUnknownComparator}
+		expected := "composites[2].rules[0] (user.age): grules: unknown comparator"
+		if err.Error() != expected {
+			t.Fatalf("expected %s but got %s", expected, err.Error())
+		}
+	})
+
+	t.Run("composite", func(t *testing.T) {
+		err := &RuleError{Node: "composites[1]", Err: ErrUnknownOperator}
+		expected := "composites[1]: grules: unknown operator"
+		if err.Error() != expected {
+			t.Fatalf("expected %s but got %s", expected, err.Error())
+		}
+	})
+
+	t.Run("unwrap", func(t *testing.T) {
+		var err error = &RuleError{Node: "composites[0]", Err: ErrUnknownOperator}
+		if !errors.Is(err, ErrUnknownOperator) {
+			t.Fatal("expected error to wrap ErrUnknownOperator")
+		}
+		var re *RuleError
+		if !errors.As(err, &re) || re.Node != "composites[0]" {
+			t.Fatal("expected error to be a *RuleError")
+		}
+	})
+}
+
+func TestEvaluateWithError(t *testing.T) {
+	cases := []struct {
+		engine   string
+		expected bool
+		node     string
+		path     string
+		err      error
+	}{
+		{engine: `{"operator":"and","rules":[{"comparator":"gte","path":"age","value":18}]}`, expected: true},
+		{engine: `{"operator":"and","rules":[{"comparator":"gte","path":"age","value":40}]}`, expected: false},
+		{engine: `{"operator":"and","rules":[{"comparator":"gte","path":"age","value":18},{"comparator":"similar","path":"name","value":"ann"}]}`, node: "composites[0].rules[1]", path: "name", err: ErrUnknownComparator},
+		{engine: `{"operator":"and","rules":[{"comparator":"eq","path":"age","value":"30"}],"composites":[{"operator":"and","rules":[{"comparator":"eq","path":"email","value":"a@example.com"}]}]}`, node: "composites[0].rules[0]", path: "age", err: ErrTypeMismatch},
+		{engine: `{"operator":"and","composites":[{"operator":"and","rules":[{"comparator":"eq","path":"email","value":"a@example.com"}]}]}`, node: "composites[0].composites[0].rules[0]", path: "email", err: ErrPathNotFound},
+		{engine: `{"operator":"and","rules":[{"comparator":"eq","path":"email","value":"a@example.com","on_missing":"false"}]}`, expected: false},
+		{engine: `{"operator":"and","rules":[{"comparator":"eq","path":"email","value":"a@example.com","on_missing":"error"}]}`, node: "composites[0].rules[0]", path: "email", err: ErrPathNotFound},
+		{engine: `{"operator":"and","rules":[{"comparator":"startswith","path":"age","value":"3"}]}`, node: "composites[0].rules[0]", path: "age", err: ErrTypeMismatch},
+		{engine: `{"operator":"or","rules":[{"comparator":"eq","path":"email","value":"a@example.com"},{"comparator":"eq","path":"name","value":"Ann"}]}`, expected: true},
+		{engine: `{"operator":"or","rules":[{"comparator":"gt","path":"age","value":"18"}]},{"operator":"and","rules":[{"comparator":"eq","path":"name","value":"Bob"}]}`, node: "composites[0].rules[0]", path: "age", err: ErrTypeMismatch},
+		{engine: `{"operator":"or","rules":[{"comparator":"gt","path":"age","value":"18"},{"comparator":"eq","path":"name","value":"Ann"}]},{"operator":"and","rules":[{"comparator":"eq","path":"name","value":"Bob"}]}`, expected: false},
+		{engine: `{"operator":"and","rules":[]},{"operator":"maybe","rules":[]}`, node: "composites[1]", err: ErrUnknownOperator},
+	}
+	props := map[string]interface{}{"age": float64(30), "name": "Ann"}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[` + c.engine + `]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := e.EvaluateWithError(props)
+		if res != (c.expected && c.err == nil) || res != e.Evaluate(props) {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+		if c.err == nil {
+			if err != nil {
+				t.Fatalf("expected case %d to not have an error, got %v", i, err)
+			}
+			continue
+		}
+		var ruleErr *RuleError
+		if !errors.As(err, &ruleErr) || !errors.Is(err, c.err) || ruleErr.Node != c.node || ruleErr.Path != c.path {
+			t.Fatalf("expected case %d to be %v at %s (%s), got %v", i, c.err, c.node, c.path, err)
+		}
+	}
+}
+
+func BenchmarkEvaluateWithError(b *testing.B) {
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[{"comparator":"eq","path":"age","value":"30"}]}]}`))
+	if err != nil {
+		b.Fatal(err)
+	}
+	props := map[string]interface{}{"age": float64(30)}
+	for i := 0; i < b.N; i++ {
+		e.EvaluateWithError(props)
+	}
+}
@@ -0,0 +1,146 @@
+package grules
+
+import (
+	"fmt"
+)
+
+// expressionFunctions is a map of the functions that can be used in
+// an expression to the comparator each one becomes
+var expressionFunctions = map[string]string{
+	"eq":       "eq",
+	"ne":       "neq",
+	"neq":      "neq",
+	"lt":       "lt",
+	"le":       "lte",
+	"lte":      "lte",
+	"gt":       "gt",
+	"ge":       "gte",
+	"gte":      "gte",
+	"co":       "contains",
+	"contains": "contains",
+	"in":       "in",
+	"sw":       "startswith",
+	"ew":       "endswith",
+	"pr":       "exists",
+}
+
+// NewExpressionEngine will create a new engine from a rule expression
+// in the style used by other Go rule libraries, for example
+//
+//	eq(user.name, "Trevor") and (gt(user.age, 20) or in(user.role, ["admin", "owner"]))
+//
+// Functions take a path and a value, except pr which only takes a path,
+// and are joined with and, or, not and parentheses. See
+// expressionFunctions for the supported functions
+func NewExpressionEngine(expr string) (Engine, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return Engine{}, err
+	}
+	n, err := p.expression()
+	if err != nil {
+		return Engine{}, err
+	}
+	if err := p.done(); err != nil {
+		return Engine{}, err
+	}
+	return n.engine(), nil
+}
+
+// expression will parse terms joined by or
+func (p *parser) expression() (node, error) {
+	n, err := p.term()
+	if err != nil {
+		return node{}, err
+	}
+	for p.accept("or") || p.accept("||") {
+		right, err := p.term()
+		if err != nil {
+			return node{}, err
+		}
+		n = join(OperatorOr, n, right)
+	}
+	return n, nil
+}
+
+// term will parse function calls joined by and
+func (p *parser) term() (node, error) {
+	n, err := p.call()
+	if err != nil {
+		return node{}, err
+	}
+	for p.accept("and") || p.accept("&&") {
+		right, err := p.call()
+		if err != nil {
+			return node{}, err
+		}
+		n = join(OperatorAnd, n, right)
+	}
+	return n, nil
+}
+
+// call will parse a single function call, a negated call or an
+// expression in parentheses
+func (p *parser) call() (node, error) {
+	if p.accept("not") || p.accept("!") {
+		n, err := p.call()
+		if err != nil {
+			return node{}, err
+		}
+		return join(OperatorNot, n), nil
+	}
+	if p.accept("(") {
+		n, err := p.expression()
+		if err != nil {
+			return node{}, err
+		}
+		return n, p.expect(")")
+	}
+
+	name := p.peek()
+	if name.kind != tokenIdent {
+		return node{}, p.unexpected()
+	}
+	comparator, ok := expressionFunctions[name.text]
+	if !ok {
+		return node{}, fmt.Errorf("%w at %d: unknown function %q", ErrSyntax, name.pos, name.text)
+	}
+	p.next()
+	if err := p.expect("("); err != nil {
+		return node{}, err
+	}
+	path := p.peek()
+	if path.kind != tokenIdent {
+		return node{}, p.unexpected()
+	}
+	p.next()
+	if presenceComparators[comparator] {
+		if err := p.expect(")"); err != nil {
+			return node{}, err
+		}
+		return node{rule: &Rule{Comparator: comparator, Path: path.text}}, nil
+	}
+	if err := p.expect(","); err != nil {
+		return node{}, err
+	}
+
+	var value interface{}
+	var err error
+	if p.accept("[") {
+		value, err = p.list("]")
+	} else {
+		value, err = p.literal()
+	}
+	if err != nil {
+		return node{}, err
+	}
+	if err := p.expect(")"); err != nil {
+		return node{}, err
+	}
+
+	return node{rule: &Rule{
+		Comparator: comparator,
+		Path:       path.text,
+		Value:      value,
+	}}, nil
+}
@@ -0,0 +1,87 @@
+package grules
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewExpressionEngine(t *testing.T) {
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "Trevor",
+			"age":  float64(25),
+			"role": "owner",
+			"tags": []interface{}{"beta"},
+		},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		e, err := NewExpressionEngine(`eq(user.name, "Trevor") and (gt(user.age, 30) or in(user.role, ["admin", "owner"])) and co(user.tags, "beta")`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedStr := "({user.name eq Trevor} and {user.tags contains beta} and ({user.age gt 30} or {user.role in [admin owner]}))"
+		actualStr := e.Stringify()
+		if expectedStr != actualStr {
+			t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+		}
+		if e.Evaluate(props) != true {
+			t.Fatal("expected engine to pass")
+		}
+	})
+
+	t.Run("other functions", func(t *testing.T) {
+		e, err := NewExpressionEngine(`sw(user.name, "Tre") and ew(user.name, "vor")`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Evaluate(props) != true {
+			t.Fatal("expected engine to pass")
+		}
+
+		e, err = NewExpressionEngine(`pr(user.name) and not pr(user.email)`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Evaluate(props) != true {
+			t.Fatal("expected engine to pass")
+		}
+
+		e, err = NewExpressionEngine(`le(user.age, 20)`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Evaluate(props) != false {
+			t.Fatal("expected engine to fail")
+		}
+	})
+
+	t.Run("not", func(t *testing.T) {
+		e, err := NewExpressionEngine(`not eq(user.name, "John") and not (lt(user.age, 18) or eq(user.role, "guest"))`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Evaluate(props) != true {
+			t.Fatal("expected engine to pass")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		exprs := []string{
+			`eq(user.name, "Trevor") and`,
+			`eq(user.name "Trevor")`,
+			`eq("Trevor", user.name)`,
+			`matches(user.name, "Trevor")`,
+			`(eq(user.name, "Trevor")`,
+			`eq(user.name, "Trevor") eq(user.age, 25)`,
+			`pr(user.name, "Trevor")`,
+		}
+		for i, expr := range exprs {
+			_, err := NewExpressionEngine(expr)
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("expected case %d to be a syntax error, got %v", i, err)
+			}
+		}
+	})
+}
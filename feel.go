@@ -0,0 +1,109 @@
+package grules
+
+// feelComparators is a map of the operators that can start a FEEL
+// unary test to the comparator each one becomes
+var feelComparators = map[string]string{
+	"<":  "lt",
+	"<=": "lte",
+	">":  "gt",
+	">=": "gte",
+	"=":  "eq",
+	"!=": "neq",
+}
+
+// NewFEELComposite will create a composite from the FEEL unary tests
+// used in the input entries of DMN decision tables, applied to the
+// value at path. The composite is true if one of the tests is true.
+// Supported tests are "-" (anything), literals ("US", 42, true),
+// comparisons (>= 18) and ranges ([10..20], (10..20], ]10..20[),
+// separated by commas. The tests can be negated with not(...)
+func NewFEELComposite(path, tests string) (Composite, error) {
+	p, err := newParser(tests)
+	if err != nil {
+		return Composite{}, err
+	}
+	if p.accept("-") {
+		return Composite{Operator: OperatorAnd}, p.done()
+	}
+
+	negated := p.accept("not")
+	if negated {
+		if err := p.expect("("); err != nil {
+			return Composite{}, err
+		}
+	}
+
+	nodes := []node{}
+	for {
+		n, err := p.feelTest(path)
+		if err != nil {
+			return Composite{}, err
+		}
+		nodes = append(nodes, n)
+		if !p.accept(",") {
+			break
+		}
+	}
+	n := join(OperatorOr, nodes...)
+
+	if negated {
+		if err := p.expect(")"); err != nil {
+			return Composite{}, err
+		}
+		n = join(OperatorNot, n)
+	}
+	if err := p.done(); err != nil {
+		return Composite{}, err
+	}
+	return n.composite, nil
+}
+
+// feelTest will parse a single unary test against the value at path
+func (p *parser) feelTest(path string) (node, error) {
+	t := p.peek()
+	if comparator, ok := feelComparators[t.text]; ok && t.kind == tokenSymbol {
+		p.next()
+		v, err := p.literal()
+		if err != nil {
+			return node{}, err
+		}
+		return node{rule: &Rule{Comparator: comparator, Path: path, Value: v}}, nil
+	}
+
+	if t.text == "[" || t.text == "(" || t.text == "]" {
+		p.next()
+		low, err := p.literal()
+		if err != nil {
+			return node{}, err
+		}
+		if err := p.expect(".."); err != nil {
+			return node{}, err
+		}
+		high, err := p.literal()
+		if err != nil {
+			return node{}, err
+		}
+
+		lowComparator := "gte"
+		if t.text != "[" {
+			lowComparator = "gt"
+		}
+		highComparator := "lte"
+		if !p.accept("]") {
+			if !p.accept(")") && !p.accept("[") {
+				return node{}, p.unexpected()
+			}
+			highComparator = "lt"
+		}
+		return join(OperatorAnd,
+			node{rule: &Rule{Comparator: lowComparator, Path: path, Value: low}},
+			node{rule: &Rule{Comparator: highComparator, Path: path, Value: high}},
+		), nil
+	}
+
+	v, err := p.literal()
+	if err != nil {
+		return node{}, err
+	}
+	return node{rule: &Rule{Comparator: "eq", Path: path, Value: v}}, nil
+}
@@ -0,0 +1,72 @@
+package grules
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewFEELComposite(t *testing.T) {
+	cases := []struct {
+		tests    string
+		expected string
+	}{
+		{tests: `-`, expected: "()"},
+		{tests: `>= 18`, expected: "({age gte 18})"},
+		{tests: `"US","CA"`, expected: "({age eq US} or {age eq CA})"},
+		{tests: `[10..20]`, expected: "(({age gte 10} and {age lte 20}))"},
+		{tests: `(10..20]`, expected: "(({age gt 10} and {age lte 20}))"},
+		{tests: `]10..20[`, expected: "(({age gt 10} and {age lt 20}))"},
+		{tests: `[10..20), 42, < -1`, expected: "({age eq 42} or {age lt -1} or ({age gte 10} and {age lt 20}))"},
+		{tests: `not("US", "CA")`, expected: "(({age eq US} or {age eq CA}))"},
+	}
+
+	for i, c := range cases {
+		composite, err := NewFEELComposite("age", c.tests)
+		if err != nil {
+			t.Fatalf("expected case %d to parse, got %v", i, err)
+		}
+		actualStr := composite.stringify(defaultComparators)
+		if actualStr != c.expected {
+			t.Fatalf("expected case %d to be %s but got %s", i, c.expected, actualStr)
+		}
+	}
+
+	t.Run("evaluate", func(t *testing.T) {
+		composite, err := NewFEELComposite("user.age", `< 13, [18..65]`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := NewEngine()
+		e.Composites = []Composite{composite}
+
+		ages := map[float64]bool{10: true, 15: false, 18: true, 65: true, 66: false}
+		for age, expected := range ages {
+			props := map[string]interface{}{
+				"user": map[string]interface{}{
+					"age": age,
+				},
+			}
+			if e.Evaluate(props) != expected {
+				t.Fatalf("expected age %v to be %v", age, expected)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []string{
+			``,
+			`>=`,
+			`[10..20`,
+			`[10 20]`,
+			`"US",`,
+			`- 18`,
+			`not("US"`,
+		}
+		for i, test := range tests {
+			_, err := NewFEELComposite("age", test)
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("expected case %d to be a syntax error, got %v", i, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package grules
+
+import (
+	"mime"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// isUUID will return true if a is a UUID string in the canonical
+// 8-4-4-4-12 hexadecimal form, in either case. b is ignored
+func isUUID(a, b interface{}) bool {
+	_, ok := uuidVersion(a)
+	return ok
+}
+
+// isUUIDVersion will create a comparator that returns true if a is a
+// UUID of the given version with the RFC 4122 variant. b is ignored
+func isUUIDVersion(version int) Comparator {
+	return func(a, b interface{}) bool {
+		v, ok := uuidVersion(a)
+		return ok && v == version && isRFC4122Variant(a.(string))
+	}
+}
+
+// uuidVersion will return the version of the UUID a, ok is false if a
+// is not a UUID string
+func uuidVersion(a interface{}) (int, bool) {
+	s, ok := a.(string)
+	if !ok || len(s) != 36 {
+		return 0, false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return 0, false
+			}
+		default:
+			if hexValue(s[i]) < 0 {
+				return 0, false
+			}
+		}
+	}
+	return hexValue(s[14]), true
+}
+
+// isRFC4122Variant will return true if the variant bits of the UUID s
+// are 10, the variant used by every versioned UUID
+func isRFC4122Variant(s string) bool {
+	return hexValue(s[19])&0xc == 0x8
+}
+
+// hexValue will return the value of the hexadecimal digit c, or -1 if
+// c is not one
+func hexValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
+
+// emailLocalChars is the set of characters, other than letters and
+// digits, allowed in the local part of an email address
+const emailLocalChars = "!#$%&'*+-/=?^_`{|}~"
+
+// isEmail will return true if a is an email address like
+// "trevor@example.com". The local part may be any dot separated atoms
+// from RFC 5322, quoted local parts and comments are not allowed, and
+// the domain must be a host name with at least two labels. b is
+// ignored
+func isEmail(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok || len(s) > 254 {
+		return false
+	}
+	at := strings.LastIndexByte(s, '@')
+	if at < 1 || at > 64 {
+		return false
+	}
+	for _, atom := range strings.Split(s[:at], ".") {
+		if atom == "" {
+			return false
+		}
+		for i := 0; i < len(atom); i++ {
+			c := atom[i]
+			if !isAlphanumeric(c) && strings.IndexByte(emailLocalChars, c) < 0 {
+				return false
+			}
+		}
+	}
+	domain := s[at+1:]
+	return strings.Contains(domain, ".") && !strings.HasSuffix(domain, ".") && isHostname(domain)
+}
+
+// isURL will return true if a is an absolute URL with a scheme and a
+// host, like "https://example.com/path?q=1". The host must be a host
+// name or an IP address. b is ignored
+func isURL(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok || strings.ContainsAny(s, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Opaque != "" {
+		return false
+	}
+	host := u.Hostname()
+	if host == "" {
+		return false
+	}
+	return net.ParseIP(host) != nil || isHostname(host)
+}
+
+// isHostname will return true if s is a host name made of dot
+// separated labels of letters, digits and hyphens, with no label
+// starting or ending with a hyphen
+func isHostname(s string) bool {
+	if len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(s, "."), ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			if !isAlphanumeric(label[i]) && label[i] != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isAlphanumeric will return true if c is an ASCII letter or digit
+func isAlphanumeric(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// mimeType will return true if the MIME type a matches the pattern b,
+// or any of the patterns if b is an array. Patterns are a type like
+// "application/json", a wildcard subtype like "image/*" or "*/*".
+// Parameters such as "; charset=utf-8" and case are ignored
+func mimeType(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	t, _, err := mime.ParseMediaType(s)
+	if err != nil {
+		return false
+	}
+	slash := strings.IndexByte(t, '/')
+	if slash < 0 {
+		return false
+	}
+
+	patterns, ok := b.([]interface{})
+	if !ok {
+		patterns = []interface{}{b}
+	}
+	for _, p := range patterns {
+		pattern, ok := p.(string)
+		if !ok {
+			continue
+		}
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "*/*" || pattern == t:
+			return true
+		case strings.HasSuffix(pattern, "/*") && pattern[:len(pattern)-1] == t[:slash+1]:
+			return true
+		}
+	}
+	return false
+}
+
+// extension will return true if the file name a has the extension b,
+// or any of the extensions if b is an array. Extensions are matched
+// with or without the leading dot and without regard to case, only the
+// last extension counts so "archive.tar.gz" has the extension "gz"
+func extension(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(s))
+	if len(ext) < 2 {
+		return false
+	}
+
+	exts, ok := b.([]interface{})
+	if !ok {
+		exts = []interface{}{b}
+	}
+	for _, e := range exts {
+		want, ok := e.(string)
+		if ok && strings.ToLower(strings.TrimPrefix(want, ".")) == ext[1:] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package grules
+
+import (
+	"strings"
+	"unicode"
+)
+
+// levenshteinAtMost will return true if the edit distance between the
+// strings a and args[0] is at most the number args[1], ignoring case
+func levenshteinAtMost(a interface{}, args ...interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	t, ok := args[0].(string)
+	if !ok {
+		return false
+	}
+	max, ok := toFloat64(args[1])
+	if !ok {
+		return false
+	}
+	return float64(levenshtein(strings.ToLower(s), strings.ToLower(t))) <= max
+}
+
+// jaroWinklerAtLeast will return true if the Jaro-Winkler similarity of
+// the strings a and args[0] is at least the number args[1], ignoring
+// case
+func jaroWinklerAtLeast(a interface{}, args ...interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	t, ok := args[0].(string)
+	if !ok {
+		return false
+	}
+	min, ok := toFloat64(args[1])
+	if !ok {
+		return false
+	}
+	return jaroWinkler(strings.ToLower(s), strings.ToLower(t)) >= min
+}
+
+// soundsLike will return true if the strings a and b have the same
+// Soundex code, so names like "Robert" and "Rupert" match
+func soundsLike(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	t, ok := b.(string)
+	if !ok {
+		return false
+	}
+	cs, ct := soundex(s), soundex(t)
+	return cs != "" && cs == ct
+}
+
+// fuzzy will return true if the string a is close enough to the
+// string in b, ignoring case and repeated spaces. b is an object with
+// the "value" to match and either the "distance", the most edits
+// allowed, or the "similarity", between 0 and 1, where 1 is an exact
+// match and 0.8 allows one edit in every five characters
+func fuzzy(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	m, ok := b.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	t, ok := m["value"].(string)
+	if !ok {
+		return false
+	}
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+	d := float64(levenshtein(s, t))
+
+	if max, ok := toFloat64(m["distance"]); ok {
+		return d <= max
+	}
+	if min, ok := toFloat64(m["similarity"]); ok {
+		n := maxInt(len([]rune(s)), len([]rune(t)))
+		if n == 0 {
+			return true
+		}
+		return 1-d/float64(n) >= min
+	}
+	return false
+}
+
+// levenshtein will return the number of single rune insertions,
+// deletions and substitutions needed to turn s into t
+func levenshtein(s, t string) int {
+	rs, rt := []rune(s), []rune(t)
+	prev := make([]int, len(rt)+1)
+	curr := make([]int, len(rt)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(rs); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rt); j++ {
+			cost := 1
+			if rs[i-1] == rt[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rt)]
+}
+
+// jaroWinkler will return the Jaro-Winkler similarity of s and t,
+// between 0 for no similarity and 1 for the same string
+func jaroWinkler(s, t string) float64 {
+	rs, rt := []rune(s), []rune(t)
+	if len(rs) == 0 && len(rt) == 0 {
+		return 1
+	}
+	if len(rs) == 0 || len(rt) == 0 {
+		return 0
+	}
+
+	// Runes match if they are the same and not too far apart
+	window := maxInt(len(rs), len(rt))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	ms, mt := make([]bool, len(rs)), make([]bool, len(rt))
+	matches := 0
+	for i := range rs {
+		lo, hi := maxInt(0, i-window), minInt(len(rt)-1, i+window)
+		for j := lo; j <= hi; j++ {
+			if !mt[j] && rs[i] == rt[j] {
+				ms[i], mt[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Half the matching runes that are in a different order
+	transpositions, j := 0, 0
+	for i := range rs {
+		if !ms[i] {
+			continue
+		}
+		for !mt[j] {
+			j++
+		}
+		if rs[i] != rt[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(rs)) + m/float64(len(rt)) + (m-float64(transpositions)/2)/m) / 3
+
+	// Strings with a common prefix of up to 4 runes get a boost
+	prefix := 0
+	for prefix < minInt(4, minInt(len(rs), len(rt))) && rs[prefix] == rt[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// soundexCodes is a map of the consonants to their Soundex digit
+var soundexCodes = map[rune]byte{
+	'B': '1', 'F': '1', 'P': '1', 'V': '1',
+	'C': '2', 'G': '2', 'J': '2', 'K': '2', 'Q': '2', 'S': '2', 'X': '2', 'Z': '2',
+	'D': '3', 'T': '3',
+	'L': '4',
+	'M': '5', 'N': '5',
+	'R': '6',
+}
+
+// soundex will return the American Soundex code of a word, a letter
+// followed by three digits, or an empty string if it has no letters
+func soundex(word string) string {
+	code := []byte{}
+	var last byte
+	for _, r := range strings.ToUpper(word) {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			continue
+		}
+		digit := soundexCodes[r]
+		if len(code) == 0 {
+			code = append(code, byte(r))
+			last = digit
+			continue
+		}
+		switch {
+		case digit != 0 && digit != last:
+			code = append(code, digit)
+		case r == 'H' || r == 'W':
+			// H and W do not separate consonants with the same code
+			continue
+		}
+		last = digit
+		if len(code) == 4 {
+			break
+		}
+	}
+	if len(code) == 0 {
+		return ""
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
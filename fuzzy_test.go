@@ -0,0 +1,164 @@
+package grules
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLevenshteinAtMost(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"Jon Smith", "John Smith", float64(1)}, expected: true},
+		testCase{args: []interface{}{"jon smyth", "John Smith", float64(2)}, expected: true},
+		testCase{args: []interface{}{"jon smyth", "John Smith", float64(1)}, expected: false},
+		testCase{args: []interface{}{"John Smith", "John Smith", float64(0)}, expected: true},
+		testCase{args: []interface{}{float64(1), "John Smith", float64(10)}, expected: false},
+		testCase{args: []interface{}{"John Smith", "John Smith", "0"}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := levenshteinAtMost(c.args[0], c.args[1:]...)
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkLevenshteinAtMost(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		levenshteinAtMost("jon smyth", "John Smith", float64(2))
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	cases := map[[2]string]int{
+		[2]string{"kitten", "sitting"}: 3,
+		[2]string{"", "abc"}:           3,
+		[2]string{"abc", ""}:           3,
+		[2]string{"héllo", "hello"}:    1,
+		[2]string{"flaw", "lawn"}:      2,
+	}
+	for c, expected := range cases {
+		if res := levenshtein(c[0], c[1]); res != expected {
+			t.Fatalf("expected %q %q to be %d, got %d", c[0], c[1], expected, res)
+		}
+	}
+}
+
+func TestJaroWinklerAtLeast(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"MARTHA", "marhta", float64(0.96)}, expected: true},
+		testCase{args: []interface{}{"Dwayne", "Duane", float64(0.9)}, expected: false},
+		testCase{args: []interface{}{"Dwayne", "Duane", float64(0.8)}, expected: true},
+		testCase{args: []interface{}{"Smith", "Jones", float64(0.5)}, expected: false},
+		testCase{args: []interface{}{true, "Jones", float64(0.5)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := jaroWinklerAtLeast(c.args[0], c.args[1:]...)
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkJaroWinklerAtLeast(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		jaroWinklerAtLeast("Dwayne", "Duane", float64(0.8))
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	cases := map[[2]string]float64{
+		[2]string{"martha", "marhta"}:       0.9611,
+		[2]string{"dwayne", "duane"}:        0.84,
+		[2]string{"dixon", "dicksonx"}:      0.8133,
+		[2]string{"", ""}:                   1,
+		[2]string{"abc", ""}:                0,
+		[2]string{"abc", "xyz"}:             0,
+		[2]string{"identical", "identical"}: 1,
+	}
+	for c, expected := range cases {
+		if res := jaroWinkler(c[0], c[1]); math.Abs(res-expected) > 0.0001 {
+			t.Fatalf("expected %q %q to be %v, got %v", c[0], c[1], expected, res)
+		}
+	}
+}
+
+func TestSoundsLike(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"Robert", "Rupert"}, expected: true},
+		testCase{args: []interface{}{"Ashcraft", "Ashcroft"}, expected: true},
+		testCase{args: []interface{}{"Tymczak", "tymczak"}, expected: true},
+		testCase{args: []interface{}{"Robert", "Rubin"}, expected: false},
+		testCase{args: []interface{}{"", ""}, expected: false},
+		testCase{args: []interface{}{"Robert", float64(1)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := soundsLike(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkSoundsLike(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		soundsLike("Robert", "Rupert")
+	}
+}
+
+func TestSoundex(t *testing.T) {
+	cases := map[string]string{
+		"Robert":   "R163",
+		"Rupert":   "R163",
+		"Rubin":    "R150",
+		"Ashcraft": "A261",
+		"Tymczak":  "T522",
+		"Pfister":  "P236",
+		"Honeyman": "H555",
+		"Lee":      "L000",
+		"":         "",
+	}
+	for word, expected := range cases {
+		if res := soundex(word); res != expected {
+			t.Fatalf("expected %q to be %q, got %q", word, expected, res)
+		}
+	}
+}
+
+func TestFuzzy(t *testing.T) {
+	distance := func(s string, n float64) map[string]interface{} {
+		return map[string]interface{}{"value": s, "distance": n}
+	}
+	similarity := func(s string, n float64) map[string]interface{} {
+		return map[string]interface{}{"value": s, "similarity": n}
+	}
+	cases := []testCase{
+		testCase{args: []interface{}{"Trevor", distance("Trevor", 0)}, expected: true},
+		testCase{args: []interface{}{"Trevr", distance("Trevor", 1)}, expected: true},
+		testCase{args: []interface{}{"trevro", distance("Trevor", 1)}, expected: false},
+		testCase{args: []interface{}{"trevro", distance("Trevor", 2)}, expected: true},
+		testCase{args: []interface{}{"1  Main   Street", distance("1 main street", 0)}, expected: true},
+		testCase{args: []interface{}{"1 Main Stret", similarity("1 Main Street", 0.9)}, expected: true},
+		testCase{args: []interface{}{"1 Mian Stret", similarity("1 Main Street", 0.9)}, expected: false},
+		testCase{args: []interface{}{"", similarity("", 1)}, expected: true},
+		testCase{args: []interface{}{"Trevor", map[string]interface{}{"value": "Trevor"}}, expected: false},
+		testCase{args: []interface{}{"Trevor", "Trevor"}, expected: false},
+		testCase{args: []interface{}{float64(1), distance("1", 0)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := fuzzy(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkFuzzy(b *testing.B) {
+	value := map[string]interface{}{"value": "1 Main Street", "similarity": 0.9}
+	for i := 0; i < b.N; i++ {
+		fuzzy("1 Main Stret", value)
+	}
+}
@@ -0,0 +1,1042 @@
+package grules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jmesKind is the kind of a JMESPath token or node
+type jmesKind int
+
+const (
+	jmesEOF jmesKind = iota
+	jmesIdentifier
+	jmesQuotedIdentifier
+	jmesNumber
+	jmesLiteral
+	jmesDot
+	jmesStar
+	jmesFlatten
+	jmesFilter
+	jmesLbracket
+	jmesRbracket
+	jmesLbrace
+	jmesRbrace
+	jmesLparen
+	jmesRparen
+	jmesComma
+	jmesColon
+	jmesPipe
+	jmesOr
+	jmesAnd
+	jmesNot
+	jmesComparator
+	jmesCurrent
+)
+
+// jmesToken is a single token of a JMESPath expression, value is the
+// decoded value of literals and numbers
+type jmesToken struct {
+	kind  jmesKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+// jmesBindingPowers is how tightly each token binds to the expression
+// on its left, tokens that are not listed bind with 0
+var jmesBindingPowers = map[jmesKind]int{
+	jmesPipe:       1,
+	jmesOr:         2,
+	jmesAnd:        3,
+	jmesComparator: 5,
+	jmesFlatten:    9,
+	jmesStar:       20,
+	jmesFilter:     21,
+	jmesDot:        40,
+	jmesNot:        45,
+	jmesLbrace:     50,
+	jmesLbracket:   55,
+	jmesLparen:     60,
+}
+
+// jmesProjectionStop is the binding power below which a token ends the
+// right hand side of a projection
+const jmesProjectionStop = 10
+
+// jmesSymbols is a list of the symbols of the language, longest first
+var jmesSymbols = []struct {
+	text string
+	kind jmesKind
+}{
+	{"[]", jmesFlatten}, {"[?", jmesFilter}, {"||", jmesOr}, {"&&", jmesAnd},
+	{"==", jmesComparator}, {"!=", jmesComparator}, {"<=", jmesComparator}, {">=", jmesComparator},
+	{"<", jmesComparator}, {">", jmesComparator}, {"!", jmesNot},
+	{".", jmesDot}, {"*", jmesStar}, {"[", jmesLbracket}, {"]", jmesRbracket},
+	{"{", jmesLbrace}, {"}", jmesRbrace}, {"(", jmesLparen}, {")", jmesRparen},
+	{",", jmesComma}, {":", jmesColon}, {"|", jmesPipe}, {"@", jmesCurrent},
+}
+
+// lexJMESPath will split a JMESPath expression into tokens
+func lexJMESPath(input string) ([]jmesToken, error) {
+	tokens := []jmesToken{}
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(input) && (isIdentStart(input[i]) || isDigit(input[i])) {
+				i++
+			}
+			tokens = append(tokens, jmesToken{kind: jmesIdentifier, text: input[start:i], pos: start})
+		case isDigit(c) || (c == '-' && i+1 < len(input) && isDigit(input[i+1])):
+			start := i
+			i++
+			for i < len(input) && isDigit(input[i]) {
+				i++
+			}
+			n, err := strconv.Atoi(input[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("%w at %d: %v", ErrSyntax, start, err)
+			}
+			tokens = append(tokens, jmesToken{kind: jmesNumber, text: input[start:i], value: n, pos: start})
+		case c == '"' || c == '\'' || c == '`':
+			raw, n, err := lexDelimited(input[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at %d: %v", ErrSyntax, i, err)
+			}
+			t, err := jmesQuotedToken(c, raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w at %d: %v", ErrSyntax, i, err)
+			}
+			t.pos = i
+			tokens = append(tokens, t)
+			i += n
+		default:
+			matched := false
+			for _, sym := range jmesSymbols {
+				if strings.HasPrefix(input[i:], sym.text) {
+					tokens = append(tokens, jmesToken{kind: sym.kind, text: sym.text, pos: i})
+					i += len(sym.text)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("%w at %d: unexpected %q", ErrSyntax, i, c)
+			}
+		}
+	}
+	tokens = append(tokens, jmesToken{kind: jmesEOF, pos: len(input)})
+	return tokens, nil
+}
+
+// lexDelimited will return the text between the delimiter at the start
+// of input and the next one that is not escaped with a backslash, along
+// with the number of bytes read
+func lexDelimited(input string) (string, int, error) {
+	delim := input[0]
+	for i := 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			i++
+		case delim:
+			return input[1:i], i + 1, nil
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+// jmesQuotedToken will create the token for a quoted identifier, a raw
+// string or a JSON literal
+func jmesQuotedToken(delim byte, raw string) (jmesToken, error) {
+	switch delim {
+	case '"':
+		s, err := strconv.Unquote(`"` + raw + `"`)
+		return jmesToken{kind: jmesQuotedIdentifier, text: s}, err
+	case '\'':
+		s := strings.Replace(raw, `\'`, `'`, -1)
+		return jmesToken{kind: jmesLiteral, text: s, value: s}, nil
+	}
+	var v interface{}
+	err := json.Unmarshal([]byte(strings.Replace(raw, "\\`", "`", -1)), &v)
+	return jmesToken{kind: jmesLiteral, text: raw, value: v}, err
+}
+
+// jmesNode is a node of a parsed JMESPath expression
+type jmesNode struct {
+	kind     string
+	value    interface{}
+	children []*jmesNode
+}
+
+// The kinds of jmesNode
+const (
+	jmesField            = "field"
+	jmesSubexpression    = "subexpression"
+	jmesIndexExpression  = "index-expression"
+	jmesIndex            = "index"
+	jmesSlice            = "slice"
+	jmesProjection       = "projection"
+	jmesValueProjection  = "value-projection"
+	jmesFilterProjection = "filter-projection"
+	jmesFlattenNode      = "flatten"
+	jmesPipeNode         = "pipe"
+	jmesOrNode           = "or"
+	jmesAndNode          = "and"
+	jmesNotNode          = "not"
+	jmesCompare          = "comparator"
+	jmesLiteralNode      = "literal"
+	jmesIdentity         = "identity"
+	jmesMultiList        = "multi-select-list"
+	jmesMultiHash        = "multi-select-hash"
+	jmesFunctionCall     = "function"
+)
+
+// jmesParser holds the state of parsing a JMESPath expression
+type jmesParser struct {
+	input  string
+	tokens []jmesToken
+	pos    int
+}
+
+// compileJMESPath will parse a JMESPath expression. Everything in the
+// JMESPath specification is supported except expression references,
+// and the functions are limited to those in jmesFunctions
+func compileJMESPath(expr string) (*jmesNode, error) {
+	tokens, err := lexJMESPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &jmesParser{input: expr, tokens: tokens}
+	n, err := p.expression(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != jmesEOF {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+func (p *jmesParser) peek() jmesToken {
+	return p.tokens[p.pos]
+}
+
+func (p *jmesParser) peekAt(n int) jmesToken {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *jmesParser) next() jmesToken {
+	t := p.tokens[p.pos]
+	if t.kind != jmesEOF {
+		p.pos++
+	}
+	return t
+}
+
+// expect will consume the next token, returning an error if it is not
+// of the given kind
+func (p *jmesParser) expect(kind jmesKind) error {
+	if p.peek().kind != kind {
+		return p.unexpected()
+	}
+	p.next()
+	return nil
+}
+
+// unexpected will return a syntax error for the next token
+func (p *jmesParser) unexpected() error {
+	t := p.peek()
+	if t.kind == jmesEOF {
+		return fmt.Errorf("%w at %d: unexpected end of input", ErrSyntax, t.pos)
+	}
+	return fmt.Errorf("%w at %d: unexpected %q", ErrSyntax, t.pos, p.input[t.pos:t.pos+maxInt(1, len(t.text))])
+}
+
+// expression will parse an expression whose tokens bind more tightly
+// than bp
+func (p *jmesParser) expression(bp int) (*jmesNode, error) {
+	left, err := p.nud(p.next())
+	if err != nil {
+		return nil, err
+	}
+	for bp < jmesBindingPowers[p.peek().kind] {
+		left, err = p.led(p.next(), left)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+// nud will parse an expression that starts with the token
+func (p *jmesParser) nud(t jmesToken) (*jmesNode, error) {
+	identity := &jmesNode{kind: jmesIdentity}
+	switch t.kind {
+	case jmesLiteral:
+		return &jmesNode{kind: jmesLiteralNode, value: t.value}, nil
+	case jmesIdentifier:
+		if p.peek().kind == jmesLparen {
+			p.next()
+			return p.function(t.text)
+		}
+		return &jmesNode{kind: jmesField, value: t.text}, nil
+	case jmesQuotedIdentifier:
+		if p.peek().kind == jmesLparen {
+			return nil, p.unexpected()
+		}
+		return &jmesNode{kind: jmesField, value: t.text}, nil
+	case jmesStar:
+		right, err := p.projectionRHS(jmesBindingPowers[jmesStar])
+		if err != nil {
+			return nil, err
+		}
+		return &jmesNode{kind: jmesValueProjection, children: []*jmesNode{identity, right}}, nil
+	case jmesFilter:
+		return p.filter(identity)
+	case jmesLbrace:
+		return p.multiSelectHash()
+	case jmesFlatten:
+		right, err := p.projectionRHS(jmesBindingPowers[jmesFlatten])
+		if err != nil {
+			return nil, err
+		}
+		flat := &jmesNode{kind: jmesFlattenNode, children: []*jmesNode{identity}}
+		return &jmesNode{kind: jmesProjection, children: []*jmesNode{flat, right}}, nil
+	case jmesLbracket:
+		switch {
+		case p.peek().kind == jmesNumber || p.peek().kind == jmesColon:
+			right, err := p.indexExpression()
+			if err != nil {
+				return nil, err
+			}
+			return p.projectIfSlice(identity, right)
+		case p.peek().kind == jmesStar && p.peekAt(1).kind == jmesRbracket:
+			p.next()
+			p.next()
+			right, err := p.projectionRHS(jmesBindingPowers[jmesStar])
+			if err != nil {
+				return nil, err
+			}
+			return &jmesNode{kind: jmesProjection, children: []*jmesNode{identity, right}}, nil
+		}
+		return p.multiSelectList()
+	case jmesCurrent:
+		return identity, nil
+	case jmesNot:
+		n, err := p.expression(jmesBindingPowers[jmesNot])
+		if err != nil {
+			return nil, err
+		}
+		return &jmesNode{kind: jmesNotNode, children: []*jmesNode{n}}, nil
+	case jmesLparen:
+		n, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(jmesRparen)
+	}
+	p.pos--
+	return nil, p.unexpected()
+}
+
+// led will parse the token that follows the expression left
+func (p *jmesParser) led(t jmesToken, left *jmesNode) (*jmesNode, error) {
+	switch t.kind {
+	case jmesDot:
+		if p.peek().kind == jmesStar {
+			p.next()
+			right, err := p.projectionRHS(jmesBindingPowers[jmesDot])
+			if err != nil {
+				return nil, err
+			}
+			return &jmesNode{kind: jmesValueProjection, children: []*jmesNode{left, right}}, nil
+		}
+		right, err := p.dotRHS(jmesBindingPowers[jmesDot])
+		if err != nil {
+			return nil, err
+		}
+		return &jmesNode{kind: jmesSubexpression, children: []*jmesNode{left, right}}, nil
+	case jmesPipe, jmesOr, jmesAnd:
+		right, err := p.expression(jmesBindingPowers[t.kind])
+		if err != nil {
+			return nil, err
+		}
+		kind := map[jmesKind]string{jmesPipe: jmesPipeNode, jmesOr: jmesOrNode, jmesAnd: jmesAndNode}[t.kind]
+		return &jmesNode{kind: kind, children: []*jmesNode{left, right}}, nil
+	case jmesComparator:
+		right, err := p.expression(jmesBindingPowers[jmesComparator])
+		if err != nil {
+			return nil, err
+		}
+		return &jmesNode{kind: jmesCompare, value: t.text, children: []*jmesNode{left, right}}, nil
+	case jmesFilter:
+		return p.filter(left)
+	case jmesFlatten:
+		right, err := p.projectionRHS(jmesBindingPowers[jmesFlatten])
+		if err != nil {
+			return nil, err
+		}
+		flat := &jmesNode{kind: jmesFlattenNode, children: []*jmesNode{left}}
+		return &jmesNode{kind: jmesProjection, children: []*jmesNode{flat, right}}, nil
+	case jmesLbracket:
+		if p.peek().kind == jmesNumber || p.peek().kind == jmesColon {
+			right, err := p.indexExpression()
+			if err != nil {
+				return nil, err
+			}
+			return p.projectIfSlice(left, right)
+		}
+		if err := p.expect(jmesStar); err != nil {
+			return nil, err
+		}
+		if err := p.expect(jmesRbracket); err != nil {
+			return nil, err
+		}
+		right, err := p.projectionRHS(jmesBindingPowers[jmesStar])
+		if err != nil {
+			return nil, err
+		}
+		return &jmesNode{kind: jmesProjection, children: []*jmesNode{left, right}}, nil
+	}
+	p.pos--
+	return nil, p.unexpected()
+}
+
+// projectionRHS will parse what a projection applies to each element
+func (p *jmesParser) projectionRHS(bp int) (*jmesNode, error) {
+	switch t := p.peek(); {
+	case jmesBindingPowers[t.kind] < jmesProjectionStop:
+		return &jmesNode{kind: jmesIdentity}, nil
+	case t.kind == jmesLbracket || t.kind == jmesFilter:
+		return p.expression(bp)
+	case t.kind == jmesDot:
+		p.next()
+		return p.dotRHS(bp)
+	}
+	return nil, p.unexpected()
+}
+
+// dotRHS will parse what follows a dot
+func (p *jmesParser) dotRHS(bp int) (*jmesNode, error) {
+	switch p.peek().kind {
+	case jmesIdentifier, jmesQuotedIdentifier, jmesStar:
+		return p.expression(bp)
+	case jmesLbracket:
+		p.next()
+		return p.multiSelectList()
+	case jmesLbrace:
+		p.next()
+		return p.multiSelectHash()
+	}
+	return nil, p.unexpected()
+}
+
+// filter will parse the condition and right hand side of a filter
+// projection over left
+func (p *jmesParser) filter(left *jmesNode) (*jmesNode, error) {
+	condition, err := p.expression(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(jmesRbracket); err != nil {
+		return nil, err
+	}
+	right, err := p.projectionRHS(jmesBindingPowers[jmesFilter])
+	if err != nil {
+		return nil, err
+	}
+	return &jmesNode{kind: jmesFilterProjection, children: []*jmesNode{left, right, condition}}, nil
+}
+
+// indexExpression will parse an index or a slice, after the opening
+// bracket
+func (p *jmesParser) indexExpression() (*jmesNode, error) {
+	if p.peek().kind == jmesColon || p.peekAt(1).kind == jmesColon {
+		parts := [3]*int{}
+		i := 0
+		for p.peek().kind != jmesRbracket && i < 3 {
+			switch t := p.next(); t.kind {
+			case jmesColon:
+				i++
+			case jmesNumber:
+				n := t.value.(int)
+				parts[i] = &n
+			default:
+				p.pos--
+				return nil, p.unexpected()
+			}
+		}
+		if parts[2] != nil && *parts[2] == 0 {
+			return nil, fmt.Errorf("%w: slice step can not be 0", ErrSyntax)
+		}
+		return &jmesNode{kind: jmesSlice, value: parts}, p.expect(jmesRbracket)
+	}
+	t := p.next()
+	if t.kind != jmesNumber {
+		p.pos--
+		return nil, p.unexpected()
+	}
+	return &jmesNode{kind: jmesIndex, value: t.value}, p.expect(jmesRbracket)
+}
+
+// projectIfSlice will apply right to left, projecting the rest of the
+// expression over the result if right is a slice
+func (p *jmesParser) projectIfSlice(left, right *jmesNode) (*jmesNode, error) {
+	n := &jmesNode{kind: jmesIndexExpression, children: []*jmesNode{left, right}}
+	if right.kind != jmesSlice {
+		return n, nil
+	}
+	rhs, err := p.projectionRHS(jmesBindingPowers[jmesStar])
+	if err != nil {
+		return nil, err
+	}
+	return &jmesNode{kind: jmesProjection, children: []*jmesNode{n, rhs}}, nil
+}
+
+// multiSelectList will parse a list of expressions, after the opening
+// bracket
+func (p *jmesParser) multiSelectList() (*jmesNode, error) {
+	n := &jmesNode{kind: jmesMultiList}
+	for {
+		child, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, child)
+		if p.peek().kind == jmesRbracket {
+			p.next()
+			return n, nil
+		}
+		if err := p.expect(jmesComma); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// multiSelectHash will parse a list of key: expression pairs, after the
+// opening brace
+func (p *jmesParser) multiSelectHash() (*jmesNode, error) {
+	n := &jmesNode{kind: jmesMultiHash}
+	keys := []string{}
+	for {
+		key := p.next()
+		if key.kind != jmesIdentifier && key.kind != jmesQuotedIdentifier {
+			p.pos--
+			return nil, p.unexpected()
+		}
+		if err := p.expect(jmesColon); err != nil {
+			return nil, err
+		}
+		child, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key.text)
+		n.children = append(n.children, child)
+		if p.peek().kind == jmesRbrace {
+			p.next()
+			n.value = keys
+			return n, nil
+		}
+		if err := p.expect(jmesComma); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// function will parse the arguments of a function call, after the
+// opening parenthesis
+func (p *jmesParser) function(name string) (*jmesNode, error) {
+	f, ok := jmesFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown function %q", ErrSyntax, name)
+	}
+	n := &jmesNode{kind: jmesFunctionCall, value: name}
+	for p.peek().kind != jmesRparen {
+		arg, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, arg)
+		if p.peek().kind == jmesComma {
+			p.next()
+		}
+	}
+	p.next()
+	if f.arity >= 0 && len(n.children) != f.arity {
+		return nil, fmt.Errorf("%w: %s takes %d arguments, got %d", ErrSyntax, name, f.arity, len(n.children))
+	}
+	return n, nil
+}
+
+// search will evaluate the expression against v
+func (n *jmesNode) search(v interface{}) interface{} {
+	switch n.kind {
+	case jmesField:
+		m, _ := v.(map[string]interface{})
+		return m[n.value.(string)]
+	case jmesSubexpression, jmesIndexExpression:
+		left := n.children[0].search(v)
+		if left == nil {
+			return nil
+		}
+		return n.children[1].search(left)
+	case jmesIndex:
+		elems, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		i := n.value.(int)
+		if i < 0 {
+			i += len(elems)
+		}
+		if i < 0 || i >= len(elems) {
+			return nil
+		}
+		return elems[i]
+	case jmesSlice:
+		elems, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		return jmesSliceOf(elems, n.value.([3]*int))
+	case jmesProjection:
+		elems, ok := n.children[0].search(v).([]interface{})
+		if !ok {
+			return nil
+		}
+		return n.children[1].project(elems)
+	case jmesValueProjection:
+		return n.children[1].project(children(n.children[0].search(v)))
+	case jmesFilterProjection:
+		elems, ok := n.children[0].search(v).([]interface{})
+		if !ok {
+			return nil
+		}
+		matched := []interface{}{}
+		for _, elem := range elems {
+			if jmesTruthy(n.children[2].search(elem)) {
+				matched = append(matched, elem)
+			}
+		}
+		return n.children[1].project(matched)
+	case jmesFlattenNode:
+		elems, ok := n.children[0].search(v).([]interface{})
+		if !ok {
+			return nil
+		}
+		flat := []interface{}{}
+		for _, elem := range elems {
+			if inner, ok := elem.([]interface{}); ok {
+				flat = append(flat, inner...)
+			} else {
+				flat = append(flat, elem)
+			}
+		}
+		return flat
+	case jmesPipeNode:
+		return n.children[1].search(n.children[0].search(v))
+	case jmesOrNode:
+		if left := n.children[0].search(v); jmesTruthy(left) {
+			return left
+		}
+		return n.children[1].search(v)
+	case jmesAndNode:
+		if left := n.children[0].search(v); !jmesTruthy(left) {
+			return left
+		}
+		return n.children[1].search(v)
+	case jmesNotNode:
+		return !jmesTruthy(n.children[0].search(v))
+	case jmesCompare:
+		return jmesCompareValues(n.value.(string), n.children[0].search(v), n.children[1].search(v))
+	case jmesLiteralNode:
+		return n.value
+	case jmesIdentity:
+		return v
+	case jmesMultiList:
+		if v == nil {
+			return nil
+		}
+		values := make([]interface{}, len(n.children))
+		for i, child := range n.children {
+			values[i] = child.search(v)
+		}
+		return values
+	case jmesMultiHash:
+		if v == nil {
+			return nil
+		}
+		m := make(map[string]interface{}, len(n.children))
+		for i, key := range n.value.([]string) {
+			m[key] = n.children[i].search(v)
+		}
+		return m
+	case jmesFunctionCall:
+		args := make([]interface{}, len(n.children))
+		for i, child := range n.children {
+			args[i] = child.search(v)
+		}
+		return jmesFunctions[n.value.(string)].call(args)
+	}
+	return nil
+}
+
+// selectValues will return the result of the expression, which is the
+// elements of the result for a projection
+func (n *jmesNode) selectValues(root interface{}) ([]interface{}, bool) {
+	v := n.search(root)
+	if n.projects() {
+		values, _ := v.([]interface{})
+		return values, false
+	}
+	if v == nil {
+		return nil, true
+	}
+	return []interface{}{v}, true
+}
+
+// project will evaluate the expression against each element, leaving
+// out null results
+func (n *jmesNode) project(elems []interface{}) interface{} {
+	values := []interface{}{}
+	for _, elem := range elems {
+		if v := n.search(elem); v != nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// projects will return true if the result of the expression is a
+// projection, which selects any number of values
+func (n *jmesNode) projects() bool {
+	switch n.kind {
+	case jmesProjection, jmesValueProjection, jmesFilterProjection, jmesFlattenNode:
+		return true
+	case jmesSubexpression, jmesIndexExpression:
+		return n.children[0].projects() || n.children[1].projects()
+	}
+	return false
+}
+
+// jmesSliceOf will return the elements selected by a slice
+func jmesSliceOf(elems []interface{}, parts [3]*int) []interface{} {
+	step := 1
+	if parts[2] != nil {
+		step = *parts[2]
+	}
+	n := len(elems)
+	bound := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += n
+		}
+		if step < 0 {
+			return maxInt(-1, minInt(i, n-1))
+		}
+		return maxInt(0, minInt(i, n))
+	}
+	values := []interface{}{}
+	if step > 0 {
+		for i := bound(parts[0], 0); i < bound(parts[1], n); i += step {
+			values = append(values, elems[i])
+		}
+		return values
+	}
+	for i := bound(parts[0], n-1); i > bound(parts[1], -1); i += step {
+		values = append(values, elems[i])
+	}
+	return values
+}
+
+// jmesTruthy will return false for null, false, and empty strings,
+// arrays and objects
+func jmesTruthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// jmesCompareValues will compare a and b with op. Any values can be
+// compared for equality, only numbers can be ordered
+func jmesCompareValues(op string, a, b interface{}) interface{} {
+	switch op {
+	case "==":
+		return reflect.DeepEqual(a, b)
+	case "!=":
+		return !reflect.DeepEqual(a, b)
+	}
+	x, ok := toFloat64(a)
+	if !ok {
+		return nil
+	}
+	y, ok := toFloat64(b)
+	if !ok {
+		return nil
+	}
+	switch op {
+	case "<":
+		return x < y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	}
+	return x >= y
+}
+
+// jmesFunction is a JMESPath function. arity is the number of
+// arguments it takes, or -1 for one or more. Functions return null for
+// arguments of the wrong type
+type jmesFunction struct {
+	arity int
+	call  func(args []interface{}) interface{}
+}
+
+// jmesFunctions is a map of the supported JMESPath functions
+var jmesFunctions = map[string]jmesFunction{
+	"abs":         {1, jmesMath(math.Abs)},
+	"avg":         {1, jmesReduce(avg)},
+	"ceil":        {1, jmesMath(math.Ceil)},
+	"contains":    {2, jmesContains},
+	"ends_with":   {2, jmesStrings(strings.HasSuffix)},
+	"floor":       {1, jmesMath(math.Floor)},
+	"join":        {2, jmesJoin},
+	"keys":        {1, jmesKeys},
+	"length":      {1, jmesLength},
+	"max":         {1, jmesReduce(maximum)},
+	"min":         {1, jmesReduce(minimum)},
+	"not_null":    {-1, jmesNotNull},
+	"reverse":     {1, jmesReverse},
+	"sort":        {1, jmesSort},
+	"starts_with": {2, jmesStrings(strings.HasPrefix)},
+	"sum":         {1, jmesReduce(sum)},
+	"to_number":   {1, jmesToNumber},
+	"to_string":   {1, jmesToString},
+	"type":        {1, jmesType},
+	"values":      {1, jmesValues},
+}
+
+func jmesMath(fn func(float64) float64) func(args []interface{}) interface{} {
+	return func(args []interface{}) interface{} {
+		f, ok := toFloat64(args[0])
+		if !ok {
+			return nil
+		}
+		return fn(f)
+	}
+}
+
+func jmesReduce(fn aggregateFunc) func(args []interface{}) interface{} {
+	return func(args []interface{}) interface{} {
+		values, ok := numbers(args[0])
+		if !ok {
+			return nil
+		}
+		if v, ok := fn(values); ok {
+			return v
+		}
+		return nil
+	}
+}
+
+func jmesStrings(fn func(s, affix string) bool) func(args []interface{}) interface{} {
+	return func(args []interface{}) interface{} {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil
+		}
+		affix, ok := args[1].(string)
+		if !ok {
+			return nil
+		}
+		return fn(s, affix)
+	}
+}
+
+func jmesContains(args []interface{}) interface{} {
+	switch subject := args[0].(type) {
+	case string:
+		s, ok := args[1].(string)
+		return ok && strings.Contains(subject, s)
+	case []interface{}:
+		for _, elem := range subject {
+			if reflect.DeepEqual(elem, args[1]) {
+				return true
+			}
+		}
+		return false
+	}
+	return nil
+}
+
+func jmesJoin(args []interface{}) interface{} {
+	sep, ok := args[0].(string)
+	if !ok {
+		return nil
+	}
+	elems, ok := args[1].([]interface{})
+	if !ok {
+		return nil
+	}
+	parts := make([]string, len(elems))
+	for i, elem := range elems {
+		if parts[i], ok = elem.(string); !ok {
+			return nil
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func jmesKeys(args []interface{}) interface{} {
+	m, ok := args[0].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		values[i] = k
+	}
+	return values
+}
+
+func jmesValues(args []interface{}) interface{} {
+	if _, ok := args[0].(map[string]interface{}); !ok {
+		return nil
+	}
+	return children(args[0])
+}
+
+func jmesLength(args []interface{}) interface{} {
+	n, ok := length(args[0])
+	if !ok {
+		return nil
+	}
+	return float64(n)
+}
+
+func jmesNotNull(args []interface{}) interface{} {
+	for _, arg := range args {
+		if arg != nil {
+			return arg
+		}
+	}
+	return nil
+}
+
+func jmesReverse(args []interface{}) interface{} {
+	switch v := args[0].(type) {
+	case string:
+		runes := []rune(v)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	case []interface{}:
+		reversed := make([]interface{}, len(v))
+		for i, elem := range v {
+			reversed[len(v)-1-i] = elem
+		}
+		return reversed
+	}
+	return nil
+}
+
+func jmesSort(args []interface{}) interface{} {
+	elems, ok := args[0].([]interface{})
+	if !ok {
+		return nil
+	}
+	sorted := append([]interface{}{}, elems...)
+	if values, ok := numbers(sorted); ok {
+		sort.Float64s(values)
+		for i, v := range values {
+			sorted[i] = v
+		}
+		return sorted
+	}
+	for _, elem := range sorted {
+		if _, ok := elem.(string); !ok {
+			return nil
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].(string) < sorted[j].(string)
+	})
+	return sorted
+}
+
+func jmesToNumber(args []interface{}) interface{} {
+	if f, ok := toFloat64(args[0]); ok {
+		return f
+	}
+	if s, ok := args[0].(string); ok {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func jmesToString(args []interface{}) interface{} {
+	if s, ok := args[0].(string); ok {
+		return s
+	}
+	b, err := json.Marshal(args[0])
+	if err != nil {
+		return nil
+	}
+	return string(b)
+}
+
+func jmesType(args []interface{}) interface{} {
+	switch args[0].(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if _, ok := toFloat64(args[0]); ok {
+		return "number"
+	}
+	return nil
+}
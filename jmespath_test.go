@@ -0,0 +1,127 @@
+package grules
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJMESPath(t *testing.T) {
+	var props map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonPathStore), &props); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		path     string
+		expected []interface{}
+		definite bool
+	}{
+		{path: "store.bicycle.color", expected: []interface{}{"red"}, definite: true},
+		{path: `store."bicycle".color`, expected: []interface{}{"red"}, definite: true},
+		{path: "store.book[0].author", expected: []interface{}{"Nigel Rees"}, definite: true},
+		{path: "store.book[-1].author", expected: []interface{}{"J. R. R. Tolkien"}, definite: true},
+		{path: "store.book[*].author", expected: []interface{}{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{path: "store.book[1:3].price", expected: []interface{}{12.99, 8.99}},
+		{path: "store.book[::-2].price", expected: []interface{}{22.99, 12.99}},
+		{path: "store.*.color", expected: []interface{}{"red"}},
+		{path: "store.book[*].isbn", expected: []interface{}{"0-553-21311-3", "0-395-19395-8"}},
+		{path: "store.book[?isbn].title", expected: []interface{}{"Moby Dick", "The Lord of the Rings"}},
+		{path: "store.book[?!isbn].title", expected: []interface{}{"Sayings of the Century", "Sword of Honour"}},
+		{path: "store.book[?price < `10`].title", expected: []interface{}{"Sayings of the Century", "Moby Dick"}},
+		{path: "store.book[?price < `10` && category == 'fiction'].title", expected: []interface{}{"Moby Dick"}},
+		{path: "store.book[?price > `20` || author == 'Nigel Rees'].price", expected: []interface{}{8.95, 22.99}},
+		{path: "store.book[?starts_with(title, 'S')].author", expected: []interface{}{"Nigel Rees", "Evelyn Waugh"}},
+		{path: "store.book[*].price | [0]", expected: []interface{}{8.95}, definite: true},
+		{path: "store.book[*].[title, price][]", expected: []interface{}{"Sayings of the Century", 8.95, "Sword of Honour", 12.99, "Moby Dick", 8.99, "The Lord of the Rings", 22.99}},
+		{path: "store.bicycle.{c: color, p: price}", expected: []interface{}{map[string]interface{}{"c": "red", "p": 19.95}}, definite: true},
+		{path: "length(store.book)", expected: []interface{}{4.0}, definite: true},
+		{path: "max(store.book[*].price)", expected: []interface{}{22.99}, definite: true},
+		{path: "length(store.book[?category == 'fiction'])", expected: []interface{}{3.0}, definite: true},
+		{path: "contains(store.book[*].author, 'Herman Melville')", expected: []interface{}{true}, definite: true},
+		{path: "store.missing || limit", expected: []interface{}{10.0}, definite: true},
+		{path: "join(', ', sort(keys(store)))", expected: []interface{}{"bicycle, book"}, definite: true},
+		{path: "store.book[9].title", expected: nil, definite: true},
+		{path: "store.missing[*].title", expected: nil},
+	}
+	for _, c := range cases {
+		p, err := compileJMESPath(c.path)
+		if err != nil {
+			t.Fatalf("expected %s to compile, got %v", c.path, err)
+		}
+		res, definite := p.selectValues(props)
+		if definite != c.definite {
+			t.Fatalf("expected %s definite to be %v", c.path, c.definite)
+		}
+		if !reflect.DeepEqual(res, c.expected) {
+			t.Fatalf("expected %s to be %v, got %v", c.path, c.expected, res)
+		}
+	}
+
+	for _, path := range []string{"store.", "store[", "store.book[?price <]", "store.book[?price", "'unterminated", "nope(store)", "length(store, book)", "[::0]", "store..book"} {
+		if _, err := compileJMESPath(path); !errors.Is(err, ErrSyntax) {
+			t.Fatalf("expected %s to be a syntax error, got %v", path, err)
+		}
+	}
+}
+
+func BenchmarkJMESPath(b *testing.B) {
+	var props map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonPathStore), &props); err != nil {
+		b.Fatal(err)
+	}
+	p, err := compileJMESPath("store.book[?price < `10`].title")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		p.selectValues(props)
+	}
+}
+
+func TestWithPathLanguage(t *testing.T) {
+	var props map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonPathStore), &props); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		rule     string
+		expected bool
+	}{
+		{rule: `{"comparator":"eq","path":"store.bicycle.color","value":"red"}`, expected: true},
+		{rule: `{"comparator":"eq","path":"store.book[?price < ` + "`10`" + `].author","value":"Herman Melville"}`, expected: true},
+		{rule: `{"comparator":"eq","path":"store.book[?price > ` + "`10`" + `].author","value":"Herman Melville"}`, expected: false},
+		{rule: `{"comparator":"count-eq","path":"store.book[?isbn]","value":2}`, expected: true},
+		{rule: `{"comparator":"gte","path":"length(store.book)","value":4}`, expected: true},
+		{rule: `{"comparator":"max-gt","path":"store.book[*].price","value":20}`, expected: true},
+		{rule: `{"comparator":"eq","path":"store.missing","value":"red"}`, expected: false},
+		{rule: `{"comparator":"nexists","path":"store.book[?price > ` + "`100`" + `]"}`, expected: true},
+	}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[` + c.rule + `]}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err = e.WithPathLanguage(PathJMESPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := e.Evaluate(props); res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","composites":[{"operator":"or","rules":[{"comparator":"eq","path":"store[","value":1}]}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = e.WithPathLanguage(PathJMESPath)
+	var ruleErr *RuleError
+	if !errors.As(err, &ruleErr) || ruleErr.Node != "composites[0].composites[0].rules[0]" || !errors.Is(err, ErrSyntax) {
+		t.Fatalf("expected a syntax error at the rule, got %v", err)
+	}
+
+	if _, err := e.WithPathLanguage(PathDotted); err != nil {
+		t.Fatalf("expected dotted paths to never fail to compile, got %v", err)
+	}
+}
@@ -0,0 +1,558 @@
+package grules
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath expression. definite is true if the
+// path can only ever select a single value, like "$.user.name"
+type jsonPath struct {
+	steps    []jsonPathStep
+	definite bool
+}
+
+// jsonPathStep is a single step of a JSONPath expression, which
+// selects some of the children of each node, or of each node and all
+// of its descendants if recursive is true
+type jsonPathStep struct {
+	recursive bool
+	wildcard  bool
+	names     []string
+	indexes   []int
+	slice     *[2]*int
+	filter    jsonPathFilter
+}
+
+// jsonPathFilter is a compiled filter expression, it is given the
+// child being filtered and the root of the props
+type jsonPathFilter func(node, root interface{}) bool
+
+// compileJSONPath will return the compiled JSONPath expression. The
+// supported subset is the root $, children .name and ['name'], the
+// wildcards .* and [*], indexes [0] and [-1], unions [0,1] and
+// ['a','b'], slices [1:3], recursive descent ..name and filters like
+// [?(@.price < 10 && @.isbn)]. A path that does not start with $ is
+// read as if it started with "$.", so "user.name" is still valid
+func compileJSONPath(path string) (*jsonPath, error) {
+	s := path
+	if !strings.HasPrefix(s, "$") {
+		s = "$." + s
+	}
+	jp := &jsonPathParser{s: s, pos: 1}
+	p, err := jp.path()
+	if err != nil {
+		return nil, fmt.Errorf("%w in %q at %d: %v", ErrSyntax, path, jp.pos, err)
+	}
+	return p, nil
+}
+
+// values will return every value the path selects from root. Null
+// values are left out, as they are for other paths
+func (p *jsonPath) values(root interface{}) []interface{} {
+	nodes := []interface{}{root}
+	for _, step := range p.steps {
+		nodes = step.apply(nodes, root)
+	}
+	values := nodes[:0]
+	for _, n := range nodes {
+		if n != nil {
+			values = append(values, n)
+		}
+	}
+	return values
+}
+
+// apply will return the nodes the step selects from each of the nodes
+func (s jsonPathStep) apply(nodes []interface{}, root interface{}) []interface{} {
+	selected := []interface{}{}
+	for _, n := range nodes {
+		if !s.recursive {
+			selected = s.selectFrom(n, root, selected)
+			continue
+		}
+		for _, d := range descendants(n, nil) {
+			selected = s.selectFrom(d, root, selected)
+		}
+	}
+	return selected
+}
+
+// selectFrom will add the children of the node selected by the step to
+// selected
+func (s jsonPathStep) selectFrom(node, root interface{}, selected []interface{}) []interface{} {
+	switch {
+	case s.wildcard:
+		return append(selected, children(node)...)
+	case s.filter != nil:
+		for _, c := range children(node) {
+			if s.filter(c, root) {
+				selected = append(selected, c)
+			}
+		}
+		return selected
+	case s.names != nil:
+		if m, ok := node.(map[string]interface{}); ok {
+			for _, name := range s.names {
+				if v, ok := m[name]; ok {
+					selected = append(selected, v)
+				}
+			}
+		}
+		return selected
+	}
+
+	elems, ok := node.([]interface{})
+	if !ok {
+		return selected
+	}
+	if s.slice != nil {
+		start, end := 0, len(elems)
+		if s.slice[0] != nil {
+			start = clampIndex(*s.slice[0], len(elems))
+		}
+		if s.slice[1] != nil {
+			end = clampIndex(*s.slice[1], len(elems))
+		}
+		for i := start; i < end; i++ {
+			selected = append(selected, elems[i])
+		}
+		return selected
+	}
+	for _, i := range s.indexes {
+		if i < 0 {
+			i += len(elems)
+		}
+		if i >= 0 && i < len(elems) {
+			selected = append(selected, elems[i])
+		}
+	}
+	return selected
+}
+
+// clampIndex will turn a slice bound into an index between 0 and n,
+// counting negative bounds from the end
+func clampIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	return maxInt(0, minInt(i, n))
+}
+
+// children will return the values of an object, in key order, or the
+// elements of an array
+func children(node interface{}) []interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for k := range node {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := make([]interface{}, len(keys))
+		for i, k := range keys {
+			values[i] = node[k]
+		}
+		return values
+	case []interface{}:
+		return node
+	}
+	return nil
+}
+
+// descendants will add the node and all of its descendants to nodes,
+// parents before their children
+func descendants(node interface{}, nodes []interface{}) []interface{} {
+	nodes = append(nodes, node)
+	for _, c := range children(node) {
+		nodes = descendants(c, nodes)
+	}
+	return nodes
+}
+
+// jsonPathParser holds the state of compiling a JSONPath expression
+type jsonPathParser struct {
+	s   string
+	pos int
+}
+
+// errJSONPathEnd is returned when a JSONPath expression ends too soon
+var errJSONPathEnd = errors.New("unexpected end of path")
+
+// path will parse the steps of the expression until its end
+func (jp *jsonPathParser) path() (*jsonPath, error) {
+	p := &jsonPath{definite: true}
+	for jp.pos < len(jp.s) {
+		step, err := jp.step()
+		if err != nil {
+			return nil, err
+		}
+		if step.recursive || step.wildcard || step.filter != nil || step.slice != nil || len(step.names)+len(step.indexes) > 1 {
+			p.definite = false
+		}
+		p.steps = append(p.steps, step)
+	}
+	return p, nil
+}
+
+// step will parse a single .name, ..name or [...] step
+func (jp *jsonPathParser) step() (jsonPathStep, error) {
+	step := jsonPathStep{}
+	switch {
+	case jp.accept(".."):
+		step.recursive = true
+		if jp.peek() == '[' {
+			return jp.bracket(step)
+		}
+		return jp.member(step)
+	case jp.accept("."):
+		return jp.member(step)
+	case jp.peek() == '[':
+		return jp.bracket(step)
+	}
+	return step, jp.unexpected()
+}
+
+// member will parse the name or * after a dot
+func (jp *jsonPathParser) member(step jsonPathStep) (jsonPathStep, error) {
+	if jp.accept("*") {
+		step.wildcard = true
+		return step, nil
+	}
+	start := jp.pos
+	for jp.pos < len(jp.s) && !strings.ContainsRune(".[]()=!<>&| ", rune(jp.s[jp.pos])) {
+		jp.pos++
+	}
+	if jp.pos == start {
+		return step, jp.unexpected()
+	}
+	step.names = []string{jp.s[start:jp.pos]}
+	return step, nil
+}
+
+// bracket will parse a wildcard, filter, union or slice in brackets
+func (jp *jsonPathParser) bracket(step jsonPathStep) (jsonPathStep, error) {
+	jp.accept("[")
+	jp.space()
+	switch {
+	case jp.accept("*"):
+		step.wildcard = true
+	case jp.accept("?("):
+		filter, err := jp.or()
+		if err != nil {
+			return step, err
+		}
+		jp.space()
+		if !jp.accept(")") {
+			return step, jp.unexpected()
+		}
+		step.filter = filter
+	case jp.peek() == '\'' || jp.peek() == '"':
+		step.names = []string{}
+		for {
+			name, err := jp.quoted()
+			if err != nil {
+				return step, err
+			}
+			step.names = append(step.names, name)
+			jp.space()
+			if !jp.accept(",") {
+				break
+			}
+			jp.space()
+		}
+	default:
+		if err := jp.indexes(&step); err != nil {
+			return step, err
+		}
+	}
+	jp.space()
+	if !jp.accept("]") {
+		return step, jp.unexpected()
+	}
+	return step, nil
+}
+
+// indexes will parse a union of indexes or a slice
+func (jp *jsonPathParser) indexes(step *jsonPathStep) error {
+	start, err := jp.integer(true)
+	if err != nil {
+		return err
+	}
+	jp.space()
+	if jp.accept(":") {
+		jp.space()
+		end, err := jp.integer(true)
+		if err != nil {
+			return err
+		}
+		step.slice = &[2]*int{start, end}
+		return nil
+	}
+	if start == nil {
+		return jp.unexpected()
+	}
+	step.indexes = []int{*start}
+	for jp.accept(",") {
+		jp.space()
+		i, err := jp.integer(false)
+		if err != nil {
+			return err
+		}
+		step.indexes = append(step.indexes, *i)
+		jp.space()
+	}
+	return nil
+}
+
+// integer will parse a possibly negative integer, which may be left
+// out if optional is true
+func (jp *jsonPathParser) integer(optional bool) (*int, error) {
+	start := jp.pos
+	jp.accept("-")
+	for jp.pos < len(jp.s) && isDigit(jp.s[jp.pos]) {
+		jp.pos++
+	}
+	if jp.pos == start && optional {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(jp.s[start:jp.pos])
+	if err != nil {
+		jp.pos = start
+		return nil, jp.unexpected()
+	}
+	return &i, nil
+}
+
+// quoted will parse a string in single or double quotes, a backslash
+// escapes the next character
+func (jp *jsonPathParser) quoted() (string, error) {
+	quote := jp.s[jp.pos]
+	jp.pos++
+	var b strings.Builder
+	for jp.pos < len(jp.s) {
+		c := jp.s[jp.pos]
+		jp.pos++
+		switch {
+		case c == '\\' && jp.pos < len(jp.s):
+			b.WriteByte(jp.s[jp.pos])
+			jp.pos++
+		case c == quote:
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", errJSONPathEnd
+}
+
+// or will parse filter terms joined by ||
+func (jp *jsonPathParser) or() (jsonPathFilter, error) {
+	left, err := jp.and()
+	if err != nil {
+		return nil, err
+	}
+	for jp.space(); jp.accept("||"); jp.space() {
+		right, err := jp.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(node, root interface{}) bool {
+			return l(node, root) || right(node, root)
+		}
+	}
+	return left, nil
+}
+
+// and will parse filter terms joined by &&
+func (jp *jsonPathParser) and() (jsonPathFilter, error) {
+	left, err := jp.term()
+	if err != nil {
+		return nil, err
+	}
+	for jp.space(); jp.accept("&&"); jp.space() {
+		right, err := jp.term()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(node, root interface{}) bool {
+			return l(node, root) && right(node, root)
+		}
+	}
+	return left, nil
+}
+
+// jsonPathComparisons is a map of the comparison operators that can be
+// used in a filter to the comparator each one uses, longest first
+var jsonPathComparisons = []struct {
+	op         string
+	comparator Comparator
+}{
+	{"==", equal},
+	{"!=", notEqual},
+	{"<=", lessThanEqual},
+	{">=", greaterThanEqual},
+	{"<", lessThan},
+	{">", greaterThan},
+}
+
+// term will parse a negated term, a filter in parentheses, or an
+// operand that is either compared to another operand or tested for
+// existence
+func (jp *jsonPathParser) term() (jsonPathFilter, error) {
+	jp.space()
+	if jp.accept("!") {
+		f, err := jp.term()
+		if err != nil {
+			return nil, err
+		}
+		return func(node, root interface{}) bool {
+			return !f(node, root)
+		}, nil
+	}
+	if jp.accept("(") {
+		f, err := jp.or()
+		if err != nil {
+			return nil, err
+		}
+		jp.space()
+		if !jp.accept(")") {
+			return nil, jp.unexpected()
+		}
+		return f, nil
+	}
+
+	left, err := jp.operand()
+	if err != nil {
+		return nil, err
+	}
+	jp.space()
+	for _, c := range jsonPathComparisons {
+		if !jp.accept(c.op) {
+			continue
+		}
+		jp.space()
+		right, err := jp.operand()
+		if err != nil {
+			return nil, err
+		}
+		comp := c.comparator
+		return func(node, root interface{}) bool {
+			for _, l := range left(node, root) {
+				for _, r := range right(node, root) {
+					if comp(l, r) {
+						return true
+					}
+				}
+			}
+			return false
+		}, nil
+	}
+	return func(node, root interface{}) bool {
+		return len(left(node, root)) > 0
+	}, nil
+}
+
+// operand will parse a path relative to the current node (@) or the
+// root ($), or a literal, returning a function that gives its values
+func (jp *jsonPathParser) operand() (func(node, root interface{}) []interface{}, error) {
+	switch c := jp.peek(); {
+	case c == '@' || c == '$':
+		jp.pos++
+		p := &jsonPath{}
+		for c := jp.peek(); c == '.' || c == '['; c = jp.peek() {
+			step, err := jp.step()
+			if err != nil {
+				return nil, err
+			}
+			p.steps = append(p.steps, step)
+		}
+		if c == '$' {
+			return func(node, root interface{}) []interface{} {
+				return p.values(root)
+			}, nil
+		}
+		return func(node, root interface{}) []interface{} {
+			return p.values(node)
+		}, nil
+	case c == '\'' || c == '"':
+		s, err := jp.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return literalOperand(s), nil
+	case jp.accept("true"):
+		return literalOperand(true), nil
+	case jp.accept("false"):
+		return literalOperand(false), nil
+	}
+
+	start := jp.pos
+	for jp.pos < len(jp.s) && (isDigit(jp.s[jp.pos]) || strings.IndexByte("+-.eE", jp.s[jp.pos]) >= 0) {
+		jp.pos++
+	}
+	n, err := strconv.ParseFloat(jp.s[start:jp.pos], 64)
+	if err != nil {
+		jp.pos = start
+		return nil, jp.unexpected()
+	}
+	return literalOperand(n), nil
+}
+
+// literalOperand will return an operand that always gives v
+func literalOperand(v interface{}) func(node, root interface{}) []interface{} {
+	values := []interface{}{v}
+	return func(node, root interface{}) []interface{} {
+		return values
+	}
+}
+
+// peek will return the next character, or 0 at the end
+func (jp *jsonPathParser) peek() byte {
+	if jp.pos >= len(jp.s) {
+		return 0
+	}
+	return jp.s[jp.pos]
+}
+
+// accept will consume text if it is next
+func (jp *jsonPathParser) accept(text string) bool {
+	if strings.HasPrefix(jp.s[jp.pos:], text) {
+		jp.pos += len(text)
+		return true
+	}
+	return false
+}
+
+// space will skip any spaces
+func (jp *jsonPathParser) space() {
+	for jp.pos < len(jp.s) && jp.s[jp.pos] == ' ' {
+		jp.pos++
+	}
+}
+
+// unexpected will return an error for the next character
+func (jp *jsonPathParser) unexpected() error {
+	if jp.pos >= len(jp.s) {
+		return errJSONPathEnd
+	}
+	return fmt.Errorf("unexpected %q", jp.s[jp.pos])
+}
+
+// WithJSONPath will return a copy of the engine that reads every rule
+// path as a JSONPath expression, see compileJSONPath for the supported
+// syntax. It is the same as WithPathLanguage(PathJSONPath)
+func (e Engine) WithJSONPath() (Engine, error) {
+	return e.WithPathLanguage(PathJSONPath)
+}
+
+// selectValues will return the values the path selects, and whether it
+// always selects at most one
+func (p *jsonPath) selectValues(root interface{}) ([]interface{}, bool) {
+	return p.values(root), p.definite
+}
@@ -0,0 +1,134 @@
+package grules
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const jsonPathStore = `{
+	"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+			{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+		],
+		"bicycle": {"color": "red", "price": 19.95}
+	},
+	"limit": 10
+}`
+
+func TestJSONPath(t *testing.T) {
+	var props map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonPathStore), &props); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		path     string
+		expected []interface{}
+		definite bool
+	}{
+		{path: "$.store.bicycle.color", expected: []interface{}{"red"}, definite: true},
+		{path: "store.bicycle.color", expected: []interface{}{"red"}, definite: true},
+		{path: "$['store']['bicycle']['color']", expected: []interface{}{"red"}, definite: true},
+		{path: "$.store.book[0].author", expected: []interface{}{"Nigel Rees"}, definite: true},
+		{path: "$.store.book[-1].author", expected: []interface{}{"J. R. R. Tolkien"}, definite: true},
+		{path: "$.store.book[*].author", expected: []interface{}{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{path: "$.store.book[0,2].price", expected: []interface{}{8.95, 8.99}},
+		{path: "$.store.book[1:3].price", expected: []interface{}{12.99, 8.99}},
+		{path: "$.store.book[:1].price", expected: []interface{}{8.95}},
+		{path: "$.store.book[-2:].price", expected: []interface{}{8.99, 22.99}},
+		{path: "$.store.bicycle['color','price']", expected: []interface{}{"red", 19.95}},
+		{path: "$.store.*.color", expected: []interface{}{"red"}},
+		{path: "$..isbn", expected: []interface{}{"0-553-21311-3", "0-395-19395-8"}},
+		{path: "$.store..price", expected: []interface{}{19.95, 8.95, 12.99, 8.99, 22.99}},
+		{path: "$..book[?(@.isbn)].title", expected: []interface{}{"Moby Dick", "The Lord of the Rings"}},
+		{path: "$..book[?(!@.isbn)].title", expected: []interface{}{"Sayings of the Century", "Sword of Honour"}},
+		{path: "$.store.book[?(@.price < 10)].title", expected: []interface{}{"Sayings of the Century", "Moby Dick"}},
+		{path: "$.store.book[?(@.price < $.limit && @.category == 'fiction')].title", expected: []interface{}{"Moby Dick"}},
+		{path: "$.store.book[?(@.price > 20 || @.author == \"Nigel Rees\")].price", expected: []interface{}{8.95, 22.99}},
+		{path: "$.store.book[?((@.price > 20))].price", expected: []interface{}{22.99}},
+		{path: "$.store.book[9].title", expected: []interface{}{}, definite: true},
+		{path: "$.store.missing", expected: []interface{}{}, definite: true},
+	}
+	for _, c := range cases {
+		p, err := compileJSONPath(c.path)
+		if err != nil {
+			t.Fatalf("expected %s to compile, got %v", c.path, err)
+		}
+		if p.definite != c.definite {
+			t.Fatalf("expected %s definite to be %v", c.path, c.definite)
+		}
+		if res := p.values(props); !reflect.DeepEqual(res, c.expected) {
+			t.Fatalf("expected %s to be %v, got %v", c.path, c.expected, res)
+		}
+	}
+
+	for _, path := range []string{"$.", "$.store[", "$.store['book'", "$.store[?(@.price <)]", "$.store[?(@.price]", "$store", "$.store[a]"} {
+		if _, err := compileJSONPath(path); !errors.Is(err, ErrSyntax) {
+			t.Fatalf("expected %s to be a syntax error, got %v", path, err)
+		}
+	}
+}
+
+func BenchmarkJSONPath(b *testing.B) {
+	var props map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonPathStore), &props); err != nil {
+		b.Fatal(err)
+	}
+	p, err := compileJSONPath("$.store.book[?(@.price < 10)].title")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		p.values(props)
+	}
+}
+
+func TestWithJSONPath(t *testing.T) {
+	var props map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonPathStore), &props); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		rule     string
+		expected bool
+	}{
+		{rule: `{"comparator":"eq","path":"$.store.bicycle.color","value":"red"}`, expected: true},
+		{rule: `{"comparator":"eq","path":"$..book[?(@.price < 10)].author","value":"Herman Melville"}`, expected: true},
+		{rule: `{"comparator":"eq","path":"$..book[?(@.price > 10)].author","value":"Herman Melville"}`, expected: false},
+		{rule: `{"comparator":"count-eq","path":"$..book[?(@.isbn)]","value":2}`, expected: true},
+		{rule: `{"comparator":"count-eq","path":"$.store.book","value":4}`, expected: true},
+		{rule: `{"comparator":"max-gt","path":"$..price","value":20}`, expected: true},
+		{rule: `{"comparator":"eq","path":"$.store.missing","value":"red"}`, expected: false},
+		{rule: `{"comparator":"nexists","path":"$.store.book[?(@.price > 100)]"}`, expected: true},
+	}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[` + c.rule + `]}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err = e.WithJSONPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := e.queries[e.Composites[0].Rules[0].Path]; !ok {
+			t.Fatalf("expected case %d to keep its compiled path", i)
+		}
+		if res := e.Evaluate(props); res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","composites":[{"operator":"or","rules":[{"comparator":"eq","path":"$.store[","value":1}]}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = e.WithJSONPath()
+	var ruleErr *RuleError
+	if !errors.As(err, &ruleErr) || ruleErr.Node != "composites[0].composites[0].rules[0]" || !errors.Is(err, ErrSyntax) {
+		t.Fatalf("expected a syntax error at the rule, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package grules
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // register the SHA-256 hash
+	_ "crypto/sha512" // register the SHA-384 and SHA-512 hashes
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Keyfunc is a function that should return the key used to verify a
+// token. It is given the decoded header of the token so the key can
+// be selected by "kid" or "alg". HMAC algorithms expect a []byte, RSA
+// algorithms expect an *rsa.PublicKey
+type Keyfunc func(header map[string]interface{}) (interface{}, error)
+
+var (
+	errMalformedToken   = errors.New("grules: malformed token")
+	errUnsupportedAlg   = errors.New("grules: unsupported signing algorithm")
+	errInvalidKey       = errors.New("grules: invalid key for signing algorithm")
+	errInvalidSignature = errors.New("grules: invalid token signature")
+	errTokenExpired     = errors.New("grules: token is expired")
+	errTokenNotValidYet = errors.New("grules: token is not valid yet")
+)
+
+// jwtHashes is a map of all the supported signing algorithms to the
+// hash they use
+var jwtHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+}
+
+// PropsFromJWT will validate the given token and return its claims
+// under the "claims" key, so rules can be written against paths like
+// "claims.sub". The signature, "exp" and "nbf" claims are verified
+func PropsFromJWT(token string, keyfunc Keyfunc) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+
+	var header map[string]interface{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+
+	key, err := keyfunc(header)
+	if err != nil {
+		return nil, err
+	}
+	alg, _ := header["alg"].(string)
+	err = verifySignature(alg, parts[0]+"."+parts[1], sig, key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := float64(Now().Unix())
+	if exp, ok := claims["exp"].(float64); ok && now >= exp {
+		return nil, errTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < nbf {
+		return nil, errTokenNotValidYet
+	}
+
+	return map[string]interface{}{
+		"claims": claims,
+	}, nil
+}
+
+// decodeSegment will decode a base64url encoded JSON segment of a token
+func decodeSegment(seg string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return errMalformedToken
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errMalformedToken
+	}
+	return nil
+}
+
+// verifySignature will make sure sig is a valid signature of signed
+// using the given algorithm and key
+func verifySignature(alg, signed string, sig []byte, key interface{}) error {
+	hash, ok := jwtHashes[alg]
+	if !ok || !hash.Available() {
+		return errUnsupportedAlg
+	}
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return errInvalidKey
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errInvalidSignature
+		}
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errInvalidKey
+		}
+		h := hash.New()
+		h.Write([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(pub, hash, h.Sum(nil), sig); err != nil {
+			return errInvalidSignature
+		}
+	}
+
+	return nil
+}
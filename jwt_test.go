@@ -0,0 +1,149 @@
+package grules
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func signHS256(t *testing.T, claims map[string]interface{}, secret []byte) string {
+	header, _ := json.Marshal(map[string]interface{}{"alg": "HS256", "typ": "JWT"})
+	body, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestPropsFromJWT(t *testing.T) {
+	secret := []byte("secret")
+	keyfunc := func(header map[string]interface{}) (interface{}, error) {
+		return secret, nil
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		token := signHS256(t, map[string]interface{}{
+			"sub":  "1234",
+			"role": "admin",
+			"exp":  float64(time.Now().Add(time.Hour).Unix()),
+		}, secret)
+		props, err := PropsFromJWT(token, keyfunc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		e := NewEngine()
+		e.Composites = []Composite{
+			Composite{
+				Operator: OperatorAnd,
+				Rules: []Rule{
+					Rule{
+						Comparator: "eq",
+						Path:       "claims.role",
+						Value:      "admin",
+					},
+				},
+			},
+		}
+		if e.Evaluate(props) != true {
+			t.Fatal("expected engine to pass")
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		token := signHS256(t, map[string]interface{}{"sub": "1234"}, []byte("other"))
+		_, err := PropsFromJWT(token, keyfunc)
+		if err != errInvalidSignature {
+			t.Fatalf("expected invalid signature, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		token := signHS256(t, map[string]interface{}{
+			"exp": float64(time.Now().Add(-time.Hour).Unix()),
+		}, secret)
+		_, err := PropsFromJWT(token, keyfunc)
+		if err != errTokenExpired {
+			t.Fatalf("expected expired token, got %v", err)
+		}
+	})
+
+	t.Run("not valid yet", func(t *testing.T) {
+		token := signHS256(t, map[string]interface{}{
+			"nbf": float64(time.Now().Add(time.Hour).Unix()),
+		}, secret)
+		_, err := PropsFromJWT(token, keyfunc)
+		if err != errTokenNotValidYet {
+			t.Fatalf("expected token not valid yet, got %v", err)
+		}
+	})
+
+	t.Run("alg none", func(t *testing.T) {
+		header, _ := json.Marshal(map[string]interface{}{"alg": "none"})
+		body, _ := json.Marshal(map[string]interface{}{"sub": "1234"})
+		token := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body) + "."
+		_, err := PropsFromJWT(token, keyfunc)
+		if err != errUnsupportedAlg {
+			t.Fatalf("expected unsupported algorithm, got %v", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := PropsFromJWT("not-a-token", keyfunc)
+		if err != errMalformedToken {
+			t.Fatalf("expected malformed token, got %v", err)
+		}
+	})
+
+	t.Run("keyfunc error", func(t *testing.T) {
+		token := signHS256(t, map[string]interface{}{"sub": "1234"}, secret)
+		want := errors.New("unknown kid")
+		_, err := PropsFromJWT(token, func(header map[string]interface{}) (interface{}, error) {
+			return nil, want
+		})
+		if err != want {
+			t.Fatalf("expected keyfunc error, got %v", err)
+		}
+	})
+
+	t.Run("rsa", func(t *testing.T) {
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header, _ := json.Marshal(map[string]interface{}{"alg": "RS256"})
+		body, _ := json.Marshal(map[string]interface{}{"sub": "1234"})
+		signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+		sum := sha256.Sum256([]byte(signed))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		token := signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+
+		props, err := PropsFromJWT(token, func(header map[string]interface{}) (interface{}, error) {
+			return &priv.PublicKey, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pluck(props, "claims.sub") != "1234" {
+			t.Fatal("expected claims.sub to be 1234")
+		}
+
+		_, err = PropsFromJWT(token, keyfunc)
+		if err != errInvalidKey {
+			t.Fatalf("expected invalid key, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package grules
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords is a set of common English words that carry no meaning on
+// their own, which are left out when the stopwords option is set
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"have": true, "i": true, "in": true, "is": true, "it": true, "its": true,
+	"me": true, "my": true, "of": true, "on": true, "or": true, "our": true,
+	"so": true, "that": true, "the": true, "their": true, "this": true, "to": true,
+	"was": true, "we": true, "were": true, "will": true, "with": true, "you": true,
+	"your": true,
+}
+
+// stemSuffixes is a list of the suffixes removed by stem, longest
+// first
+var stemSuffixes = []string{"ingly", "edly", "ness", "ment", "ing", "ies", "ied", "ed", "ly", "es", "s"}
+
+// keywordOptions is how the keywords comparator matches
+type keywordOptions struct {
+	keywords  [][]string
+	stem      bool
+	stopwords bool
+}
+
+// keywords will return true if the text a contains any of the keywords
+// in b. Keywords can be phrases of more than one word, which must
+// appear together. b is either an array of keywords or an object with
+// the "keywords" array and the optional "stem" and "stopwords" flags.
+// stem matches words with the same stem, like "scam" and "scammed",
+// and stopwords ignores common words like "the", so "click here" also
+// matches "click on here"
+func keywords(a, b interface{}) bool {
+	text, ok := a.(string)
+	if !ok {
+		return false
+	}
+	opts, ok := parseKeywordOptions(b)
+	if !ok {
+		return false
+	}
+	tokens := opts.tokenize(text)
+	for _, keyword := range opts.keywords {
+		if len(keyword) > 0 && containsPhrase(tokens, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseKeywordOptions will read the options of the keywords
+// comparator, ok is false if b is not in one of the supported forms
+func parseKeywordOptions(b interface{}) (keywordOptions, bool) {
+	opts := keywordOptions{}
+	list, ok := b.([]interface{})
+	if !ok {
+		m, ok := b.(map[string]interface{})
+		if !ok {
+			return opts, false
+		}
+		list, ok = m["keywords"].([]interface{})
+		if !ok {
+			return opts, false
+		}
+		opts.stem, _ = m["stem"].(bool)
+		opts.stopwords, _ = m["stopwords"].(bool)
+	}
+	for _, k := range list {
+		s, ok := k.(string)
+		if !ok {
+			return opts, false
+		}
+		opts.keywords = append(opts.keywords, opts.tokenize(s))
+	}
+	return opts, true
+}
+
+// tokenize will split text into lower case words, removing stopwords
+// and stemming each word if the options say to
+func (opts keywordOptions) tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.Trim(w, "'")
+		if w == "" || (opts.stopwords && stopwords[w]) {
+			continue
+		}
+		if opts.stem {
+			w = stem(w)
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// stem will remove a common English suffix from a word, along with a
+// doubled final consonant, so "scammed", "scamming" and "scams" all
+// become "scam". It is a light stemmer, it does not know every word
+func stem(word string) string {
+	for _, suffix := range stemSuffixes {
+		if len(word)-len(suffix) >= 3 && strings.HasSuffix(word, suffix) {
+			word = strings.TrimSuffix(word, suffix)
+			if suffix == "ies" || suffix == "ied" {
+				word += "y"
+			}
+			n := len(word)
+			if n >= 2 && word[n-1] == word[n-2] && !strings.ContainsRune("aeiousl", rune(word[n-1])) {
+				word = word[:n-1]
+			}
+			return word
+		}
+	}
+	return word
+}
+
+// containsPhrase will return true if the words of phrase appear
+// together, in order, in tokens
+func containsPhrase(tokens, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		match := true
+		for j, w := range phrase {
+			if tokens[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package grules
+
+import (
+	"testing"
+)
+
+func TestKeywords(t *testing.T) {
+	stemmed := map[string]interface{}{
+		"keywords": []interface{}{"scam", "click here"},
+		"stem":     true,
+	}
+	both := map[string]interface{}{
+		"keywords":  []interface{}{"scam", "click here"},
+		"stem":      true,
+		"stopwords": true,
+	}
+	cases := []testCase{
+		testCase{args: []interface{}{"This is a SCAM!", []interface{}{"scam", "fraud"}}, expected: true},
+		testCase{args: []interface{}{"Please click here now", []interface{}{"click here"}}, expected: true},
+		testCase{args: []interface{}{"Please click on here now", []interface{}{"click here"}}, expected: false},
+		testCase{args: []interface{}{"Please click on here now", both}, expected: true},
+		testCase{args: []interface{}{"I was scammed", []interface{}{"scam"}}, expected: false},
+		testCase{args: []interface{}{"I was scammed", stemmed}, expected: true},
+		testCase{args: []interface{}{"Stop scamming people", stemmed}, expected: true},
+		testCase{args: []interface{}{"A scampi recipe", stemmed}, expected: false},
+		testCase{args: []interface{}{"Nothing to see", []interface{}{"scam"}}, expected: false},
+		testCase{args: []interface{}{"Nothing to see", []interface{}{}}, expected: false},
+		testCase{args: []interface{}{"This is a scam", "scam"}, expected: false},
+		testCase{args: []interface{}{"This is a scam", []interface{}{float64(1)}}, expected: false},
+		testCase{args: []interface{}{float64(1), []interface{}{"scam"}}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := keywords(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkKeywords(b *testing.B) {
+	opts := map[string]interface{}{
+		"keywords":  []interface{}{"scam", "click here", "free money"},
+		"stem":      true,
+		"stopwords": true,
+	}
+	for i := 0; i < b.N; i++ {
+		keywords("Please click on the link below to claim your free money", opts)
+	}
+}
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"scammed":  "scam",
+		"scamming": "scam",
+		"scams":    "scam",
+		"parties":  "party",
+		"quickly":  "quick",
+		"falls":    "fall",
+		"is":       "is",
+		"bus":      "bus",
+	}
+	for word, expected := range cases {
+		if res := stem(word); res != expected {
+			t.Fatalf("expected %q to be %q, got %q", word, expected, res)
+		}
+	}
+}
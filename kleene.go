@@ -0,0 +1,112 @@
+package grules
+
+// Truth is the result of an evaluation in three valued logic, where a
+// rule whose path is missing is neither true nor false but unknown
+type Truth int
+
+const (
+	// TruthFalse is a definite false
+	TruthFalse Truth = iota
+	// TruthTrue is a definite true
+	TruthTrue
+	// TruthUnknown is a result that depends on missing data
+	TruthUnknown
+)
+
+// String will return the name of the truth value
+func (t Truth) String() string {
+	switch t {
+	case TruthFalse:
+		return "false"
+	case TruthTrue:
+		return "true"
+	}
+	return "unknown"
+}
+
+// truthOf will convert a bool to a truth value
+func truthOf(b bool) Truth {
+	if b {
+		return TruthTrue
+	}
+	return TruthFalse
+}
+
+// EvaluateKleene will evaluate the engine in three valued logic. A rule
+// whose path is missing is unknown, unless its OnMissing says what it
+// should be instead, and an unknown child makes its composite unknown
+// when the result of the composite depends on it. For example "a and
+// b" is false if a is false even if b is unknown, but unknown if a is
+// true. This lets a rejection because of missing data be treated
+// differently from a definite rejection
+func (e Engine) EvaluateKleene(props map[string]interface{}) Truth {
+	if !e.prepare(props) {
+		return TruthFalse
+	}
+	res := TruthTrue
+	for _, c := range e.Composites {
+		t, skipped := c.truth(props, &e)
+		if e.err != nil {
+			return TruthFalse
+		}
+		if skipped || t == TruthTrue {
+			continue
+		}
+		if t == TruthFalse {
+			return TruthFalse
+		}
+		res = TruthUnknown
+	}
+	return res
+}
+
+// truth will return the result of the composite in three valued
+// logic, or skipped if every one of its children was skipped. Every
+// child is evaluated, and the operator is called once for each number
+// of the unknown children that could be true. If it gives the same
+// result every time, that is the result, otherwise it is unknown. This
+// is exact for the default operators, which only depend on how many of
+// their children are true, and custom operators are assumed to do the
+// same. Skipped children are given to the operator like in Evaluate,
+// see skipResult
+func (c Composite) truth(props map[string]interface{}, e *Engine) (Truth, bool) {
+	op, ok := c.operator(e)
+	if !ok {
+		return TruthFalse, false
+	}
+
+	n := len(c.Rules) + len(c.Composites)
+	trues, unknowns, skips := 0, 0, 0
+	count := func(t Truth, skipped bool) {
+		if skipped {
+			skips++
+			t = truthOf(skipResult(c.Operator))
+		}
+		switch t {
+		case TruthTrue:
+			trues++
+		case TruthUnknown:
+			unknowns++
+		}
+	}
+	for _, r := range c.Rules {
+		count(r.truth(props, e))
+	}
+	for _, cc := range c.Composites {
+		count(cc.truth(props, e))
+	}
+	if n > 0 && skips == n {
+		return TruthFalse, true
+	}
+
+	res := truthOf(op(n, func(i int) bool { return i < trues }))
+	for t := trues + 1; t <= trues+unknowns; t++ {
+		t := t
+		if truthOf(op(n, func(i int) bool { return i < t })) != res {
+			res = TruthUnknown
+			break
+		}
+	}
+	c.recordMatch(e, res == TruthTrue)
+	return res, false
+}
@@ -0,0 +1,86 @@
+package grules
+
+import (
+	"testing"
+)
+
+func TestEvaluateKleene(t *testing.T) {
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"age": float64(30),
+		},
+	}
+	adult := `{"comparator":"gte","path":"user.age","value":18}`
+	child := `{"comparator":"lt","path":"user.age","value":18}`
+	missing := `{"comparator":"eq","path":"user.country","value":"US"}`
+	cases := []struct {
+		doc      string
+		expected Truth
+	}{
+		{doc: `{"operator":"and","rules":[` + adult + `]}`, expected: TruthTrue},
+		{doc: `{"operator":"and","rules":[` + adult + `,` + missing + `]}`, expected: TruthUnknown},
+		{doc: `{"operator":"and","rules":[` + child + `,` + missing + `]}`, expected: TruthFalse},
+		{doc: `{"operator":"or","rules":[` + adult + `,` + missing + `]}`, expected: TruthTrue},
+		{doc: `{"operator":"or","rules":[` + child + `,` + missing + `]}`, expected: TruthUnknown},
+		{doc: `{"operator":"not","rules":[` + missing + `]}`, expected: TruthUnknown},
+		{doc: `{"operator":"not","rules":[` + child + `,` + missing + `]}`, expected: TruthTrue},
+		{doc: `{"operator":"xor","rules":[` + adult + `,` + adult + `,` + missing + `]}`, expected: TruthFalse},
+		{doc: `{"operator":"xor","rules":[` + adult + `,` + missing + `]}`, expected: TruthUnknown},
+		{doc: `{"operator":"none","rules":[` + adult + `,` + missing + `]}`, expected: TruthFalse},
+		{doc: `{"operator":"atleast","n":2,"rules":[` + adult + `,` + child + `,` + missing + `]}`, expected: TruthUnknown},
+		{doc: `{"operator":"atleast","n":1,"rules":[` + adult + `,` + missing + `]}`, expected: TruthTrue},
+		{doc: `{"operator":"exactly","n":1,"rules":[` + child + `,` + child + `,` + missing + `]}`, expected: TruthUnknown},
+		{doc: `{"operator":"and","rules":[{"comparator":"eq","path":"user.country","value":"US","on_missing":"true"}]}`, expected: TruthTrue},
+		{doc: `{"operator":"and","rules":[{"comparator":"eq","path":"user.country","value":"US","on_missing":"false"}]}`, expected: TruthFalse},
+		{doc: `{"operator":"and","rules":[` + adult + `,{"comparator":"eq","path":"user.country","value":"US","on_missing":"skip"}]}`, expected: TruthTrue},
+		{doc: `{"operator":"or","rules":[` + adult + `,{"comparator":"eq","path":"user.country","value":"US","on_missing":"error"}]}`, expected: TruthFalse},
+		{doc: `{"operator":"and","composites":[{"operator":"or","rules":[` + child + `,` + missing + `]},{"operator":"and","rules":[` + child + `]}]}`, expected: TruthFalse},
+		{doc: `{"operator":"and","composites":[{"operator":"or","rules":[` + child + `,` + missing + `]},{"operator":"and","rules":[` + adult + `]}]}`, expected: TruthUnknown},
+	}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[` + c.doc + `]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := e.EvaluateKleene(props)
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	e, err := NewJSONEngine([]byte(`{"composites":[
+		{"operator":"and","rules":[` + missing + `]},
+		{"operator":"and","rules":[` + child + `]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := e.EvaluateKleene(props); res != TruthFalse {
+		t.Fatalf("expected a false composite to make the engine false, got %v", res)
+	}
+
+	// Custom operators see skipped children like in Evaluate, and
+	// composites with an ID are profiled
+	all := func(n int, result func(i int) bool) bool {
+		for i := 0; i < n; i++ {
+			if !result(i) {
+				return false
+			}
+		}
+		return true
+	}
+	e, err = NewJSONEngine([]byte(`{"composites":[
+		{"id":"all","operator":"all","rules":[` + adult + `,{"comparator":"eq","path":"user.country","value":"US","on_missing":"skip"}]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProfile()
+	e = e.AddOperator("all", all).WithProfile(p)
+	if res, expected := e.EvaluateKleene(props), truthOf(e.Evaluate(props)); res != expected {
+		t.Fatalf("expected a skipped child of a custom operator to be %v like in Evaluate, got %v", expected, res)
+	}
+	if c := p.Report().Composites; len(c) != 1 || c[0].Evaluations != 2 || c[0].Matches != 0 {
+		t.Fatalf("expected both evaluations to be profiled, got %+v", c)
+	}
+}
@@ -0,0 +1,124 @@
+package grules
+
+import (
+	"strings"
+	"unicode"
+)
+
+// langSuffix is added to a text path to get the language of the text
+const langSuffix = ".lang"
+
+// languageScripts is a list of the scripts that are only used by one
+// of the supported languages, checked in order
+var languageScripts = []struct {
+	lang   string
+	tables []*unicode.RangeTable
+}{
+	{"ja", []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana}},
+	{"ko", []*unicode.RangeTable{unicode.Hangul}},
+	{"zh", []*unicode.RangeTable{unicode.Han}},
+	{"ru", []*unicode.RangeTable{unicode.Cyrillic}},
+	{"ar", []*unicode.RangeTable{unicode.Arabic}},
+	{"he", []*unicode.RangeTable{unicode.Hebrew}},
+	{"el", []*unicode.RangeTable{unicode.Greek}},
+	{"hi", []*unicode.RangeTable{unicode.Devanagari}},
+	{"th", []*unicode.RangeTable{unicode.Thai}},
+}
+
+// languageWords is a map of the languages written in the Latin script
+// to their most common words, which are counted to tell them apart
+var languageWords = map[string]map[string]bool{
+	"en": wordSet("the and of to is in that it was for you with on are this be have not"),
+	"es": wordSet("el la de que y en los las del se por un una con no es para al lo"),
+	"fr": wordSet("le la les de des et est un une du que qui dans pour pas sur au ce"),
+	"de": wordSet("der die das und ist nicht ein eine zu den mit von sich auf ich für dem"),
+	"it": wordSet("il la di che è e un una per non del della sono con gli le da"),
+	"pt": wordSet("o a os as de que e do da em um uma para com não é se no"),
+	"nl": wordSet("de het een en van is dat niet ik op te zijn voor met die er"),
+}
+
+// wordSet will create a set of the space separated words in s
+func wordSet(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+// Language will return the ISO 639-1 code of the language the text is
+// written in, or an empty string if it can not tell. Languages with
+// their own script are found by the script, languages written in the
+// Latin script by counting their most common words, so short texts
+// may not be detected
+func Language(text string) string {
+	for _, s := range languageScripts {
+		for _, r := range text {
+			if unicode.In(r, s.tables...) {
+				return s.lang
+			}
+		}
+	}
+
+	counts := map[string]int{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, w := range words {
+		for lang, set := range languageWords {
+			if set[w] {
+				counts[lang]++
+			}
+		}
+	}
+
+	best, max, tied := "", 0, false
+	for lang, n := range counts {
+		switch {
+		case n > max:
+			best, max, tied = lang, n, false
+		case n == max:
+			tied = true
+		}
+	}
+	if tied {
+		return ""
+	}
+	return best
+}
+
+// AddLanguageDetection will make the language of the text at each of
+// the given paths available to rules at the path with ".lang" added,
+// so a rule on "comment.body.lang" is given the language of
+// "comment.body". The props are not changed
+func (e Engine) AddLanguageDetection(paths ...string) Engine {
+	langs := map[string]bool{}
+	for p := range e.languagePaths {
+		langs[p] = true
+	}
+	for _, p := range paths {
+		langs[p] = true
+	}
+	e.languagePaths = langs
+	return e
+}
+
+// language will return the detected language for path if it is the
+// language of a configured text path, ok is false if it is not
+func (e *Engine) language(props map[string]interface{}, path string) (interface{}, bool) {
+	if len(e.languagePaths) == 0 || !strings.HasSuffix(path, langSuffix) {
+		return nil, false
+	}
+	textPath := strings.TrimSuffix(path, langSuffix)
+	if !e.languagePaths[textPath] {
+		return nil, false
+	}
+	text, ok := e.pluck(props, textPath).(string)
+	if !ok {
+		return nil, false
+	}
+	if lang := Language(text); lang != "" {
+		return lang, true
+	}
+	return nil, true
+}
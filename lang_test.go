@@ -0,0 +1,77 @@
+package grules
+
+import (
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	cases := map[string]string{
+		"The cat is on the mat and it is happy":   "en",
+		"El perro de mi hermano es muy grande":    "es",
+		"Le chat est dans la maison pour la nuit": "fr",
+		"Der Hund ist nicht in dem Haus":          "de",
+		"Il gatto è sul tavolo della cucina":      "it",
+		"Het is niet een goed idee voor mij":      "nl",
+		"Привет, как дела?":                       "ru",
+		"こんにちは世界":                                 "ja",
+		"你好世界":                                    "zh",
+		"안녕하세요":                                   "ko",
+		"مرحبا بالعالم":                           "ar",
+		"":                                        "",
+		"12345":                                   "",
+		"Zyx qwv":                                 "",
+	}
+	for text, expected := range cases {
+		if res := Language(text); res != expected {
+			t.Fatalf("expected %q to be %q, got %q", text, expected, res)
+		}
+	}
+}
+
+func BenchmarkLanguage(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Language("The cat is on the mat and it is happy")
+	}
+}
+
+func TestAddLanguageDetection(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "eq", Path: "comment.body.lang", Value: "fr"},
+			},
+		},
+	}
+	props := map[string]interface{}{
+		"comment": map[string]interface{}{
+			"body": "Je ne sais pas ce que c'est",
+		},
+	}
+
+	if e.Evaluate(props) {
+		t.Fatal("expected the language to be missing before detection is added")
+	}
+
+	detecting := e.AddLanguageDetection("comment.body")
+	if !detecting.Evaluate(props) {
+		t.Fatal("expected the language to be detected")
+	}
+	if len(e.languagePaths) != 0 {
+		t.Fatal("expected the original engine to be unchanged")
+	}
+	if _, ok := props["comment"].(map[string]interface{})["body.lang"]; ok {
+		t.Fatal("expected the props to be unchanged")
+	}
+
+	props["comment"] = map[string]interface{}{"body": "Wer ist das?"}
+	if detecting.Evaluate(props) {
+		t.Fatal("expected a different language not to match")
+	}
+
+	props["comment"] = map[string]interface{}{"body": float64(1)}
+	if detecting.Evaluate(props) {
+		t.Fatal("expected a value that is not text not to match")
+	}
+}
@@ -0,0 +1,297 @@
+package grules
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Severity is how serious a lint finding is
+type Severity int
+
+const (
+	// SeverityOff disables a check
+	SeverityOff Severity = iota
+	// SeverityInfo is for findings that are only worth knowing about
+	SeverityInfo
+	// SeverityWarning is for findings that are probably mistakes
+	SeverityWarning
+	// SeverityError is for findings that are almost certainly mistakes
+	SeverityError
+)
+
+// String will return the name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityOff:
+		return "off"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+const (
+	// LintSingleChild finds and/or composites with a single child,
+	// which could be replaced by the child
+	LintSingleChild = "single-child"
+	// LintDuplicateRule finds rules that appear more than once in the
+	// same composite
+	LintDuplicateRule = "duplicate-rule"
+	// LintUnanchoredRegex finds regex rules whose pattern is not
+	// anchored with ^ and $, and so match anywhere in the value
+	LintUnanchoredRegex = "unanchored-regex"
+	// LintDuplicateOneOf finds oneof rules with duplicate values
+	LintDuplicateOneOf = "duplicate-oneof"
+	// LintNumericString finds ordering rules, like gt, comparing
+	// against a string that looks like a number, which never match a
+	// numeric property. Equality rules are left alone, since strings
+	// like zip codes and IDs often look like numbers
+	LintNumericString = "numeric-string"
+)
+
+// defaultSeverities is a map of every check to the severity it has
+// when it is not in the config
+var defaultSeverities = map[string]Severity{
+	LintSingleChild:     SeverityInfo,
+	LintDuplicateRule:   SeverityWarning,
+	LintUnanchoredRegex: SeverityWarning,
+	LintDuplicateOneOf:  SeverityWarning,
+	LintNumericString:   SeverityWarning,
+}
+
+// LintConfig is a map of checks to the severity of their findings.
+// Checks that are not in the config use their default severity, and
+// checks set to SeverityOff are not run
+type LintConfig map[string]Severity
+
+// Finding is a single problem found by Lint. Node is the location of
+// the offending node, in the same form as RuleError. Fixable is true
+// if ApplyFixes can fix the problem without changing the result of
+// the engine
+type Finding struct {
+	Check    string
+	Severity Severity
+	Node     string
+	Message  string
+	Fixable  bool
+}
+
+// String will return a human readable finding
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Node, f.Severity, f.Message, f.Check)
+}
+
+// Lint will check the engine for rules that are likely to be mistakes
+// and return what it finds
+func Lint(e Engine, config LintConfig) []Finding {
+	l := linter{config: config, findings: []Finding{}}
+	for i, c := range e.Composites {
+		l.composite(fmt.Sprintf("composites[%d]", i), c, "")
+	}
+	return l.findings
+}
+
+// linter holds the state of a single run of Lint
+type linter struct {
+	config   LintConfig
+	findings []Finding
+}
+
+// severity will return the configured severity of a check
+func (l *linter) severity(check string) Severity {
+	if s, ok := l.config[check]; ok {
+		return s
+	}
+	return defaultSeverities[check]
+}
+
+// report will add a finding, unless the check is turned off
+func (l *linter) report(check, node string, fixable bool, format string, args ...interface{}) {
+	s := l.severity(check)
+	if s == SeverityOff {
+		return
+	}
+	l.findings = append(l.findings, Finding{
+		Check:    check,
+		Severity: s,
+		Node:     node,
+		Message:  fmt.Sprintf(format, args...),
+		Fixable:  fixable,
+	})
+}
+
+// composite will check a composite and all of its children. parentOp
+// is the operator of the parent composite, or empty if the composite
+// belongs to the engine
+func (l *linter) composite(node string, c Composite, parentOp string) {
+	if isAndOr(c.Operator) && len(c.Rules)+len(c.Composites) == 1 {
+		// A single composite can always take the place of its parent,
+		// a single rule can only be moved into an and/or parent. Either
+		// way the parent is dropped, so it must not have anything of
+		// its own
+		fixable := c.bare() && (len(c.Composites) == 1 || isAndOr(parentOp))
+		l.report(LintSingleChild, node, fixable, "%s composite has a single child", c.Operator)
+	}
+
+	for i, r := range c.Rules {
+		rnode := fmt.Sprintf("%s.rules[%d]", node, i)
+		for j := 0; j < i; j++ {
+			if reflect.DeepEqual(r, c.Rules[j]) {
+				l.report(LintDuplicateRule, rnode, isAndOr(c.Operator) && idempotent(c.Aggregate), "rule is a duplicate of %s.rules[%d]", node, j)
+				break
+			}
+		}
+		l.rule(rnode, r)
+	}
+
+	for i, cc := range c.Composites {
+		l.composite(fmt.Sprintf("%s.composites[%d]", node, i), cc, c.Operator)
+	}
+}
+
+// rule will check a single rule
+func (l *linter) rule(node string, r Rule) {
+	switch r.Comparator {
+	case "regex", "nregex":
+		if p, ok := r.Value.(string); ok && (!strings.HasPrefix(p, "^") || !strings.HasSuffix(p, "$")) {
+			l.report(LintUnanchoredRegex, node, false, "pattern %q is not anchored with ^ and $", p)
+		}
+	case "oneof":
+		if values, ok := r.Value.([]interface{}); ok {
+			for i := range values {
+				if indexOf(values[:i], values[i]) >= 0 {
+					l.report(LintDuplicateOneOf, node, true, "value %v is listed more than once", values[i])
+					break
+				}
+			}
+		}
+	case "lt", "lte", "gt", "gte":
+		if s, ok := r.Value.(string); ok {
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				l.report(LintNumericString, node, false, "value %q is a string, it will never match a numeric property", s)
+			}
+		}
+	}
+}
+
+// indexOf will return the index of the first element of values equal
+// to v, or -1 if there is none
+func indexOf(values []interface{}, v interface{}) int {
+	for i, elem := range values {
+		if reflect.DeepEqual(elem, v) {
+			return i
+		}
+	}
+	return -1
+}
+
+// bare will return true if the composite has nothing but its operator
+// and children, so it can be replaced by a child
+func (c Composite) bare() bool {
+	return c.ID == "" && c.Aggregate == "" && len(c.Weights) == 0 && len(c.Webhooks) == 0
+}
+
+// idempotent will return true if the aggregate gives the same score
+// no matter how many times a child is repeated, which is true for min
+// and max but not for product or weighted
+func idempotent(aggregate string) bool {
+	return aggregate == "" || aggregate == AggregateMin || aggregate == AggregateMax
+}
+
+// isAndOr will return true if the operator is and or or, which give
+// the same result no matter how many times a child is repeated or
+// what order the children are in
+func isAndOr(op string) bool {
+	return op == OperatorAnd || op == OperatorOr
+}
+
+// ApplyFixes will return a copy of the engine with the fixable
+// findings fixed: single child composites without an ID, webhooks, an
+// aggregate or weights are flattened into their parent, duplicate
+// rules are removed along with their weights, unless their composite's
+// aggregate counts them, and duplicate oneof values are removed. Findings must come from running Lint on the same
+// engine
+func ApplyFixes(e Engine, findings []Finding) Engine {
+	fixes := map[string]bool{}
+	for _, f := range findings {
+		if f.Fixable {
+			fixes[f.Check+" "+f.Node] = true
+		}
+	}
+
+	composites := make([]Composite, 0, len(e.Composites))
+	for i, c := range e.Composites {
+		node := fmt.Sprintf("composites[%d]", i)
+		c = fixComposite(node, c, fixes)
+		if fixes[LintSingleChild+" "+node] && len(c.Composites) == 1 && len(c.Rules) == 0 && c.bare() {
+			c = c.Composites[0]
+		}
+		composites = append(composites, c)
+	}
+	e.Composites = composites
+	return e
+}
+
+// fixComposite will return a copy of the composite with the fixes for
+// it and its children applied. Weights follow the children they belong
+// to
+func fixComposite(node string, c Composite, fixes map[string]bool) Composite {
+	fixed := c
+	fixed.Rules = nil
+	fixed.Composites = nil
+
+	var ruleWeights, compositeWeights []float64
+	weight := func(i int) float64 {
+		if i < len(c.Weights) {
+			return c.Weights[i]
+		}
+		return 1
+	}
+
+	for i, r := range c.Rules {
+		rnode := fmt.Sprintf("%s.rules[%d]", node, i)
+		if fixes[LintDuplicateRule+" "+rnode] {
+			continue
+		}
+		if values, ok := r.Value.([]interface{}); ok && fixes[LintDuplicateOneOf+" "+rnode] {
+			deduped := []interface{}{}
+			for _, v := range values {
+				if indexOf(deduped, v) < 0 {
+					deduped = append(deduped, v)
+				}
+			}
+			r.Value = deduped
+		}
+		fixed.Rules = append(fixed.Rules, r)
+		ruleWeights = append(ruleWeights, weight(i))
+	}
+
+	for i, cc := range c.Composites {
+		cnode := fmt.Sprintf("%s.composites[%d]", node, i)
+		cc = fixComposite(cnode, cc, fixes)
+		w := weight(len(c.Rules) + i)
+		if fixes[LintSingleChild+" "+cnode] && cc.bare() {
+			switch {
+			case len(cc.Composites) == 1 && len(cc.Rules) == 0:
+				cc = cc.Composites[0]
+			case len(cc.Rules) == 1 && len(cc.Composites) == 0 && isAndOr(c.Operator):
+				fixed.Rules = append(fixed.Rules, cc.Rules[0])
+				ruleWeights = append(ruleWeights, w)
+				continue
+			}
+		}
+		fixed.Composites = append(fixed.Composites, cc)
+		compositeWeights = append(compositeWeights, w)
+	}
+
+	if len(c.Weights) > 0 {
+		fixed.Weights = append(ruleWeights, compositeWeights...)
+	}
+	return fixed
+}
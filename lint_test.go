@@ -0,0 +1,169 @@
+package grules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	j := []byte(`{"composites":[
+		{"operator":"and","rules":[
+			{"comparator":"eq","path":"user.name","value":"Trevor"},
+			{"comparator":"gt","path":"user.age","value":"18"},
+			{"comparator":"eq","path":"user.name","value":"Trevor"},
+			{"comparator":"oneof","path":"user.role","value":["admin","owner","admin"]},
+			{"comparator":"regex","path":"user.email","value":"@example\\.com$"},
+			{"comparator":"eq","path":"user.zip","value":"02134"}
+		],"composites":[
+			{"operator":"or","rules":[
+				{"comparator":"regex","path":"user.email","value":"^.+@example\\.com$"}
+			]}
+		]}
+	]}`)
+	e, err := NewJSONEngine(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("default severities", func(t *testing.T) {
+		findings := Lint(e, nil)
+		expected := []Finding{
+			Finding{Check: LintNumericString, Severity: SeverityWarning, Node: "composites[0].rules[1]", Message: `value "18" is a string, it will never match a numeric property`, Fixable: false},
+			Finding{Check: LintDuplicateRule, Severity: SeverityWarning, Node: "composites[0].rules[2]", Message: "rule is a duplicate of composites[0].rules[0]", Fixable: true},
+			Finding{Check: LintDuplicateOneOf, Severity: SeverityWarning, Node: "composites[0].rules[3]", Message: "value admin is listed more than once", Fixable: true},
+			Finding{Check: LintUnanchoredRegex, Severity: SeverityWarning, Node: "composites[0].rules[4]", Message: `pattern "@example\\.com$" is not anchored with ^ and $`},
+			Finding{Check: LintSingleChild, Severity: SeverityInfo, Node: "composites[0].composites[0]", Message: "or composite has a single child", Fixable: true},
+		}
+		if !reflect.DeepEqual(findings, expected) {
+			t.Fatalf("expected %v but got %v", expected, findings)
+		}
+	})
+
+	t.Run("configured severities", func(t *testing.T) {
+		findings := Lint(e, LintConfig{
+			LintSingleChild:     SeverityError,
+			LintDuplicateRule:   SeverityOff,
+			LintNumericString:   SeverityOff,
+			LintDuplicateOneOf:  SeverityOff,
+			LintUnanchoredRegex: SeverityInfo,
+		})
+		if len(findings) != 2 {
+			t.Fatalf("expected 2 findings, got %v", findings)
+		}
+		if findings[0].Severity != SeverityInfo || findings[1].Severity != SeverityError {
+			t.Fatalf("expected configured severities, got %v", findings)
+		}
+	})
+
+	t.Run("clean", func(t *testing.T) {
+		findings := Lint(NewEngine(), nil)
+		if len(findings) != 0 {
+			t.Fatalf("expected no findings, got %v", findings)
+		}
+	})
+}
+
+func TestApplyFixes(t *testing.T) {
+	j := []byte(`{"composites":[
+		{"operator":"and","composites":[
+			{"operator":"or","rules":[
+				{"comparator":"oneof","path":"user.role","value":["admin","owner","admin"]},
+				{"comparator":"eq","path":"user.name","value":"Trevor"},
+				{"comparator":"eq","path":"user.name","value":"Trevor"}
+			]}
+		]},
+		{"operator":"and","rules":[
+			{"comparator":"gt","path":"user.age","value":20}
+		],"composites":[
+			{"operator":"or","rules":[
+				{"comparator":"lt","path":"user.age","value":30}
+			]},
+			{"operator":"majority","rules":[
+				{"comparator":"eq","path":"user.name","value":"Trevor"},
+				{"comparator":"eq","path":"user.name","value":"Trevor"},
+				{"comparator":"eq","path":"user.role","value":"admin"}
+			]}
+		]},
+		{"operator":"and","rules":[
+			{"comparator":"eq","path":"user.active","value":true}
+		]}
+	]}`)
+	e, err := NewJSONEngine(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixed := ApplyFixes(e, Lint(e, nil))
+	expectedStr := "({user.role oneof [admin owner]} or {user.name eq Trevor}) && " +
+		"({user.age gt 20} and {user.age lt 30} and ({user.name eq Trevor} majority {user.name eq Trevor} majority {user.role eq admin})) && " +
+		"({user.active eq true})"
+	actualStr := fixed.Stringify()
+	if expectedStr != actualStr {
+		t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+	}
+
+	// The original engine should be untouched
+	if len(e.Composites[0].Composites) != 1 {
+		t.Fatal("expected original engine to be unchanged")
+	}
+
+	// Only the findings that can not be fixed should be left
+	for _, f := range Lint(fixed, nil) {
+		if f.Fixable {
+			t.Fatalf("expected no fixable findings, got %v", f)
+		}
+	}
+
+	// Composites with anything of their own are kept, and weights
+	// follow the children that are moved or removed
+	e, err = NewJSONEngine([]byte(`{"composites":[
+		{"id":"vip","operator":"and","composites":[
+			{"operator":"or","aggregate":"max","weights":[2,4,8],"rules":[
+				{"comparator":"eq","path":"user.name","value":"Trevor"},
+				{"comparator":"eq","path":"user.name","value":"Trevor"},
+				{"comparator":"eq","path":"user.role","value":"admin"}
+			],"composites":[
+				{"operator":"and","rules":[{"comparator":"gt","path":"user.age","value":20}]}
+			]}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed = ApplyFixes(e, Lint(e, nil))
+	c := fixed.Composites[0]
+	if c.ID != "vip" || len(c.Composites) != 1 || c.Composites[0].Aggregate != AggregateMax {
+		t.Fatalf("expected the composites with an ID or weights to be kept, got %s", fixed.Stringify())
+	}
+	if weights := c.Composites[0].Weights; !reflect.DeepEqual(weights, []float64{2, 8, 1}) || len(c.Composites[0].Rules) != 3 {
+		t.Fatalf("expected the weights to follow the rules, got %v for %s", weights, fixed.Stringify())
+	}
+
+	// Duplicates are only removed where the aggregate ignores them, so
+	// the score does not change
+	props := map[string]interface{}{"user": map[string]interface{}{"name": "Trevor"}}
+	for i, aggregate := range []string{"", AggregateMin, AggregateMax, AggregateProduct, AggregateWeighted} {
+		e, err = NewJSONEngine([]byte(`{"composites":[{"operator":"and","aggregate":"` + aggregate + `","weights":[1,1,2],"rules":[
+			{"comparator":"similar","path":"user.name","value":"Trevor"},
+			{"comparator":"similar","path":"user.name","value":"Trevor"},
+			{"comparator":"eq","path":"user.name","value":"Trevor"}
+		]}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		e = e.AddScoreComparator("similar", func(a, b interface{}) float64 { return 0.6 })
+		before, _ := e.Score(props, 0.5)
+		after, _ := ApplyFixes(e, Lint(e, nil)).Score(props, 0.5)
+		if before != after {
+			t.Fatalf("expected case %d to be %v, got %v", i, before, after)
+		}
+	}
+}
+
+func TestFindingString(t *testing.T) {
+	f := Finding{Check: LintSingleChild, Severity: SeverityInfo, Node: "composites[0]", Message: "and composite has a single child"}
+	expected := "composites[0]: info: and composite has a single child (single-child)"
+	if f.String() != expected {
+		t.Fatalf("expected %s but got %s", expected, f.String())
+	}
+}
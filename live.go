@@ -0,0 +1,118 @@
+package grules
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// LiveEngine holds an engine that can be changed one composite at a
+// time while it is being evaluated. Every change is made to a copy of
+// the engine, which is then published atomically, so evaluations never
+// see a half made change. Composites are found by their ID
+type LiveEngine struct {
+	mu      sync.Mutex
+	current atomic.Value
+}
+
+// NewLiveEngine will create a new live engine starting with e
+func NewLiveEngine(e Engine) *LiveEngine {
+	l := &LiveEngine{}
+	l.current.Store(e)
+	return l
+}
+
+// Engine will return the engine as it is right now
+func (l *LiveEngine) Engine() Engine {
+	return l.current.Load().(Engine)
+}
+
+// Evaluate will evaluate the props against the engine as it is right
+// now
+func (l *LiveEngine) Evaluate(props map[string]interface{}) bool {
+	return l.Engine().Evaluate(props)
+}
+
+// AddComposite will add a composite to the end of the engine. The
+// composite's ID must not already be used
+func (l *LiveEngine) AddComposite(c Composite) error {
+	return l.update(func(composites []Composite) ([]Composite, error) {
+		if c.ID != "" && findComposite(composites, c.ID) {
+			return nil, ErrDuplicateComposite
+		}
+		return append(composites[:len(composites):len(composites)], c), nil
+	})
+}
+
+// ReplaceComposite will replace the composite with the same ID as c,
+// wherever it is nested
+func (l *LiveEngine) ReplaceComposite(c Composite) error {
+	return l.update(func(composites []Composite) ([]Composite, error) {
+		return replaceComposite(composites, c.ID, &c)
+	})
+}
+
+// RemoveComposite will remove the composite with the given ID,
+// wherever it is nested
+func (l *LiveEngine) RemoveComposite(id string) error {
+	return l.update(func(composites []Composite) ([]Composite, error) {
+		return replaceComposite(composites, id, nil)
+	})
+}
+
+// update will publish a copy of the engine with the composites changed
+// by fn
+func (l *LiveEngine) update(fn func([]Composite) ([]Composite, error)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := l.Engine()
+	composites, err := fn(e.Composites)
+	if err != nil {
+		return err
+	}
+	e.Composites = composites
+	if e.plan != nil {
+		e.plan = newPlan(composites)
+	}
+	l.current.Store(e)
+	return nil
+}
+
+// findComposite will return true if a composite with the ID is in
+// composites, or nested in one of them
+func findComposite(composites []Composite, id string) bool {
+	for _, c := range composites {
+		if c.ID == id || findComposite(c.Composites, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceComposite will return a copy of composites with the composite
+// with the ID replaced by with, or removed if with is nil. Only the
+// composites on the way to the match are copied, the rest are shared
+func replaceComposite(composites []Composite, id string, with *Composite) ([]Composite, error) {
+	if id == "" {
+		return nil, ErrCompositeNotFound
+	}
+	for i, c := range composites {
+		if c.ID == id {
+			replaced := make([]Composite, 0, len(composites))
+			replaced = append(replaced, composites[:i]...)
+			if with != nil {
+				replaced = append(replaced, *with)
+			}
+			return append(replaced, composites[i+1:]...), nil
+		}
+
+		children, err := replaceComposite(c.Composites, id, with)
+		if err == nil {
+			replaced := make([]Composite, len(composites))
+			copy(replaced, composites)
+			replaced[i].Composites = children
+			return replaced, nil
+		}
+	}
+	return nil, ErrCompositeNotFound
+}
@@ -0,0 +1,128 @@
+package grules
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestLiveEngine(t *testing.T) {
+	j := []byte(`{"composites":[
+		{"id":"age","operator":"and","rules":[{"comparator":"gte","path":"user.age","value":18}]},
+		{"id":"region","operator":"or","composites":[
+			{"id":"us","operator":"and","rules":[{"comparator":"eq","path":"user.country","value":"US"}]}
+		]}
+	]}`)
+	e, err := NewJSONEngine(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLiveEngine(e)
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"age":     float64(16),
+			"country": "CA",
+		},
+	}
+
+	t.Run("replace nested", func(t *testing.T) {
+		err := l.ReplaceComposite(Composite{
+			ID:       "us",
+			Operator: OperatorOr,
+			Rules: []Rule{
+				Rule{Comparator: "eq", Path: "user.country", Value: "US"},
+				Rule{Comparator: "eq", Path: "user.country", Value: "CA"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedStr := "({user.age gte 18}) && (({user.country eq US} or {user.country eq CA}))"
+		if l.Engine().Stringify() != expectedStr {
+			t.Fatalf("expected %s but got %s", expectedStr, l.Engine().Stringify())
+		}
+
+		// The engine the live engine started with should be untouched
+		if e.Composites[1].Composites[0].Operator != OperatorAnd {
+			t.Fatal("expected original engine to be unchanged")
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		if l.Evaluate(props) != false {
+			t.Fatal("expected engine to fail")
+		}
+		if err := l.RemoveComposite("age"); err != nil {
+			t.Fatal(err)
+		}
+		if l.Evaluate(props) != true {
+			t.Fatal("expected engine to pass")
+		}
+	})
+
+	t.Run("add", func(t *testing.T) {
+		err := l.AddComposite(Composite{
+			ID:       "name",
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "eq", Path: "user.name", Value: "Trevor"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Evaluate(props) != false {
+			t.Fatal("expected engine to fail")
+		}
+
+		err = l.AddComposite(Composite{ID: "us", Operator: OperatorAnd})
+		if !errors.Is(err, ErrDuplicateComposite) {
+			t.Fatalf("expected duplicate composite, got %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if err := l.RemoveComposite("missing"); !errors.Is(err, ErrCompositeNotFound) {
+			t.Fatalf("expected composite not found, got %v", err)
+		}
+		if err := l.ReplaceComposite(Composite{Operator: OperatorAnd}); !errors.Is(err, ErrCompositeNotFound) {
+			t.Fatalf("expected composite not found, got %v", err)
+		}
+	})
+
+	t.Run("compiled", func(t *testing.T) {
+		l := NewLiveEngine(e.Compile())
+		err := l.AddComposite(Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "eq", Path: "user.name", Value: "Trevor"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := l.Engine().plan.index["user.name"]; !ok {
+			t.Fatal("expected plan to include the new path")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		l := NewLiveEngine(NewEngine())
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				l.AddComposite(Composite{Operator: OperatorAnd})
+			}()
+			go func() {
+				defer wg.Done()
+				l.Evaluate(props)
+			}()
+		}
+		wg.Wait()
+		if len(l.Engine().Composites) != 20 {
+			t.Fatalf("expected 20 composites, got %d", len(l.Engine().Composites))
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package grules
+
+import (
+	"math"
+	"strings"
+)
+
+// ExchangeRate is a function that should return how much one unit of
+// the currency from is worth in the currency to, ok should be false if
+// the rate is not known
+type ExchangeRate func(from, to string) (rate float64, ok bool)
+
+// moneyTolerance is the difference below which two amounts of money
+// are equal, so rounding errors from converting between currencies do
+// not matter
+const moneyTolerance = 0.005
+
+// moneyTests is a map of the money comparators to the test each one
+// does on the result of compareMoney
+var moneyTests = map[string]func(c int) bool{
+	"money-eq":  moneyEqual,
+	"money-neq": moneyNotEqual,
+	"money-lt":  moneyLessThan,
+	"money-lte": moneyLessThanEqual,
+	"money-gt":  moneyGreaterThan,
+	"money-gte": moneyGreaterThanEqual,
+}
+
+func moneyEqual(c int) bool            { return c == 0 }
+func moneyNotEqual(c int) bool         { return c != 0 }
+func moneyLessThan(c int) bool         { return c < 0 }
+func moneyLessThanEqual(c int) bool    { return c <= 0 }
+func moneyGreaterThan(c int) bool      { return c > 0 }
+func moneyGreaterThanEqual(c int) bool { return c >= 0 }
+
+// moneyComparator will create a comparator for money values, which
+// are objects like {"amount": 10.5, "currency": "EUR"}. a is converted
+// to the currency of b with rate when they differ, and the comparator
+// is false if there is no rate
+func moneyComparator(rate ExchangeRate, test func(c int) bool) Comparator {
+	return func(a, b interface{}) bool {
+		c, ok := compareMoney(a, b, rate)
+		return ok && test(c)
+	}
+}
+
+// compareMoney will return -1, 0 or 1 if the money a is less than,
+// equal to or greater than the money b, ok is false if either is not
+// money or a can not be converted to the currency of b
+func compareMoney(a, b interface{}, rate ExchangeRate) (int, bool) {
+	x, xc, ok := toMoney(a)
+	if !ok {
+		return 0, false
+	}
+	y, yc, ok := toMoney(b)
+	if !ok {
+		return 0, false
+	}
+	if xc != yc {
+		if rate == nil {
+			return 0, false
+		}
+		r, ok := rate(xc, yc)
+		if !ok {
+			return 0, false
+		}
+		x *= r
+	}
+	switch d := x - y; {
+	case math.Abs(d) < moneyTolerance:
+		return 0, true
+	case d < 0:
+		return -1, true
+	}
+	return 1, true
+}
+
+// toMoney will return the amount and upper case currency of a money
+// value, ok is false if v is not an object with a numeric "amount"
+// and a string "currency"
+func toMoney(v interface{}) (float64, string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return 0, "", false
+	}
+	amount, ok := toFloat64(m["amount"])
+	if !ok {
+		return 0, "", false
+	}
+	currency, ok := m["currency"].(string)
+	if !ok || currency == "" {
+		return 0, "", false
+	}
+	return amount, strings.ToUpper(currency), true
+}
+
+// AddExchangeRates will let the money comparators compare amounts in
+// different currencies, using rate to convert the property's amount to
+// the currency of the rule's value. Without it, money in different
+// currencies never matches
+func (e Engine) AddExchangeRates(rate ExchangeRate) Engine {
+	comps := make(map[string]Comparator, len(e.comparators))
+	for n, c := range e.comparators {
+		comps[n] = c
+	}
+	for n, test := range moneyTests {
+		comps[n] = moneyComparator(rate, test)
+	}
+	e.comparators = comps
+	return e
+}
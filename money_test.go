@@ -0,0 +1,90 @@
+package grules
+
+import (
+	"testing"
+)
+
+func money(amount float64, currency string) map[string]interface{} {
+	return map[string]interface{}{"amount": amount, "currency": currency}
+}
+
+func testRate(from, to string) (float64, bool) {
+	rates := map[string]float64{
+		"EUR USD": 1.1,
+		"USD EUR": 1 / 1.1,
+		"GBP USD": 1.25,
+	}
+	r, ok := rates[from+" "+to]
+	return r, ok
+}
+
+func TestCompareMoney(t *testing.T) {
+	type moneyCase struct {
+		a, b     interface{}
+		expected int
+		ok       bool
+	}
+	cases := []moneyCase{
+		moneyCase{a: money(10, "EUR"), b: money(10, "EUR"), expected: 0, ok: true},
+		moneyCase{a: money(10, "eur"), b: money(10.001, "EUR"), expected: 0, ok: true},
+		moneyCase{a: money(9.99, "EUR"), b: money(10, "EUR"), expected: -1, ok: true},
+		moneyCase{a: money(10.01, "EUR"), b: money(10, "EUR"), expected: 1, ok: true},
+		moneyCase{a: money(10, "EUR"), b: money(11, "USD"), expected: 0, ok: true},
+		moneyCase{a: money(10, "EUR"), b: money(10, "USD"), expected: 1, ok: true},
+		moneyCase{a: money(11, "USD"), b: money(10, "EUR"), expected: 0, ok: true},
+		moneyCase{a: money(10, "USD"), b: money(10, "GBP"), ok: false},
+		moneyCase{a: map[string]interface{}{"amount": 10}, b: money(10, "EUR"), ok: false},
+		moneyCase{a: map[string]interface{}{"amount": "10", "currency": "EUR"}, b: money(10, "EUR"), ok: false},
+		moneyCase{a: float64(10), b: money(10, "EUR"), ok: false},
+		moneyCase{a: money(10, "EUR"), b: float64(10), ok: false},
+	}
+
+	for i, c := range cases {
+		res, ok := compareMoney(c.a, c.b, testRate)
+		if ok != c.ok || (ok && res != c.expected) {
+			t.Fatalf("expected case %d to be %d %v, got %d %v", i, c.expected, c.ok, res, ok)
+		}
+	}
+
+	if _, ok := compareMoney(money(10, "EUR"), money(11, "USD"), nil); ok {
+		t.Fatal("expected different currencies not to compare without rates")
+	}
+}
+
+func BenchmarkCompareMoney(b *testing.B) {
+	x, y := money(10, "EUR"), money(11, "USD")
+	for i := 0; i < b.N; i++ {
+		compareMoney(x, y, testRate)
+	}
+}
+
+func TestAddExchangeRates(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "money-gte", Path: "order.total", Value: money(100, "USD")},
+			},
+		},
+	}
+	props := map[string]interface{}{
+		"order": map[string]interface{}{"total": money(95, "EUR")},
+	}
+
+	if e.Evaluate(props) {
+		t.Fatal("expected different currencies not to match without rates")
+	}
+	converting := e.AddExchangeRates(testRate)
+	if !converting.Evaluate(props) {
+		t.Fatal("expected the total to be converted to USD")
+	}
+	if e.Evaluate(props) {
+		t.Fatal("expected the original engine to be unchanged")
+	}
+
+	props["order"] = map[string]interface{}{"total": money(99.99, "USD")}
+	if converting.Evaluate(props) {
+		t.Fatal("expected a lower total in the same currency not to match")
+	}
+}
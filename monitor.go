@@ -0,0 +1,136 @@
+package grules
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"sync"
+)
+
+// RateAlert is raised by a Monitor when the match rate of a composite
+// in the latest window moves outside the band around its baseline.
+// Rates are the fraction of evaluations that matched
+type RateAlert struct {
+	Instance string
+	ID       string
+	Rate     float64
+	Baseline float64
+}
+
+// Monitor watches the match rate of every composite with an ID and
+// raises an alert when it shifts, which catches broken upstream data
+// or rules that are accidentally too broad. It is a Collector, so a
+// profile can push to it on an interval with PushEvery, and each push
+// is one window. The baseline is the average rate of the windows
+// before it. It is safe to use from multiple goroutines
+type Monitor struct {
+	mu      sync.Mutex
+	windows int
+	band    float64
+	alert   func(RateAlert)
+	history map[string][]float64
+}
+
+// NewMonitor will create a new monitor that compares each window to
+// the average of the given number of windows before it, and calls
+// alert when the rates differ by more than band. No alerts are raised
+// until a composite has a full baseline
+func NewMonitor(windows int, band float64, alert func(RateAlert)) *Monitor {
+	if windows < 1 {
+		windows = 1
+	}
+	return &Monitor{
+		windows: windows,
+		band:    band,
+		alert:   alert,
+		history: map[string][]float64{},
+	}
+}
+
+// Collect will check the rates of a window against their baselines.
+// Composites that were not evaluated in the window are skipped
+func (m *Monitor) Collect(instance string, deltas []CompositeStats) error {
+	alerts := []RateAlert{}
+
+	m.mu.Lock()
+	for _, d := range deltas {
+		if d.Evaluations == 0 {
+			continue
+		}
+		key := instance + "\x00" + d.ID
+		rate := float64(d.Matches) / float64(d.Evaluations)
+		history := m.history[key]
+		if len(history) == m.windows {
+			baseline := 0.0
+			for _, r := range history {
+				baseline += r
+			}
+			baseline /= float64(len(history))
+			if math.Abs(rate-baseline) > m.band {
+				alerts = append(alerts, RateAlert{
+					Instance: instance,
+					ID:       d.ID,
+					Rate:     rate,
+					Baseline: baseline,
+				})
+			}
+			history = history[1:]
+		}
+		m.history[key] = append(history, rate)
+	}
+	m.mu.Unlock()
+
+	// Call alert without holding the lock, so it can take its time
+	for _, a := range alerts {
+		m.alert(a)
+	}
+	return nil
+}
+
+// monitorSnapshot is the saved state of a Monitor
+type monitorSnapshot struct {
+	Rates []monitorRates `json:"rates"`
+}
+
+// monitorRates is the rates of the latest windows of a composite
+type monitorRates struct {
+	Instance string    `json:"instance"`
+	ID       string    `json:"id"`
+	Rates    []float64 `json:"rates"`
+}
+
+// Snapshot will return the rates of the latest windows of every
+// composite as JSON, so a process can Restore its baselines after it
+// restarts rather than waiting for enough windows to build them again
+func (m *Monitor) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := monitorSnapshot{Rates: make([]monitorRates, 0, len(m.history))}
+	for key, rates := range m.history {
+		parts := strings.SplitN(key, "\x00", 2)
+		s.Rates = append(s.Rates, monitorRates{Instance: parts[0], ID: parts[1], Rates: rates})
+	}
+	return json.Marshal(s)
+}
+
+// Restore will replace the rates of the monitor with a snapshot. Only
+// the latest windows are kept if the snapshot has more than the
+// monitor compares against
+func (m *Monitor) Restore(data []byte) error {
+	var s monitorSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	history := make(map[string][]float64, len(s.Rates))
+	for _, r := range s.Rates {
+		rates := r.Rates
+		if len(rates) > m.windows {
+			rates = rates[len(rates)-m.windows:]
+		}
+		history[r.Instance+"\x00"+r.ID] = append([]float64(nil), rates...)
+	}
+	m.mu.Lock()
+	m.history = history
+	m.mu.Unlock()
+	return nil
+}
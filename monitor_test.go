@@ -0,0 +1,77 @@
+package grules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMonitor(t *testing.T) {
+	alerts := []RateAlert{}
+	m := NewMonitor(2, 0.2, func(a RateAlert) {
+		alerts = append(alerts, a)
+	})
+
+	windows := [][]CompositeStats{
+		[]CompositeStats{CompositeStats{ID: "adult", Evaluations: 4, Matches: 2}},
+		[]CompositeStats{CompositeStats{ID: "adult", Evaluations: 4, Matches: 3}},
+		[]CompositeStats{CompositeStats{ID: "adult", Evaluations: 4, Matches: 2}},
+		[]CompositeStats{CompositeStats{ID: "adult", Evaluations: 0, Matches: 0}},
+		[]CompositeStats{CompositeStats{ID: "adult", Evaluations: 4, Matches: 4}},
+		[]CompositeStats{CompositeStats{ID: "adult", Evaluations: 4, Matches: 3}},
+	}
+	for _, w := range windows {
+		if err := m.Collect("a", w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.Collect("b", []CompositeStats{CompositeStats{ID: "adult", Evaluations: 10, Matches: 0}})
+
+	expected := []RateAlert{
+		RateAlert{Instance: "a", ID: "adult", Rate: 1, Baseline: 0.625},
+	}
+	if !reflect.DeepEqual(alerts, expected) {
+		t.Fatalf("expected %v but got %v", expected, alerts)
+	}
+}
+
+func TestMonitorSnapshot(t *testing.T) {
+	alerts := []RateAlert{}
+	alert := func(a RateAlert) {
+		alerts = append(alerts, a)
+	}
+	m := NewMonitor(2, 0.2, alert)
+	m.Collect("a", []CompositeStats{CompositeStats{ID: "adult", Evaluations: 4, Matches: 2}})
+	m.Collect("a", []CompositeStats{CompositeStats{ID: "adult", Evaluations: 4, Matches: 3}})
+	data, err := m.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted monitor alerts on the next window with the restored
+	// baseline
+	restarted := NewMonitor(2, 0.2, alert)
+	if err := restarted.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	restarted.Collect("a", []CompositeStats{CompositeStats{ID: "adult", Evaluations: 4, Matches: 4}})
+	expected := []RateAlert{
+		RateAlert{Instance: "a", ID: "adult", Rate: 1, Baseline: 0.625},
+	}
+	if !reflect.DeepEqual(alerts, expected) {
+		t.Fatalf("expected %v but got %v", expected, alerts)
+	}
+
+	// Only the latest windows are kept by a monitor that compares
+	// against fewer
+	shorter := NewMonitor(1, 0.2, alert)
+	if err := shorter.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	if rates := shorter.history["a\x00adult"]; !reflect.DeepEqual(rates, []float64{0.75}) {
+		t.Fatalf("expected only the latest rate, got %v", rates)
+	}
+
+	if err := restarted.Restore([]byte("{")); err == nil {
+		t.Fatal("expected an invalid snapshot to be an error")
+	}
+}
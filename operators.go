@@ -0,0 +1,96 @@
+package grules
+
+// Operator is a function that should combine the results of a
+// composite's children into a single result. n is the number of
+// children, and result will evaluate the i'th child, rules first and
+// then composites. Children are only evaluated when asked for, so an
+// operator can stop as soon as it knows the answer
+type Operator func(n int, result func(i int) bool) bool
+
+// quantifiers is a map of the operators that depend on the composite's
+// N to a function creating the operator for a given N
+var quantifiers = map[string]func(want int) Operator{
+	OperatorAtLeast: atLeast,
+	OperatorExactly: exactly,
+}
+
+// and will return true if all of the children are true
+func and(n int, result func(i int) bool) bool {
+	for i := 0; i < n; i++ {
+		if result(i) == false {
+			return false
+		}
+	}
+	return true
+}
+
+// or will return true if one of the children is true
+func or(n int, result func(i int) bool) bool {
+	for i := 0; i < n; i++ {
+		if result(i) == true {
+			return true
+		}
+	}
+	return false
+}
+
+// not will return true unless all of the children are true, negating
+// the group as a whole
+func not(n int, result func(i int) bool) bool {
+	return !and(n, result)
+}
+
+// xor will return true if exactly one of the children is true
+func xor(n int, result func(i int) bool) bool {
+	passed := false
+	for i := 0; i < n; i++ {
+		if result(i) == true {
+			if passed {
+				return false
+			}
+			passed = true
+		}
+	}
+	return passed
+}
+
+// none will return true if all of the children are false
+func none(n int, result func(i int) bool) bool {
+	return !or(n, result)
+}
+
+// atLeast will create an operator that returns true if at least want
+// of the children are true
+func atLeast(want int) Operator {
+	return func(n int, result func(i int) bool) bool {
+		passed := 0
+		for i := 0; i < n && passed < want; i++ {
+			// Stop once there are not enough children left
+			if n-i < want-passed {
+				return false
+			}
+			if result(i) == true {
+				passed++
+			}
+		}
+		return passed >= want
+	}
+}
+
+// exactly will create an operator that returns true if exactly want
+// of the children are true
+func exactly(want int) Operator {
+	return func(n int, result func(i int) bool) bool {
+		passed := 0
+		for i := 0; i < n; i++ {
+			// Stop once there are too many, or not enough children left
+			if passed > want || n-i < want-passed {
+				return false
+			}
+			if result(i) == true {
+				passed++
+			}
+		}
+		return passed == want
+	}
+}
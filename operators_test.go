@@ -0,0 +1,217 @@
+package grules
+
+import (
+	"testing"
+)
+
+type operatorCase struct {
+	results  []bool
+	expected bool
+}
+
+// resultsOf will return a result function over the given results that
+// records how many children were evaluated
+func resultsOf(results []bool, evaluated *int) func(i int) bool {
+	return func(i int) bool {
+		*evaluated++
+		return results[i]
+	}
+}
+
+func TestAnd(t *testing.T) {
+	cases := []operatorCase{
+		operatorCase{results: []bool{}, expected: true},
+		operatorCase{results: []bool{true}, expected: true},
+		operatorCase{results: []bool{true, true}, expected: true},
+		operatorCase{results: []bool{true, false}, expected: false},
+		operatorCase{results: []bool{false, false}, expected: false},
+	}
+
+	for i, c := range cases {
+		var evaluated int
+		res := and(len(c.results), resultsOf(c.results, &evaluated))
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	t.Run("short circuit", func(t *testing.T) {
+		var evaluated int
+		and(3, resultsOf([]bool{false, true, true}, &evaluated))
+		if evaluated != 1 {
+			t.Fatalf("expected 1 child to be evaluated, got %d", evaluated)
+		}
+	})
+}
+
+func TestOr(t *testing.T) {
+	cases := []operatorCase{
+		operatorCase{results: []bool{}, expected: false},
+		operatorCase{results: []bool{true}, expected: true},
+		operatorCase{results: []bool{false, true}, expected: true},
+		operatorCase{results: []bool{false, false}, expected: false},
+	}
+
+	for i, c := range cases {
+		var evaluated int
+		res := or(len(c.results), resultsOf(c.results, &evaluated))
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	t.Run("short circuit", func(t *testing.T) {
+		var evaluated int
+		or(3, resultsOf([]bool{true, false, false}, &evaluated))
+		if evaluated != 1 {
+			t.Fatalf("expected 1 child to be evaluated, got %d", evaluated)
+		}
+	})
+}
+
+func TestNot(t *testing.T) {
+	cases := []operatorCase{
+		operatorCase{results: []bool{}, expected: false},
+		operatorCase{results: []bool{true}, expected: false},
+		operatorCase{results: []bool{false}, expected: true},
+		operatorCase{results: []bool{true, true}, expected: false},
+		operatorCase{results: []bool{true, false}, expected: true},
+	}
+
+	for i, c := range cases {
+		var evaluated int
+		res := not(len(c.results), resultsOf(c.results, &evaluated))
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func TestXor(t *testing.T) {
+	cases := []operatorCase{
+		operatorCase{results: []bool{}, expected: false},
+		operatorCase{results: []bool{true}, expected: true},
+		operatorCase{results: []bool{false}, expected: false},
+		operatorCase{results: []bool{false, true, false}, expected: true},
+		operatorCase{results: []bool{true, false, true}, expected: false},
+		operatorCase{results: []bool{true, true, true}, expected: false},
+	}
+
+	for i, c := range cases {
+		var evaluated int
+		res := xor(len(c.results), resultsOf(c.results, &evaluated))
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	t.Run("short circuit", func(t *testing.T) {
+		var evaluated int
+		xor(4, resultsOf([]bool{true, true, false, false}, &evaluated))
+		if evaluated != 2 {
+			t.Fatalf("expected 2 children to be evaluated, got %d", evaluated)
+		}
+	})
+}
+
+func TestNone(t *testing.T) {
+	cases := []operatorCase{
+		operatorCase{results: []bool{}, expected: true},
+		operatorCase{results: []bool{false}, expected: true},
+		operatorCase{results: []bool{false, false}, expected: true},
+		operatorCase{results: []bool{false, true}, expected: false},
+		operatorCase{results: []bool{true, true}, expected: false},
+	}
+
+	for i, c := range cases {
+		var evaluated int
+		res := none(len(c.results), resultsOf(c.results, &evaluated))
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	t.Run("short circuit", func(t *testing.T) {
+		var evaluated int
+		none(3, resultsOf([]bool{true, false, false}, &evaluated))
+		if evaluated != 1 {
+			t.Fatalf("expected 1 child to be evaluated, got %d", evaluated)
+		}
+	})
+}
+
+func TestAtLeast(t *testing.T) {
+	cases := []operatorCase{
+		operatorCase{results: []bool{}, expected: false},
+		operatorCase{results: []bool{true}, expected: false},
+		operatorCase{results: []bool{true, true}, expected: true},
+		operatorCase{results: []bool{true, false, true}, expected: true},
+		operatorCase{results: []bool{true, true, true}, expected: true},
+		operatorCase{results: []bool{false, false, true}, expected: false},
+	}
+
+	for i, c := range cases {
+		var evaluated int
+		res := atLeast(2)(len(c.results), resultsOf(c.results, &evaluated))
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	t.Run("zero", func(t *testing.T) {
+		var evaluated int
+		res := atLeast(0)(2, resultsOf([]bool{false, false}, &evaluated))
+		if res != true || evaluated != 0 {
+			t.Fatalf("expected true without evaluating, got %v after %d", res, evaluated)
+		}
+	})
+
+	t.Run("short circuit", func(t *testing.T) {
+		var evaluated int
+		atLeast(2)(5, resultsOf([]bool{true, true, false, false, false}, &evaluated))
+		if evaluated != 2 {
+			t.Fatalf("expected 2 children to be evaluated, got %d", evaluated)
+		}
+
+		evaluated = 0
+		atLeast(4)(5, resultsOf([]bool{false, false, true, true, true}, &evaluated))
+		if evaluated != 2 {
+			t.Fatalf("expected 2 children to be evaluated, got %d", evaluated)
+		}
+	})
+}
+
+func TestExactly(t *testing.T) {
+	cases := []operatorCase{
+		operatorCase{results: []bool{}, expected: false},
+		operatorCase{results: []bool{true}, expected: false},
+		operatorCase{results: []bool{true, true}, expected: true},
+		operatorCase{results: []bool{true, false, true}, expected: true},
+		operatorCase{results: []bool{true, true, true}, expected: false},
+		operatorCase{results: []bool{false, false, true}, expected: false},
+	}
+
+	for i, c := range cases {
+		var evaluated int
+		res := exactly(2)(len(c.results), resultsOf(c.results, &evaluated))
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	t.Run("zero", func(t *testing.T) {
+		var evaluated int
+		res := exactly(0)(2, resultsOf([]bool{false, false}, &evaluated))
+		if res != true {
+			t.Fatal("expected true when no children are true")
+		}
+	})
+
+	t.Run("short circuit", func(t *testing.T) {
+		var evaluated int
+		exactly(1)(5, resultsOf([]bool{true, true, false, false, false}, &evaluated))
+		if evaluated != 2 {
+			t.Fatalf("expected 2 children to be evaluated, got %d", evaluated)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package grules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// paramPattern matches a ${name} placeholder in a rule value, where
+// name is a path into the params
+var paramPattern = regexp.MustCompile(`\$\{([A-Za-z0-9_.]+)\}`)
+
+// WithParams will return a copy of the engine that replaces ${name}
+// placeholders in rule values with the value at name in params, so the
+// same rules can be shared by tenants that only differ by a few
+// values. A value that is only a placeholder is replaced by the param
+// as it is, placeholders inside a longer string are formatted into it.
+// Placeholders in arrays and objects are replaced too, and a rule
+// whose param is missing is treated as if its path was missing
+func (e Engine) WithParams(params map[string]interface{}) Engine {
+	e.params = params
+	return e
+}
+
+// EvaluateWithParams will evaluate the engine with the placeholders in
+// its rule values replaced by params, see WithParams
+func (e Engine) EvaluateWithParams(props, params map[string]interface{}) bool {
+	return e.WithParams(params).Evaluate(props)
+}
+
+// resolveParams will return v with its placeholders replaced, ok is
+// false if a placeholder's param does not exist
+func (e *Engine) resolveParams(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		if !strings.Contains(v, "${") {
+			return v, true
+		}
+		if m := paramPattern.FindStringSubmatch(v); m != nil && m[0] == v {
+			p := pluck(e.params, m[1])
+			return p, p != nil
+		}
+		ok := true
+		s := paramPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			p := pluck(e.params, placeholder[2:len(placeholder)-1])
+			if p == nil {
+				ok = false
+			}
+			return fmt.Sprint(p)
+		})
+		return s, ok
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, elem := range v {
+			r, ok := e.resolveParams(elem)
+			if !ok {
+				return nil, false
+			}
+			resolved[i] = r
+		}
+		return resolved, true
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			r, ok := e.resolveParams(elem)
+			if !ok {
+				return nil, false
+			}
+			resolved[k] = r
+		}
+		return resolved, true
+	}
+	return v, true
+}
@@ -0,0 +1,72 @@
+package grules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveParams(t *testing.T) {
+	e := NewEngine().WithParams(map[string]interface{}{
+		"tenant_id": "acme",
+		"limit":     float64(100),
+		"region": map[string]interface{}{
+			"code": "EU",
+		},
+	})
+	cases := []struct {
+		value    interface{}
+		expected interface{}
+		ok       bool
+	}{
+		{value: "${tenant_id}", expected: "acme", ok: true},
+		{value: "${limit}", expected: float64(100), ok: true},
+		{value: "${region.code}", expected: "EU", ok: true},
+		{value: "tenant-${tenant_id}-${limit}", expected: "tenant-acme-100", ok: true},
+		{value: []interface{}{"${tenant_id}", "other"}, expected: []interface{}{"acme", "other"}, ok: true},
+		{value: map[string]interface{}{"amount": "${limit}", "currency": "EUR"}, expected: map[string]interface{}{"amount": float64(100), "currency": "EUR"}, ok: true},
+		{value: "no placeholders", expected: "no placeholders", ok: true},
+		{value: "$tenant_id", expected: "$tenant_id", ok: true},
+		{value: float64(1), expected: float64(1), ok: true},
+		{value: "${missing}", ok: false},
+		{value: "tenant-${missing}", ok: false},
+		{value: []interface{}{"${missing}"}, ok: false},
+	}
+	for i, c := range cases {
+		res, ok := e.resolveParams(c.value)
+		if ok != c.ok || (ok && !reflect.DeepEqual(res, c.expected)) {
+			t.Fatalf("expected case %d to be %v %v, got %v %v", i, c.expected, c.ok, res, ok)
+		}
+	}
+}
+
+func TestEvaluateWithParams(t *testing.T) {
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[
+		{"comparator":"eq","path":"event.tenant","value":"${tenant_id}"},
+		{"comparator":"lte","path":"event.amount","value":"${limit}"}
+	]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	props := map[string]interface{}{
+		"event": map[string]interface{}{
+			"tenant": "acme",
+			"amount": float64(50),
+		},
+	}
+
+	if !e.EvaluateWithParams(props, map[string]interface{}{"tenant_id": "acme", "limit": float64(100)}) {
+		t.Fatal("expected acme to pass")
+	}
+	if e.EvaluateWithParams(props, map[string]interface{}{"tenant_id": "acme", "limit": float64(10)}) {
+		t.Fatal("expected a lower limit to fail")
+	}
+	if e.EvaluateWithParams(props, map[string]interface{}{"tenant_id": "globex", "limit": float64(100)}) {
+		t.Fatal("expected another tenant to fail")
+	}
+	if e.EvaluateWithParams(props, map[string]interface{}{"limit": float64(100)}) {
+		t.Fatal("expected a missing param to fail")
+	}
+	if e.Evaluate(props) {
+		t.Fatal("expected placeholders to be compared as they are without params")
+	}
+}
@@ -0,0 +1,270 @@
+package grules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+// token is the smallest unit of an expression, pos is the offset of
+// the token in the input
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// symbols is a list of all the symbols the lexer knows about, longest
+// first so that "<=" is not read as "<" followed by "="
+var symbols = []string{
+	"==", "!=", "<=", ">=", "&&", "||", "?.", "..",
+	"(", ")", "[", "]", "{", "}", ",", "<", ">", "!", "=", "-",
+	"+", "*", "/", "%", "$",
+}
+
+// lex will split an expression into tokens. Identifiers may contain
+// dots so that a path like user.name is a single token
+func lex(input string) ([]token, error) {
+	tokens := []token{}
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(input) && (isIdentStart(input[i]) || isDigit(input[i]) || (input[i] == '.' && i+1 < len(input) && input[i+1] != '.')) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: input[start:i], pos: start})
+		case isDigit(c) || (c == '-' && i+1 < len(input) && isDigit(input[i+1]) && !followsValue(tokens)):
+			start := i
+			i++
+			for i < len(input) && (isDigit(input[i]) || (input[i] == '.' && i+1 < len(input) && isDigit(input[i+1]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: input[start:i], pos: start})
+		case c == '"' || c == '\'':
+			s, n, err := lexString(input[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at %d: %v", ErrSyntax, i, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s, pos: i})
+			i += n
+		default:
+			matched := false
+			for _, sym := range symbols {
+				if strings.HasPrefix(input[i:], sym) {
+					tokens = append(tokens, token{kind: tokenSymbol, text: sym, pos: i})
+					i += len(sym)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("%w at %d: unexpected %q", ErrSyntax, i, c)
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(input)})
+	return tokens, nil
+}
+
+// lexString will read a quoted string from the start of input and
+// return it unquoted, along with the number of bytes read. Double
+// quoted strings use Go escapes, single quoted strings escape a quote
+// by doubling it
+func lexString(input string) (string, int, error) {
+	quote := input[0]
+	for i := 1; i < len(input); i++ {
+		switch {
+		case quote == '"' && input[i] == '\\':
+			i++
+		case input[i] == quote && quote == '\'' && i+1 < len(input) && input[i+1] == '\'':
+			i++
+		case input[i] == quote:
+			if quote == '\'' {
+				return strings.Replace(input[1:i], "''", "'", -1), i + 1, nil
+			}
+			s, err := strconv.Unquote(input[:i+1])
+			return s, i + 1, err
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// followsValue will return true if the last token ends a value, in
+// which case a '-' is a symbol rather than the sign of a number
+func followsValue(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokenIdent || last.kind == tokenNumber || last.kind == tokenString ||
+		last.text == ")" || last.text == "]"
+}
+
+// parser holds the state shared by the expression languages that can
+// be imported into an engine
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// newParser will create a parser over the tokens of the input
+func newParser(input string) (*parser, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+// peek will return the next token without consuming it
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next will consume and return the next token
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept will consume the next token if it is a symbol or keyword
+// matching text, keywords are matched without regard to case
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokenSymbol && t.text == text) || (t.kind == tokenIdent && strings.EqualFold(t.text, text)) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect will consume the next token, returning an error if it does
+// not match text
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+// done will return an error if there are tokens left over
+func (p *parser) done() error {
+	if p.peek().kind != tokenEOF {
+		return p.unexpected()
+	}
+	return nil
+}
+
+// unexpected will return a syntax error for the next token
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return fmt.Errorf("%w at %d: unexpected end of input", ErrSyntax, t.pos)
+	}
+	return fmt.Errorf("%w at %d: unexpected %q", ErrSyntax, t.pos, t.text)
+}
+
+// literal will parse a string, number, boolean or null. Numbers are
+// float64 to match encoding/json
+func (p *parser) literal() (interface{}, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenString:
+		p.next()
+		return t.text, nil
+	case t.kind == tokenNumber:
+		p.next()
+		return strconv.ParseFloat(t.text, 64)
+	case p.accept("true"):
+		return true, nil
+	case p.accept("false"):
+		return false, nil
+	case p.accept("null"):
+		return nil, nil
+	}
+	return nil, p.unexpected()
+}
+
+// list will parse literals separated by commas until the closing
+// symbol
+func (p *parser) list(close string) ([]interface{}, error) {
+	values := []interface{}{}
+	if p.accept(close) {
+		return values, nil
+	}
+	for {
+		v, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if p.accept(close) {
+			return values, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// node is either a single rule or a composite, built up while parsing
+type node struct {
+	rule      *Rule
+	composite Composite
+}
+
+// join will combine nodes with the given operator. Children that
+// already use the same operator are merged in, so "a and b and c"
+// becomes a single composite. NOT children are never merged, as
+// "not (not a)" is not the same as "not a"
+func join(op string, nodes ...node) node {
+	c := Composite{Operator: op}
+	for _, n := range nodes {
+		switch {
+		case n.rule != nil:
+			c.Rules = append(c.Rules, *n.rule)
+		case n.composite.Operator == op && op != OperatorNot:
+			c.Rules = append(c.Rules, n.composite.Rules...)
+			c.Composites = append(c.Composites, n.composite.Composites...)
+		default:
+			c.Composites = append(c.Composites, n.composite)
+		}
+	}
+	return node{composite: c}
+}
+
+// engine will create a new engine with the node as its only composite
+func (n node) engine() Engine {
+	e := NewEngine()
+	if n.rule != nil {
+		n = join(OperatorAnd, n)
+	}
+	e.Composites = []Composite{n.composite}
+	return e
+}
@@ -0,0 +1,73 @@
+package grules
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	t.Run("tokens", func(t *testing.T) {
+		tokens, err := lex(`user.name == "Tre\"vor" && age >= -2.5 || x?.y != 'it''s' [1..10]`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []token{
+			token{kind: tokenIdent, text: "user.name", pos: 0},
+			token{kind: tokenSymbol, text: "==", pos: 10},
+			token{kind: tokenString, text: `Tre"vor`, pos: 13},
+			token{kind: tokenSymbol, text: "&&", pos: 24},
+			token{kind: tokenIdent, text: "age", pos: 27},
+			token{kind: tokenSymbol, text: ">=", pos: 31},
+			token{kind: tokenNumber, text: "-2.5", pos: 34},
+			token{kind: tokenSymbol, text: "||", pos: 39},
+			token{kind: tokenIdent, text: "x", pos: 42},
+			token{kind: tokenSymbol, text: "?.", pos: 43},
+			token{kind: tokenIdent, text: "y", pos: 45},
+			token{kind: tokenSymbol, text: "!=", pos: 47},
+			token{kind: tokenString, text: "it's", pos: 50},
+			token{kind: tokenSymbol, text: "[", pos: 58},
+			token{kind: tokenNumber, text: "1", pos: 59},
+			token{kind: tokenSymbol, text: "..", pos: 60},
+			token{kind: tokenNumber, text: "10", pos: 62},
+			token{kind: tokenSymbol, text: "]", pos: 64},
+			token{kind: tokenEOF, pos: 65},
+		}
+		if !reflect.DeepEqual(tokens, expected) {
+			t.Fatalf("expected %v but got %v", expected, tokens)
+		}
+	})
+
+	t.Run("unterminated string", func(t *testing.T) {
+		_, err := lex(`name == "Trevor`)
+		if !errors.Is(err, ErrSyntax) {
+			t.Fatalf("expected syntax error, got %v", err)
+		}
+	})
+
+	t.Run("unknown character", func(t *testing.T) {
+		_, err := lex(`name @ "Trevor"`)
+		if !errors.Is(err, ErrSyntax) {
+			t.Fatalf("expected syntax error, got %v", err)
+		}
+	})
+}
+
+func TestJoin(t *testing.T) {
+	a := node{rule: &Rule{Comparator: "eq", Path: "a", Value: "a"}}
+	b := node{rule: &Rule{Comparator: "eq", Path: "b", Value: "b"}}
+	c := node{rule: &Rule{Comparator: "eq", Path: "c", Value: "c"}}
+
+	n := join(OperatorAnd, join(OperatorAnd, a, b), join(OperatorOr, b, c), c)
+	expectedStr := "({a eq a} and {b eq b} and {c eq c} and ({b eq b} or {c eq c}))"
+	actualStr := n.engine().Stringify()
+	if expectedStr != actualStr {
+		t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+	}
+
+	expectedStr = "({a eq a})"
+	actualStr = a.engine().Stringify()
+	if expectedStr != actualStr {
+		t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+	}
+}
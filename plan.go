@@ -0,0 +1,102 @@
+package grules
+
+import (
+	"strings"
+)
+
+// plan resolves every path referenced by an engine in a single
+// traversal of the props, instead of one traversal per rule. It is
+// built by Compile and Optimize
+type plan struct {
+	root  planNode
+	index map[string]int
+}
+
+// planNode is a single key of a path. index is the position of the
+// path ending at this node in the resolved values, or -1 if no rule
+// references it
+type planNode struct {
+	key      string
+	index    int
+	children []*planNode
+}
+
+// newPlan will create a plan for all of the paths referenced by the
+// composites
+func newPlan(composites []Composite) *plan {
+	p := &plan{
+		root:  planNode{index: -1},
+		index: map[string]int{},
+	}
+	for _, c := range composites {
+		p.addComposite(c)
+	}
+	return p
+}
+
+// addComposite will add the paths of all of the composite's rules
+func (p *plan) addComposite(c Composite) {
+	for _, r := range c.Rules {
+		p.add(r.Path)
+		if ref, ok := pathRef(r.Value); ok {
+			p.add(ref)
+		}
+		if a, ok := valueArithmetic(r.Value); ok {
+			a.paths(p.add)
+		}
+	}
+	for _, cc := range c.Composites {
+		p.addComposite(cc)
+	}
+}
+
+// add will add a single path to the plan. Wildcard paths are left
+// out, they are resolved when their rule is evaluated
+func (p *plan) add(path string) {
+	if _, ok := p.index[path]; ok || isWildcard(path) {
+		return
+	}
+
+	n := &p.root
+	for _, key := range strings.Split(path, ".") {
+		var next *planNode
+		for _, child := range n.children {
+			if child.key == key {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			next = &planNode{key: key, index: -1}
+			n.children = append(n.children, next)
+		}
+		n = next
+	}
+	n.index = len(p.index)
+	p.index[path] = n.index
+}
+
+// resolve will return the value of every path in the plan, nil if the
+// path does not exist in the props
+func (p *plan) resolve(props map[string]interface{}) []interface{} {
+	values := make([]interface{}, len(p.index))
+	p.root.resolve(props, values)
+	return values
+}
+
+// resolve will store the values of the node's children, and their
+// children, from the given map
+func (n *planNode) resolve(props map[string]interface{}, values []interface{}) {
+	for _, child := range n.children {
+		val, ok := props[child.key]
+		if !ok {
+			continue
+		}
+		if child.index >= 0 {
+			values[child.index] = val
+		}
+		if m, ok := val.(map[string]interface{}); ok {
+			child.resolve(m, values)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package grules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanResolve(t *testing.T) {
+	p := newPlan([]Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "eq", Path: "user.name", Value: "Trevor"},
+				Rule{Comparator: "eq", Path: "user.address.city", Value: "Atlanta"},
+			},
+			Composites: []Composite{
+				Composite{
+					Operator: OperatorOr,
+					Rules: []Rule{
+						Rule{Comparator: "eq", Path: "user", Value: "Trevor"},
+						Rule{Comparator: "eq", Path: "user.name", Value: "John"},
+						Rule{Comparator: "eq", Path: "user.email", Value: "test@test.com"},
+						Rule{Comparator: "eq", Path: "user.name.first", Value: "Trevor"},
+					},
+				},
+			},
+		},
+	})
+
+	user := map[string]interface{}{
+		"name": "Trevor",
+		"address": map[string]interface{}{
+			"city": "Atlanta",
+		},
+	}
+	props := map[string]interface{}{
+		"user": user,
+	}
+
+	values := p.resolve(props)
+	expected := []interface{}{"Trevor", "Atlanta", user, nil, nil}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("expected %v but got %v", expected, values)
+	}
+	for path, i := range p.index {
+		if !reflect.DeepEqual(values[i], pluck(props, path)) {
+			t.Fatalf("expected %s to resolve to %v, got %v", path, pluck(props, path), values[i])
+		}
+	}
+}
+
+func TestCompiledEvaluate(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "eq", Path: "user.name", Value: "Trevor"},
+				Rule{Comparator: "gt", Path: "user.age", Value: float64(20)},
+			},
+		},
+	}
+	compiled := e.Compile()
+
+	cases := []map[string]interface{}{
+		map[string]interface{}{"user": map[string]interface{}{"name": "Trevor", "age": float64(25)}},
+		map[string]interface{}{"user": map[string]interface{}{"name": "Trevor", "age": float64(18)}},
+		map[string]interface{}{"user": map[string]interface{}{"name": "Trevor"}},
+		map[string]interface{}{"user": "Trevor"},
+		map[string]interface{}{},
+	}
+	for i, props := range cases {
+		if compiled.Evaluate(props) != e.Evaluate(props) {
+			t.Fatalf("expected case %d to be %v", i, e.Evaluate(props))
+		}
+	}
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	e := benchmarkEngine()
+	props := benchmarkProps()
+	for i := 0; i < b.N; i++ {
+		e.Evaluate(props)
+	}
+}
+
+func BenchmarkEvaluateCompiled(b *testing.B) {
+	e := benchmarkEngine().Compile()
+	props := benchmarkProps()
+	for i := 0; i < b.N; i++ {
+		e.Evaluate(props)
+	}
+}
+
+func benchmarkEngine() Engine {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "eq", Path: "user.profile.name", Value: "Trevor"},
+				Rule{Comparator: "gt", Path: "user.profile.age", Value: float64(20)},
+				Rule{Comparator: "lt", Path: "user.profile.age", Value: float64(30)},
+				Rule{Comparator: "eq", Path: "user.address.city", Value: "Atlanta"},
+				Rule{Comparator: "eq", Path: "user.address.country", Value: "US"},
+			},
+		},
+	}
+	return e
+}
+
+func benchmarkProps() map[string]interface{} {
+	return map[string]interface{}{
+		"user": map[string]interface{}{
+			"profile": map[string]interface{}{
+				"name": "Trevor",
+				"age":  float64(25),
+			},
+			"address": map[string]interface{}{
+				"city":    "Atlanta",
+				"country": "US",
+			},
+		},
+	}
+}
@@ -1,9 +1,92 @@
 package grules
 
 import (
+	"fmt"
 	"strings"
 )
 
+// PathLanguage is the syntax of the paths of rules
+type PathLanguage int
+
+const (
+	// PathDotted paths are keys separated by dots, such as "user.name"
+	PathDotted PathLanguage = iota
+	// PathJSONPath paths are JSONPath expressions, see compileJSONPath
+	PathJSONPath
+	// PathJMESPath paths are JMESPath expressions, see compileJMESPath
+	PathJMESPath
+)
+
+// query is a compiled path in a query language
+type query interface {
+	selectValues(root interface{}) (values []interface{}, definite bool)
+}
+
+// compilePath will compile a path in the query language
+func compilePath(lang PathLanguage, path string) (query, error) {
+	switch lang {
+	case PathJSONPath:
+		return compileJSONPath(path)
+	case PathJMESPath:
+		return compileJMESPath(path)
+	}
+	return nil, fmt.Errorf("grules: unknown path language %d", lang)
+}
+
+// WithPathLanguage will return a copy of the engine that reads every
+// rule path in the language. A path that selects more than one value,
+// such as a JSONPath wildcard or a JMESPath projection, is true if the
+// comparator is true for any of them, and aggregate comparators are
+// given all of them. Every path is compiled up front and kept with the
+// engine, and the first one that is invalid is returned as a
+// *RuleError. Paths of rules added to the engine later are compiled
+// every time they are evaluated, so the language should be set again
+// after the composites change
+func (e Engine) WithPathLanguage(lang PathLanguage) (Engine, error) {
+	var queries map[string]query
+	if lang != PathDotted {
+		queries = map[string]query{}
+		for i, c := range e.Composites {
+			if err := compilePaths(fmt.Sprintf("composites[%d]", i), c, lang, queries); err != nil {
+				return Engine{}, err
+			}
+		}
+	}
+	e.pathLanguage = lang
+	e.queries = queries
+	return e, nil
+}
+
+// compilePaths will compile the path of every rule in the composite
+// and its children into queries, keyed by the path
+func compilePaths(node string, c Composite, lang PathLanguage, queries map[string]query) error {
+	for i, r := range c.Rules {
+		if _, ok := queries[r.Path]; ok {
+			continue
+		}
+		q, err := compilePath(lang, r.Path)
+		if err != nil {
+			return &RuleError{Node: fmt.Sprintf("%s.rules[%d]", node, i), Path: r.Path, Err: err}
+		}
+		queries[r.Path] = q
+	}
+	for i, cc := range c.Composites {
+		if err := compilePaths(fmt.Sprintf("%s.composites[%d]", node, i), cc, lang, queries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// query will return the compiled path in the engine's path language,
+// compiling it if it was not compiled by WithPathLanguage
+func (e *Engine) query(path string) (query, error) {
+	if q, ok := e.queries[path]; ok {
+		return q, nil
+	}
+	return compilePath(e.pathLanguage, path)
+}
+
 func pluck(props map[string]interface{}, path string) interface{} {
 	parts := strings.Split(path, ".")
 	for i := 0; i < len(parts)-1; i++ {
@@ -15,3 +98,38 @@ func pluck(props map[string]interface{}, path string) interface{} {
 	}
 	return props[parts[len(parts)-1]]
 }
+
+// pluckAll will return every value at a path whose segments may be *,
+// which stands for each element of an array, so "order.items.*.sku"
+// is the sku of every item. Elements without the rest of the path are
+// left out
+func pluckAll(props map[string]interface{}, path string) []interface{} {
+	return collect(props, strings.Split(path, "."), nil)
+}
+
+// collect will add the values at the path parts below v to values
+func collect(v interface{}, parts []string, values []interface{}) []interface{} {
+	if len(parts) == 0 {
+		if v != nil {
+			values = append(values, v)
+		}
+		return values
+	}
+	if parts[0] == "*" {
+		elems, _ := v.([]interface{})
+		for _, elem := range elems {
+			values = collect(elem, parts[1:], values)
+		}
+		return values
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return values
+	}
+	return collect(m[parts[0]], parts[1:], values)
+}
+
+// isWildcard will return true if any segment of the path is *
+func isWildcard(path string) bool {
+	return path == "*" || strings.HasPrefix(path, "*.") || strings.HasSuffix(path, ".*") || strings.Contains(path, ".*.")
+}
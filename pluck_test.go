@@ -1,6 +1,7 @@
 package grules
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -48,6 +49,50 @@ func TestPluck(t *testing.T) {
 	})
 }
 
+func TestPluckAll(t *testing.T) {
+	props := map[string]interface{}{
+		"order": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"sku": "A1", "tags": []interface{}{"new", "sale"}},
+				map[string]interface{}{"price": float64(10)},
+				map[string]interface{}{"sku": "B2", "tags": []interface{}{"sale"}},
+				"not an object",
+			},
+			"id": "o1",
+		},
+	}
+	cases := map[string][]interface{}{
+		"order.items.*.sku":    []interface{}{"A1", "B2"},
+		"order.items.*.tags.*": []interface{}{"new", "sale", "sale"},
+		"order.items.*.price":  []interface{}{float64(10)},
+		"order.items.*.color":  nil,
+		"order.id.*":           nil,
+		"order.missing.*.sku":  nil,
+	}
+	for path, expected := range cases {
+		if res := pluckAll(props, path); !reflect.DeepEqual(res, expected) {
+			t.Fatalf("expected %s to be %v, got %v", path, expected, res)
+		}
+	}
+}
+
+func TestIsWildcard(t *testing.T) {
+	cases := map[string]bool{
+		"*":                 true,
+		"*.sku":             true,
+		"order.items.*":     true,
+		"order.items.*.sku": true,
+		"order.items":       false,
+		"order.*items":      false,
+		"order.items*.sku":  false,
+	}
+	for path, expected := range cases {
+		if res := isWildcard(path); res != expected {
+			t.Fatalf("expected %s to be %v, got %v", path, expected, res)
+		}
+	}
+}
+
 func BenchmarkPluckShallow(b *testing.B) {
 	props := map[string]interface{}{
 		"username": "huttotw",
@@ -0,0 +1,107 @@
+package grules
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Loader is a function that should load the engine stored under key.
+// It is called by an EnginePool when the engine is not in memory
+type Loader func(key string) (Engine, error)
+
+// PoolStats is a snapshot of an EnginePool's metrics
+type PoolStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// EnginePool keeps the most recently used engines in memory, loading
+// and compiling engines the first time they are asked for and evicting
+// the least recently used engine once it is full. It is safe to use
+// from multiple goroutines
+type EnginePool struct {
+	mu       sync.Mutex
+	capacity int
+	load     Loader
+	order    *list.List
+	engines  map[string]*list.Element
+	stats    PoolStats
+}
+
+// poolEntry is a single engine held by the pool
+type poolEntry struct {
+	key    string
+	engine Engine
+}
+
+// NewEnginePool will create a new pool that holds at most capacity
+// engines, loading them with load
+func NewEnginePool(capacity int, load Loader) *EnginePool {
+	return &EnginePool{
+		capacity: capacity,
+		load:     load,
+		order:    list.New(),
+		engines:  map[string]*list.Element{},
+	}
+}
+
+// Get will return the compiled engine stored under key, loading it if
+// it is not in memory
+func (p *EnginePool) Get(key string) (Engine, error) {
+	p.mu.Lock()
+	if el, ok := p.engines[key]; ok {
+		p.order.MoveToFront(el)
+		p.stats.Hits++
+		e := el.Value.(*poolEntry).engine
+		p.mu.Unlock()
+		return e, nil
+	}
+	p.stats.Misses++
+	p.mu.Unlock()
+
+	// Load without holding the lock so a slow load does not block
+	// every other key
+	e, err := p.load(key)
+	if err != nil {
+		return Engine{}, err
+	}
+	e = e.Compile()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if el, ok := p.engines[key]; ok {
+		// Another goroutine loaded the same key first
+		p.order.MoveToFront(el)
+		return el.Value.(*poolEntry).engine, nil
+	}
+	p.engines[key] = p.order.PushFront(&poolEntry{key: key, engine: e})
+	for p.order.Len() > p.capacity {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.engines, oldest.Value.(*poolEntry).key)
+		p.stats.Evictions++
+	}
+	return e, nil
+}
+
+// Remove will drop the engine stored under key, so the next Get loads
+// it again
+func (p *EnginePool) Remove(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if el, ok := p.engines[key]; ok {
+		p.order.Remove(el)
+		delete(p.engines, key)
+	}
+}
+
+// Stats will return the pool's current metrics
+func (p *EnginePool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	s.Size = p.order.Len()
+	return s
+}
@@ -0,0 +1,98 @@
+package grules
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestEnginePool(t *testing.T) {
+	loads := map[string]int{}
+	var mu sync.Mutex
+	load := func(key string) (Engine, error) {
+		mu.Lock()
+		loads[key]++
+		mu.Unlock()
+		if key == "missing" {
+			return Engine{}, errors.New("not found")
+		}
+		return NewJSONEngine([]byte(fmt.Sprintf(`{"composites":[{"operator":"and","rules":[{"comparator":"eq","path":"tenant","value":%q}]}]}`, key)))
+	}
+	p := NewEnginePool(2, load)
+
+	t.Run("load and compile", func(t *testing.T) {
+		e, err := p.Get("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.plan == nil {
+			t.Fatal("expected engine to be compiled")
+		}
+		if e.Evaluate(map[string]interface{}{"tenant": "a"}) != true {
+			t.Fatal("expected engine to pass")
+		}
+	})
+
+	t.Run("evict least recently used", func(t *testing.T) {
+		p.Get("b")
+		p.Get("a")
+		p.Get("c")
+		p.Get("a")
+		p.Get("b")
+
+		expected := map[string]int{"a": 1, "b": 2, "c": 1}
+		for key, n := range expected {
+			if loads[key] != n {
+				t.Fatalf("expected %s to be loaded %d times, got %d", key, n, loads[key])
+			}
+		}
+
+		stats := p.Stats()
+		expectedStats := PoolStats{Hits: 2, Misses: 4, Evictions: 2, Size: 2}
+		if stats != expectedStats {
+			t.Fatalf("expected %v but got %v", expectedStats, stats)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		p.Remove("a")
+		p.Get("a")
+		if loads["a"] != 2 {
+			t.Fatalf("expected a to be loaded again, got %d loads", loads["a"])
+		}
+	})
+
+	t.Run("load error", func(t *testing.T) {
+		_, err := p.Get("missing")
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if p.Stats().Size != 2 {
+			t.Fatal("expected failed loads not to be held")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				key := fmt.Sprintf("tenant-%d", i%5)
+				e, err := p.Get(key)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if e.Evaluate(map[string]interface{}{"tenant": key}) != true {
+					t.Errorf("expected engine for %s to pass", key)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if p.Stats().Size != 2 {
+			t.Fatalf("expected pool to hold 2 engines, got %d", p.Stats().Size)
+		}
+	})
+}
@@ -0,0 +1,342 @@
+package grules
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Profile records which paths are plucked, how long comparators take
+// and how often composites with an ID match while an engine evaluates
+// real traffic. It is safe to use from multiple goroutines
+type Profile struct {
+	mu          sync.Mutex
+	plucks      map[string]int
+	comparisons map[string]ComparatorStats
+	composites  map[string]CompositeStats
+
+	// sampleSize is the number of values sampled at each path, zero
+	// if values are not sampled
+	sampleSize int
+	sketches   map[string]*sketch
+	rand       *rand.Rand
+
+	// pushMu makes sure only one push runs at a time, pushed is what
+	// has been pushed to a collector so far
+	pushMu sync.Mutex
+	pushed map[string]CompositeStats
+}
+
+// PathStats is how many times a path was plucked
+type PathStats struct {
+	Path   string
+	Plucks int
+}
+
+// ComparatorStats is how many times a comparator was called and the
+// total time spent in it
+type ComparatorStats struct {
+	Comparator string
+	Calls      int
+	Total      time.Duration
+}
+
+// Average will return the average time spent in a single call
+func (s ComparatorStats) Average() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Calls)
+}
+
+// CompositeStats is how many times a composite with an ID was
+// evaluated and how many of those times it matched
+type CompositeStats struct {
+	ID          string
+	Evaluations int
+	Matches     int
+}
+
+// ProfileReport is a summary of a profile. Paths are ordered by the
+// most plucked first, comparators by the most total time first,
+// composites by the most matched first and values by path
+type ProfileReport struct {
+	Paths       []PathStats
+	Comparators []ComparatorStats
+	Composites  []CompositeStats
+	Values      []ValueStats
+}
+
+// NewProfile will create a new, empty profile
+func NewProfile() *Profile {
+	return &Profile{
+		plucks:      map[string]int{},
+		comparisons: map[string]ComparatorStats{},
+		composites:  map[string]CompositeStats{},
+		pushed:      map[string]CompositeStats{},
+	}
+}
+
+// NewSamplingProfile will create a new, empty profile that also
+// samples up to size of the values seen at each path, see ValueStats
+func NewSamplingProfile(size int) *Profile {
+	p := NewProfile()
+	p.sampleSize = size
+	p.sketches = map[string]*sketch{}
+	p.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	return p
+}
+
+// WithProfile will return a copy of the engine that records every
+// evaluation in the profile
+func (e Engine) WithProfile(p *Profile) Engine {
+	e.profile = p
+	return e
+}
+
+// pluck will record that a path was plucked, and sample the value if
+// the profile samples values
+func (p *Profile) pluck(path string, v interface{}) {
+	p.mu.Lock()
+	p.plucks[path]++
+	if p.sampleSize > 0 && v != nil {
+		s, ok := p.sketches[path]
+		if !ok {
+			s = &sketch{}
+			p.sketches[path] = s
+		}
+		s.add(v, p.sampleSize, p.rand)
+	}
+	p.mu.Unlock()
+}
+
+// compare will record a call to a comparator
+func (p *Profile) compare(comparator string, d time.Duration) {
+	p.mu.Lock()
+	s := p.comparisons[comparator]
+	s.Comparator = comparator
+	s.Calls++
+	s.Total += d
+	p.comparisons[comparator] = s
+	p.mu.Unlock()
+}
+
+// match will record an evaluation of the composite with the given ID
+func (p *Profile) match(id string, matched bool) {
+	p.mu.Lock()
+	s := p.composites[id]
+	s.ID = id
+	s.Evaluations++
+	if matched {
+		s.Matches++
+	}
+	p.composites[id] = s
+	p.mu.Unlock()
+}
+
+// recordMatch will record the result of the composite in the engine's
+// profile, if it has one and the composite has an ID
+func (c Composite) recordMatch(e *Engine, matched bool) {
+	if e.profile != nil && c.ID != "" {
+		e.profile.match(c.ID, matched)
+	}
+}
+
+// Report will summarize everything recorded so far
+func (p *Profile) Report() ProfileReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r := ProfileReport{
+		Paths:       make([]PathStats, 0, len(p.plucks)),
+		Comparators: make([]ComparatorStats, 0, len(p.comparisons)),
+		Composites:  make([]CompositeStats, 0, len(p.composites)),
+		Values:      make([]ValueStats, 0, len(p.sketches)),
+	}
+	for path, n := range p.plucks {
+		r.Paths = append(r.Paths, PathStats{Path: path, Plucks: n})
+	}
+	for _, s := range p.comparisons {
+		r.Comparators = append(r.Comparators, s)
+	}
+	for _, s := range p.composites {
+		r.Composites = append(r.Composites, s)
+	}
+	for path, s := range p.sketches {
+		r.Values = append(r.Values, s.stats(path))
+	}
+
+	sort.Slice(r.Paths, func(i, j int) bool {
+		if r.Paths[i].Plucks != r.Paths[j].Plucks {
+			return r.Paths[i].Plucks > r.Paths[j].Plucks
+		}
+		return r.Paths[i].Path < r.Paths[j].Path
+	})
+	sort.Slice(r.Comparators, func(i, j int) bool {
+		if r.Comparators[i].Total != r.Comparators[j].Total {
+			return r.Comparators[i].Total > r.Comparators[j].Total
+		}
+		return r.Comparators[i].Comparator < r.Comparators[j].Comparator
+	})
+	sort.Slice(r.Composites, func(i, j int) bool {
+		if r.Composites[i].Matches != r.Composites[j].Matches {
+			return r.Composites[i].Matches > r.Composites[j].Matches
+		}
+		return r.Composites[i].ID < r.Composites[j].ID
+	})
+	sort.Slice(r.Values, func(i, j int) bool {
+		return r.Values[i].Path < r.Values[j].Path
+	})
+	return r
+}
+
+// Compile will return a copy of the engine with the children of every
+// and/or composite ordered cheapest first, using the cost hints of the
+// comparators. These operators stop at the first child that decides
+// the result, so running cheap children first avoids running
+// expensive ones. The order of children of other operators is left
+// alone. The regex rules of an or composite that have the same path
+// are combined into a single rule, so one regular expression is run
+// rather than one per rule. The compiled engine also resolves every
+// path its rules reference in a single traversal of the props, so the
+// engine should be compiled again after its composites change
+func (e Engine) Compile() Engine {
+	return e.Optimize(ProfileReport{})
+}
+
+// Optimize will do the same as Compile, but will prefer the average
+// time of each comparator in the report over its cost hint
+func (e Engine) Optimize(r ProfileReport) Engine {
+	costs := map[string]time.Duration{}
+	for name, cost := range e.costs {
+		costs[name] = cost
+	}
+	for _, s := range r.Comparators {
+		costs[s.Comparator] = s.Average()
+	}
+
+	// Rules are only combined when regex is the built in comparator
+	_, contextual := e.contextComparators["regex"]
+	combine := !contextual && reflect.ValueOf(e.comparators["regex"]).Pointer() == reflect.ValueOf(regex).Pointer()
+
+	composites := make([]Composite, len(e.Composites))
+	for i, c := range e.Composites {
+		composites[i], _ = optimizeComposite(c, costs, combine)
+	}
+	e.Composites = composites
+	e.plan = newPlan(composites)
+	return e
+}
+
+// optimizeComposite will return a copy of the composite with its
+// children ordered cheapest first, along with the cost of evaluating
+// every child. Weights are moved along with the children they belong
+// to. If combine is true the regex rules of or composites are combined
+func optimizeComposite(c Composite, costs map[string]time.Duration, combine bool) (Composite, time.Duration) {
+	var total time.Duration
+	if combine && c.Operator == OperatorOr && c.Aggregate == "" && len(c.Weights) == 0 {
+		c.Rules = combineRegexes(c.Rules)
+	}
+
+	type costed struct {
+		index     int
+		rule      Rule
+		composite Composite
+		cost      time.Duration
+	}
+	rules := make([]costed, len(c.Rules))
+	for i, r := range c.Rules {
+		rules[i] = costed{index: i, rule: r, cost: costs[r.Comparator]}
+		total += rules[i].cost
+	}
+	children := make([]costed, len(c.Composites))
+	for i, cc := range c.Composites {
+		children[i].index = len(c.Rules) + i
+		children[i].composite, children[i].cost = optimizeComposite(cc, costs, combine)
+		total += children[i].cost
+	}
+
+	if isAndOr(c.Operator) {
+		sort.SliceStable(rules, func(i, j int) bool {
+			return rules[i].cost < rules[j].cost
+		})
+		sort.SliceStable(children, func(i, j int) bool {
+			return children[i].cost < children[j].cost
+		})
+	}
+
+	var weights []float64
+	weight := func(i int) {
+		if len(c.Weights) == 0 {
+			return
+		}
+		w := 1.0
+		if i < len(c.Weights) {
+			w = c.Weights[i]
+		}
+		weights = append(weights, w)
+	}
+	var ordered []Rule
+	for _, r := range rules {
+		ordered = append(ordered, r.rule)
+		weight(r.index)
+	}
+	var composites []Composite
+	for _, child := range children {
+		composites = append(composites, child.composite)
+		weight(child.index)
+	}
+	c.Rules = ordered
+	c.Composites = composites
+	c.Weights = weights
+	return c, total
+}
+
+// combineRegexes will return the rules with the regex rules that have
+// the same path and OnMissing replaced by a single rule, in the place
+// of the first, whose pattern matches if any of theirs does. Invalid
+// patterns never match, so they are left out. Patterns with params
+// are left alone, since a missing param makes only its own rule false
+func combineRegexes(rules []Rule) []Rule {
+	type group struct {
+		index    int
+		rules    int
+		patterns []string
+	}
+	groups := map[[2]string]*group{}
+	order := []*group{}
+	combined := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		p, ok := r.Value.(string)
+		if r.Comparator != "regex" || !ok || strings.Contains(p, "${") {
+			combined = append(combined, r)
+			continue
+		}
+		key := [2]string{r.Path, r.OnMissing}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{index: len(combined)}
+			groups[key] = g
+			order = append(order, g)
+			combined = append(combined, r)
+		}
+		g.rules++
+		if compilePattern(p) != nil {
+			g.patterns = append(g.patterns, p)
+		}
+	}
+
+	for _, g := range order {
+		switch {
+		case g.rules == 1 || len(g.patterns) == 0:
+		case len(g.patterns) == 1:
+			combined[g.index].Value = g.patterns[0]
+		default:
+			combined[g.index].Value = "(?:" + strings.Join(g.patterns, ")|(?:") + ")"
+		}
+	}
+	return combined
+}
@@ -0,0 +1,223 @@
+package grules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProfile(t *testing.T) {
+	slow := func(a, b interface{}) bool {
+		time.Sleep(time.Millisecond)
+		return a == b
+	}
+	e := NewEngine().AddComparator("slow-eq", slow)
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{
+					Comparator: "slow-eq",
+					Path:       "user.name",
+					Value:      "Trevor",
+				},
+				Rule{
+					Comparator: "eq",
+					Path:       "user.age",
+					Value:      float64(23),
+				},
+				Rule{
+					Comparator: "eq",
+					Path:       "user.email",
+					Value:      "test@test.com",
+				},
+			},
+		},
+	}
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "Trevor",
+			"age":  float64(23),
+		},
+	}
+
+	p := NewProfile()
+	profiled := e.WithProfile(p)
+	for i := 0; i < 3; i++ {
+		profiled.Evaluate(props)
+	}
+	e.Evaluate(props)
+
+	r := p.Report()
+	expectedPaths := []PathStats{
+		PathStats{Path: "user.age", Plucks: 3},
+		PathStats{Path: "user.email", Plucks: 3},
+		PathStats{Path: "user.name", Plucks: 3},
+	}
+	if len(r.Paths) != len(expectedPaths) {
+		t.Fatalf("expected %v but got %v", expectedPaths, r.Paths)
+	}
+	for i := range expectedPaths {
+		if r.Paths[i] != expectedPaths[i] {
+			t.Fatalf("expected %v but got %v", expectedPaths, r.Paths)
+		}
+	}
+
+	if len(r.Comparators) != 2 {
+		t.Fatalf("expected 2 comparators, got %v", r.Comparators)
+	}
+	if r.Comparators[0].Comparator != "slow-eq" || r.Comparators[0].Calls != 3 {
+		t.Fatalf("expected slow-eq to be called 3 times, got %v", r.Comparators[0])
+	}
+	if r.Comparators[0].Average() < time.Millisecond {
+		t.Fatalf("expected slow-eq to take at least 1ms, got %v", r.Comparators[0].Average())
+	}
+	if r.Comparators[1].Comparator != "eq" || r.Comparators[1].Calls != 3 {
+		t.Fatalf("expected eq to be called 3 times, got %v", r.Comparators[1])
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "regex", Path: "user.email", Value: "^.+@test\\\\.com$"},
+				Rule{Comparator: "eq", Path: "user.name", Value: "Trevor"},
+			},
+			Composites: []Composite{
+				Composite{
+					Operator: OperatorOr,
+					Rules: []Rule{
+						Rule{Comparator: "regex", Path: "user.email", Value: "^a"},
+						Rule{Comparator: "regex", Path: "user.login", Value: "^b"},
+					},
+				},
+				Composite{
+					Operator: "majority",
+					Rules: []Rule{
+						Rule{Comparator: "regex", Path: "user.email", Value: "^c"},
+						Rule{Comparator: "eq", Path: "user.name", Value: "John"},
+					},
+				},
+			},
+		},
+	}
+	r := ProfileReport{
+		Comparators: []ComparatorStats{
+			ComparatorStats{Comparator: "regex", Calls: 10, Total: 10 * time.Microsecond},
+			ComparatorStats{Comparator: "eq", Calls: 10, Total: 10 * time.Nanosecond},
+		},
+	}
+
+	optimized := e.Optimize(r)
+	expectedStr := "({user.name eq Trevor} and {user.email regex ^.+@test\\\\.com$} and " +
+		"({user.email regex ^c} majority {user.name eq John}) and " +
+		"({user.email regex ^a} or {user.login regex ^b}))"
+	actualStr := optimized.Stringify()
+	if expectedStr != actualStr {
+		t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+	}
+
+	// The original engine should be untouched
+	if e.Composites[0].Rules[0].Comparator != "regex" {
+		t.Fatal("expected original engine to be unchanged")
+	}
+}
+
+func TestCompile(t *testing.T) {
+	always := func(a, b interface{}) bool {
+		return true
+	}
+	e := NewEngine().AddComparatorWithCost("lookup", always, time.Millisecond)
+	other := NewEngine()
+	if _, ok := other.comparators["lookup"]; ok {
+		t.Fatal("expected other engines not to have the comparator")
+	}
+	if _, ok := other.costs["lookup"]; ok {
+		t.Fatal("expected other engines not to have the cost")
+	}
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorOr,
+			Rules: []Rule{
+				Rule{Comparator: "lookup", Path: "user.id", Value: "blocked"},
+				Rule{Comparator: "contains", Path: "user.tags", Value: "vip"},
+				Rule{Comparator: "eq", Path: "user.name", Value: "Trevor"},
+			},
+		},
+	}
+
+	expectedStr := "({user.name eq Trevor} or {user.tags contains vip} or {user.id lookup blocked})"
+	actualStr := e.Compile().Stringify()
+	if expectedStr != actualStr {
+		t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+	}
+
+	// Measured costs should win over the hints
+	r := ProfileReport{
+		Comparators: []ComparatorStats{
+			ComparatorStats{Comparator: "lookup", Calls: 1, Total: time.Nanosecond},
+		},
+	}
+	expectedStr = "({user.id lookup blocked} or {user.name eq Trevor} or {user.tags contains vip})"
+	actualStr = e.Optimize(r).Stringify()
+	if expectedStr != actualStr {
+		t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+	}
+}
+
+func TestCompileRegexes(t *testing.T) {
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"or","rules":[
+		{"comparator":"regex","path":"user.email","value":"@example\\.com$"},
+		{"comparator":"eq","path":"user.name","value":"Trevor"},
+		{"comparator":"regex","path":"user.email","value":"(?i)^admin@"},
+		{"comparator":"regex","path":"user.email","value":"("},
+		{"comparator":"regex","path":"user.phone","value":"^\\+44"},
+		{"comparator":"regex","path":"user.email","value":"^root@","on_missing":"true"}
+	]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiled := e.Compile()
+	expectedStr := `({user.name eq Trevor} or {user.email regex (?:@example\.com$)|(?:(?i)^admin@)} or {user.phone regex ^\+44} or {user.email regex ^root@})`
+	if actualStr := compiled.Stringify(); actualStr != expectedStr {
+		t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+	}
+
+	cases := []map[string]interface{}{
+		map[string]interface{}{"email": "ann@example.com"},
+		map[string]interface{}{"email": "ADMIN@corp.io"},
+		map[string]interface{}{"email": "bob@corp.io"},
+		map[string]interface{}{"email": "bob@corp.io", "phone": "+441234"},
+		map[string]interface{}{"name": "Trevor"},
+		map[string]interface{}{},
+	}
+	for i, user := range cases {
+		props := map[string]interface{}{"user": user}
+		if expected, res := e.Evaluate(props), compiled.Evaluate(props); res != expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, expected, res)
+		}
+	}
+
+	// A custom regex comparator is not combined
+	custom := e.AddComparator("regex", func(a, b interface{}) bool { return false }).Compile()
+	if len(custom.Composites[0].Rules) != 6 {
+		t.Fatalf("expected the rules of a custom comparator to be kept, got %s", custom.Stringify())
+	}
+}
+
+func BenchmarkCompileRegexes(b *testing.B) {
+	rules := []Rule{}
+	for _, domain := range []string{"example", "corp", "test", "mail", "inbox", "post"} {
+		rules = append(rules, Rule{Comparator: "regex", Path: "user.email", Value: "@" + domain + "\\.com$"})
+	}
+	e := NewEngine()
+	e.Composites = []Composite{Composite{Operator: OperatorOr, Rules: rules}}
+	props := map[string]interface{}{"user": map[string]interface{}{"email": "ann@other.org"}}
+	e = e.Compile()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.Evaluate(props)
+	}
+}
@@ -0,0 +1,57 @@
+package grules
+
+// QuorumMajority can be used as the required number of votes of a
+// quorum to require more than half of its engines to agree
+const QuorumMajority = -1
+
+// Quorum evaluates several versions of an engine against the same
+// props, and only returns true if enough of them do. It is meant for
+// migrating rules where a false positive is costly: the old and new
+// versions can run side by side until they are known to agree
+type Quorum struct {
+	engines  []Engine
+	required int
+}
+
+// NewQuorum will create a new quorum of the given engines. required is
+// the number of engines that must return true, 0 requires all of them
+// and QuorumMajority requires more than half of them
+func NewQuorum(required int, engines ...Engine) Quorum {
+	switch {
+	case required == QuorumMajority:
+		required = len(engines)/2 + 1
+	case required <= 0 || required > len(engines):
+		required = len(engines)
+	}
+	return Quorum{engines: engines, required: required}
+}
+
+// Votes will return the result of each engine, in the order the
+// engines were given
+func (q Quorum) Votes(props map[string]interface{}) []bool {
+	votes := make([]bool, len(q.engines))
+	for i, e := range q.engines {
+		votes[i] = e.Evaluate(props)
+	}
+	return votes
+}
+
+// Evaluate will return true if at least the required number of
+// engines return true. It stops as soon as the result is known
+func (q Quorum) Evaluate(props map[string]interface{}) bool {
+	yes, no := 0, 0
+	for _, e := range q.engines {
+		if e.Evaluate(props) {
+			yes++
+		} else {
+			no++
+		}
+		if yes >= q.required {
+			return true
+		}
+		if no > len(q.engines)-q.required {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package grules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuorum(t *testing.T) {
+	engine := func(value float64) Engine {
+		e := NewEngine()
+		e.Composites = []Composite{
+			Composite{
+				Operator: OperatorAnd,
+				Rules: []Rule{
+					Rule{Comparator: "gte", Path: "user.age", Value: value},
+				},
+			},
+		}
+		return e
+	}
+	engines := []Engine{engine(18), engine(21), engine(16)}
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"age": float64(19),
+		},
+	}
+
+	votes := NewQuorum(0, engines...).Votes(props)
+	if !reflect.DeepEqual(votes, []bool{true, false, true}) {
+		t.Fatalf("expected votes to be [true false true], got %v", votes)
+	}
+
+	type quorumCase struct {
+		required int
+		expected bool
+	}
+	cases := []quorumCase{
+		quorumCase{required: 0, expected: false},
+		quorumCase{required: QuorumMajority, expected: true},
+		quorumCase{required: 1, expected: true},
+		quorumCase{required: 2, expected: true},
+		quorumCase{required: 3, expected: false},
+		quorumCase{required: 4, expected: false},
+	}
+	for i, c := range cases {
+		res := NewQuorum(c.required, engines...).Evaluate(props)
+		if res != c.expected {
+			t.Errorf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	if NewQuorum(0).Evaluate(props) {
+		t.Error("expected a quorum of no engines to be false")
+	}
+}
@@ -0,0 +1,325 @@
+package grules
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// errJSONEnd is returned when a JSON document ends too soon
+var errJSONEnd = errors.New("grules: unexpected end of JSON")
+
+// EvaluateJSON will evaluate the engine against a JSON object, like
+// Evaluate, decoding only the values at the paths its rules reference
+// instead of the whole document. Engines with JSONPath or JMESPath
+// paths, or rules with context comparators, which are given all of the
+// props, decode the whole document. JSON that can not be read is false
+func (e Engine) EvaluateJSON(data []byte) bool {
+	fields, whole := e.pathTree()
+	var props map[string]interface{}
+	if whole {
+		if err := json.Unmarshal(data, &props); err != nil {
+			return false
+		}
+		return e.Evaluate(props)
+	}
+	s := &jsonScanner{data: data}
+	props, err := s.object(fields)
+	if err != nil {
+		return false
+	}
+	s.space()
+	if s.pos != len(s.data) {
+		return false
+	}
+	return e.Evaluate(props)
+}
+
+// pathTree is a tree of the keys the rules of an engine reference,
+// which are the only ones that have to be read from a document. The
+// whole value of a key is read if all is true
+type pathTree struct {
+	all      bool
+	children map[string]*pathTree
+}
+
+// pathTree will return the keys that the engine's rules reference,
+// or whole if the whole document has to be decoded
+func (e *Engine) pathTree() (fields *pathTree, whole bool) {
+	if e.pathLanguage != PathDotted {
+		return nil, true
+	}
+	fields = &pathTree{}
+	for _, c := range e.Composites {
+		if !e.addPaths(fields, c) {
+			return nil, true
+		}
+	}
+	return fields, false
+}
+
+// addPaths will add the paths of all of the composite's rules,
+// returning false if the whole document has to be decoded
+func (e *Engine) addPaths(fields *pathTree, c Composite) bool {
+	for _, r := range c.Rules {
+		if _, ok := e.contextComparators[r.Comparator]; ok {
+			return false
+		}
+		fields.add(r.Path)
+		if strings.HasSuffix(r.Path, langSuffix) {
+			fields.add(strings.TrimSuffix(r.Path, langSuffix))
+		}
+		if ref, ok := pathRef(r.Value); ok {
+			fields.add(ref)
+		}
+		if a, ok := valueArithmetic(r.Value); ok {
+			a.paths(fields.add)
+		}
+	}
+	for _, cc := range c.Composites {
+		if !e.addPaths(fields, cc) {
+			return false
+		}
+	}
+	return true
+}
+
+// add will add a path to the tree. A * key stands for every element
+// of an array
+func (f *pathTree) add(path string) {
+	for _, key := range strings.Split(path, ".") {
+		if f.all {
+			break
+		}
+		if f.children == nil {
+			f.children = map[string]*pathTree{}
+		}
+		child, ok := f.children[key]
+		if !ok {
+			child = &pathTree{}
+			f.children[key] = child
+		}
+		f = child
+	}
+	f.all = true
+}
+
+// jsonScanner reads values from a JSON document, skipping the ones
+// that are not needed without decoding them
+type jsonScanner struct {
+	data []byte
+	pos  int
+}
+
+// object will read an object, decoding the keys in fields
+func (s *jsonScanner) object(fields *pathTree) (map[string]interface{}, error) {
+	if err := s.expect('{'); err != nil {
+		return nil, err
+	}
+	props := map[string]interface{}{}
+	s.space()
+	if s.peek() == '}' {
+		s.pos++
+		return props, nil
+	}
+	for {
+		s.space()
+		key, err := s.key()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.expect(':'); err != nil {
+			return nil, err
+		}
+		s.space()
+		child := fields.children[key]
+		switch {
+		case child == nil:
+			err = s.skip()
+		case child.all || child.children["*"] != nil:
+			start := s.pos
+			if err = s.skip(); err == nil {
+				props[key], err = decodeJSON(s.data[start:s.pos])
+			}
+		case s.peek() == '{':
+			props[key], err = s.object(child)
+		default:
+			err = s.skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.space()
+		switch s.next() {
+		case ',':
+		case '}':
+			return props, nil
+		default:
+			return nil, s.unexpected()
+		}
+	}
+}
+
+// key will read the key of an object
+func (s *jsonScanner) key() (string, error) {
+	start := s.pos
+	if err := s.skipString(); err != nil {
+		return "", err
+	}
+	raw := s.data[start+1 : s.pos-1]
+	for _, c := range raw {
+		if c == '\\' {
+			var key string
+			err := json.Unmarshal(s.data[start:s.pos], &key)
+			return key, err
+		}
+	}
+	return string(raw), nil
+}
+
+// skip will move past the next value. Objects and arrays are only
+// checked for strings and brackets that match, not parsed
+func (s *jsonScanner) skip() error {
+	switch s.peek() {
+	case '"':
+		return s.skipString()
+	case '{', '[':
+		// closers is the closing bracket of every open object and array,
+		// innermost last
+		closers := []byte{}
+		for s.pos < len(s.data) {
+			switch c := s.data[s.pos]; c {
+			case '"':
+				if err := s.skipString(); err != nil {
+					return err
+				}
+				continue
+			case '{':
+				closers = append(closers, '}')
+			case '[':
+				closers = append(closers, ']')
+			case '}', ']':
+				if closers[len(closers)-1] != c {
+					return s.unexpected()
+				}
+				closers = closers[:len(closers)-1]
+			}
+			s.pos++
+			if len(closers) == 0 {
+				return nil
+			}
+		}
+		return errJSONEnd
+	case 0:
+		return errJSONEnd
+	}
+	start := s.pos
+	for s.pos < len(s.data) && !isJSONDelimiter(s.data[s.pos]) {
+		s.pos++
+	}
+	if _, err := decodeJSON(s.data[start:s.pos]); err != nil {
+		s.pos = start
+		return s.unexpected()
+	}
+	return nil
+}
+
+// isJSONDelimiter will return true if c can end a number, true, false
+// or null
+func isJSONDelimiter(c byte) bool {
+	switch c {
+	case ',', '}', ']', ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
+
+// decodeJSON will decode a single JSON value, without reflection for
+// numbers, strings without escapes, true, false and null
+func decodeJSON(raw []byte) (interface{}, error) {
+	switch {
+	case len(raw) == 0:
+		return nil, errJSONEnd
+	case string(raw) == "true":
+		return true, nil
+	case string(raw) == "false":
+		return false, nil
+	case string(raw) == "null":
+		return nil, nil
+	case raw[0] == '-' || isDigit(raw[0]):
+		if f, err := strconv.ParseFloat(string(raw), 64); err == nil && isDigit(raw[len(raw)-1]) {
+			return f, nil
+		}
+	case raw[0] == '"' && bytes.IndexByte(raw, '\\') < 0:
+		return string(raw[1 : len(raw)-1]), nil
+	}
+	var v interface{}
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+// skipString will move past the string that starts at the position
+func (s *jsonScanner) skipString() error {
+	if s.peek() != '"' {
+		return s.unexpected()
+	}
+	for s.pos++; s.pos < len(s.data); s.pos++ {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '"':
+			s.pos++
+			return nil
+		}
+	}
+	return errJSONEnd
+}
+
+// space will skip any whitespace
+func (s *jsonScanner) space() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\r', '\n':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek will return the next byte, or 0 at the end of the document
+func (s *jsonScanner) peek() byte {
+	if s.pos >= len(s.data) {
+		return 0
+	}
+	return s.data[s.pos]
+}
+
+// next will return the next byte and move past it
+func (s *jsonScanner) next() byte {
+	c := s.peek()
+	s.pos++
+	return c
+}
+
+// expect will move past the next byte, which must be c, after any
+// whitespace
+func (s *jsonScanner) expect(c byte) error {
+	s.space()
+	if s.peek() != c {
+		return s.unexpected()
+	}
+	s.pos++
+	return nil
+}
+
+// unexpected will return an error for the next byte
+func (s *jsonScanner) unexpected() error {
+	if s.pos >= len(s.data) {
+		return errJSONEnd
+	}
+	return fmt.Errorf("grules: unexpected %q in JSON at %d", s.data[s.pos], s.pos)
+}
@@ -0,0 +1,72 @@
+package grules
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEvaluateJSON(t *testing.T) {
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[
+		{"comparator":"gte","path":"user.age","value":18},
+		{"comparator":"eq","path":"user.na\"me","value":"Ann"},
+		{"comparator":"contains","path":"user.tags","value":"vip"},
+		{"comparator":"eq","path":"order.items.*.sku","value":"b"},
+		{"comparator":"lte","path":"order.total","value":{"$expr":"$user.limit * 2"}}
+	]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		json     string
+		expected bool
+	}{
+		{json: `{"user":{"age":30,"na\"me":"Ann","tags":["vip"],"limit":50},"order":{"total":100,"items":[{"sku":"a"},{"sku":"b"}]}}`, expected: true},
+		{json: ` { "skipped" : {"deep": [1, {"x": "}]\""}, true, null, -1.5e3]} , "user":{"age":30,"na\"me":"Ann","tags":["vip"],"limit":50},"order":{"total":100,"items":[{"sku":"b"}]}} `, expected: true},
+		{json: `{"user":{"age":17,"na\"me":"Ann","tags":["vip"],"limit":50},"order":{"total":100,"items":[{"sku":"b"}]}}`, expected: false},
+		{json: `{"user":{"age":30,"na\"me":"Ann","tags":["vip"],"limit":40},"order":{"total":100,"items":[{"sku":"b"}]}}`, expected: false},
+		{json: `{"user":{"age":30,"na\"me":"Ann","tags":["vip"],"limit":50},"order":{"total":100,"items":[{"sku":"a"}]}}`, expected: false},
+		{json: `{"user":"ann","order":{"total":100,"items":[{"sku":"b"}]}}`, expected: false},
+		{json: `{"user":{"age":30,"na\"me":"Ann","tags":["vip"],"limit":50},"order":{"total":100,"items":[{"sku":"b"}]}`, expected: false},
+		{json: `{"user":{"age":30,"na\"me":"Ann","tags":["vip"],"limit":50},"order":{"total":100,"items":[{"sku":"b"}]}} x`, expected: false},
+		{json: `{"skipped":tru,"user":{"age":30,"na\"me":"Ann","tags":["vip"],"limit":50},"order":{"total":100,"items":[{"sku":"b"}]}}`, expected: false},
+		{json: `[]`, expected: false},
+		{json: `{"x":[1,2},"user":{"age":30,"na\"me":"Ann","tags":["vip"],"limit":50},"order":{"total":100,"items":[{"sku":"b"}]}}`, expected: false},
+		{json: `{"x":{"y":1],"user":{"age":30,"na\"me":"Ann","tags":["vip"],"limit":50},"order":{"total":100,"items":[{"sku":"b"}]}}`, expected: false},
+	}
+	for i, c := range cases {
+		if res := e.EvaluateJSON([]byte(c.json)); res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+		var props map[string]interface{}
+		if json.Unmarshal([]byte(c.json), &props) == nil && e.Evaluate(props) != c.expected {
+			t.Fatalf("expected case %d to match Evaluate", i)
+		}
+	}
+
+	e, err = NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[{"comparator":"eq","path":"order.items[-1].sku","value":"b"}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err = e.WithPathLanguage(PathJMESPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.EvaluateJSON([]byte(cases[0].json)) {
+		t.Fatal("expected JMESPath paths to be read from the whole document")
+	}
+}
+
+func BenchmarkEvaluateJSON(b *testing.B) {
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[
+		{"comparator":"gte","path":"user.age","value":18},
+		{"comparator":"eq","path":"user.country","value":"NZ"}
+	]}]}`))
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := []byte(`{"user":{"age":30,"country":"NZ","bio":"a long biography that is never read by the rules","tags":["a","b","c"]},"events":[{"type":"click","at":1},{"type":"view","at":2},{"type":"click","at":3}]}`)
+	for i := 0; i < b.N; i++ {
+		e.EvaluateJSON(data)
+	}
+}
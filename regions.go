@@ -0,0 +1,87 @@
+package grules
+
+import (
+	"strings"
+)
+
+// regionMembers is a map of each region to its members, which are ISO
+// 3166-1 alpha-2 country codes or other regions. Continents follow the
+// UN M49 standard, with the Americas split into North and South
+var regionMembers = map[string]string{
+	"Africa": "DZ AO BJ BW IO BF BI CV CM CF TD KM CG CD CI DJ EG GQ ER SZ ET TF GA GM GH GN GW KE LS LR LY MG " +
+		"MW ML MR MU YT MA MZ NA NE NG RE RW SH ST SN SC SL SO ZA SS SD TZ TG TN UG EH ZM ZW",
+	"Antarctica": "AQ",
+	"Asia": "AF AM AZ BH BD BT BN KH CN CY GE HK IN ID IR IQ IL JP JO KZ KW KG LA LB MO MY MV MN MM NP KP OM PK " +
+		"PS PH QA SA SG KR LK SY TW TJ TH TL TR TM AE UZ VN YE",
+	"Europe": "AX AL AD AT BY BE BA BG HR CZ DK EE FO FI FR DE GI GR GG VA HU IS IE IM IT JE LV LI LT LU MT MD " +
+		"MC ME NL MK NO PL PT RO RU SM RS SK SI ES SJ SE CH UA GB",
+	"NorthAmerica": "AI AG AW BS BB BZ BM BQ VG CA KY CR CU CW DM DO SV GL GD GP GT HT HN JM MQ MX MS NI PA PR " +
+		"BL KN LC MF PM VC SX TT TC US VI",
+	"Oceania":      "AS AU CX CC CK FJ PF GU HM KI MH FM NR NC NZ NU NF MP PW PG PN WS SB TK TO TV UM VU WF",
+	"SouthAmerica": "AR BO BV BR CL CO EC FK GF GY PY PE GS SR UY VE",
+	"Americas":     "NorthAmerica SouthAmerica",
+	"EU":           "AT BE BG HR CY CZ DK EE FI FR DE GR HU IE IT LV LT LU MT NL PL PT RO SK SI ES SE",
+	"EEA":          "EU IS LI NO",
+	"Schengen":     "AT BE BG CZ DK EE FI FR DE GR HR HU IS IT LV LI LT LU MT NL NO PL PT RO SK SI ES SE CH",
+}
+
+// regions is a map of each lower case region to the set of countries
+// in it, with the regions in regionMembers expanded
+var regions = expandRegions(regionMembers)
+
+// expandRegions will create a set of countries for every region,
+// replacing member regions with their countries
+func expandRegions(members map[string]string) map[string]map[string]bool {
+	expanded := map[string]map[string]bool{}
+	var expand func(name string) map[string]bool
+	expand = func(name string) map[string]bool {
+		key := strings.ToLower(name)
+		if set, ok := expanded[key]; ok {
+			return set
+		}
+		set := map[string]bool{}
+		expanded[key] = set
+		for _, m := range strings.Fields(members[name]) {
+			if _, ok := members[m]; ok {
+				for c := range expand(m) {
+					set[c] = true
+				}
+				continue
+			}
+			set[m] = true
+		}
+		return set
+	}
+	for name := range members {
+		expand(name)
+	}
+	return expanded
+}
+
+// inRegion will return true if the country a is in the region b, or
+// any of the regions if b is an array. a is an ISO 3166-1 alpha-2 code
+// like "DE", or an ISO 3166-2 subdivision like "CA-ON", which is in
+// the regions of its country. Regions are matched without regard to
+// case, see regionMembers for the regions
+func inRegion(a, b interface{}) bool {
+	s, ok := a.(string)
+	if !ok {
+		return false
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s = s[:i]
+	}
+	country := strings.ToUpper(s)
+
+	names, ok := b.([]interface{})
+	if !ok {
+		names = []interface{}{b}
+	}
+	for _, n := range names {
+		name, ok := n.(string)
+		if ok && regions[strings.ToLower(name)][country] {
+			return true
+		}
+	}
+	return false
+}
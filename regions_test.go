@@ -0,0 +1,58 @@
+package grules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInRegion(t *testing.T) {
+	cases := []testCase{
+		testCase{args: []interface{}{"DE", "EU"}, expected: true},
+		testCase{args: []interface{}{"de", "eu"}, expected: true},
+		testCase{args: []interface{}{"CA-ON", "NorthAmerica"}, expected: true},
+		testCase{args: []interface{}{"CA-ON", "Americas"}, expected: true},
+		testCase{args: []interface{}{"NO", "EU"}, expected: false},
+		testCase{args: []interface{}{"NO", "EEA"}, expected: true},
+		testCase{args: []interface{}{"FR", "EEA"}, expected: true},
+		testCase{args: []interface{}{"CH", "Schengen"}, expected: true},
+		testCase{args: []interface{}{"GB", "EU"}, expected: false},
+		testCase{args: []interface{}{"GB", []interface{}{"EU", "Europe"}}, expected: true},
+		testCase{args: []interface{}{"JP", []interface{}{"EU", "Europe"}}, expected: false},
+		testCase{args: []interface{}{"BR", "SouthAmerica"}, expected: true},
+		testCase{args: []interface{}{"DE", "Atlantis"}, expected: false},
+		testCase{args: []interface{}{"EU", "Europe"}, expected: false},
+		testCase{args: []interface{}{float64(1), "EU"}, expected: false},
+		testCase{args: []interface{}{"DE", float64(1)}, expected: false},
+	}
+
+	for i, c := range cases {
+		res := inRegion(c.args[0], c.args[1])
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func BenchmarkInRegion(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		inRegion("CA-ON", "NorthAmerica")
+	}
+}
+
+func TestContinents(t *testing.T) {
+	seen := map[string]string{}
+	for _, continent := range []string{"Africa", "Antarctica", "Asia", "Europe", "NorthAmerica", "Oceania", "SouthAmerica"} {
+		for c := range regions[strings.ToLower(continent)] {
+			if other, ok := seen[c]; ok {
+				t.Fatalf("expected %s to be in one continent, got %s and %s", c, other, continent)
+			}
+			seen[c] = continent
+		}
+	}
+	if len(seen) != 249 {
+		t.Fatalf("expected 249 countries, got %d", len(seen))
+	}
+	if len(regions["eu"]) != 27 || len(regions["eea"]) != 30 {
+		t.Fatalf("expected 27 EU and 30 EEA countries, got %d and %d", len(regions["eu"]), len(regions["eea"]))
+	}
+}
@@ -0,0 +1,128 @@
+package grules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MaxRequestBody is the largest request body PropsFromRequest will read
+const MaxRequestBody = 10 << 20
+
+// PropsFromRequest will return the props of an HTTP request, for rules
+// that filter requests. The method, path and host are at "method",
+// "path" and "host", headers are at "header." followed by their name in
+// lower case, query params are at "query." and the optional params,
+// such as those a router takes from the path, are at "params.". A JSON
+// body is decoded to "body", and a form body is at "body." like query
+// params. A header or param with one value is a string, and one with
+// several is a list of them. The body is read and replaced, so handlers
+// can still read it
+func PropsFromRequest(r *http.Request, params map[string]string) (map[string]interface{}, error) {
+	header := make(map[string]interface{}, len(r.Header))
+	for k, v := range r.Header {
+		header[strings.ToLower(k)] = formValue(v)
+	}
+	props := map[string]interface{}{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"host":   r.Host,
+		"header": header,
+		"query":  PropsFromValues(r.URL.Query()),
+	}
+	if params != nil {
+		p := make(map[string]interface{}, len(params))
+		for k, v := range params {
+			p[k] = v
+		}
+		props["params"] = p
+	}
+
+	body, err := requestBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		props["body"] = body
+	}
+	return props, nil
+}
+
+// PropsFromValues will return url.Values as props, with a string for
+// keys with one value and a list of strings for keys with several
+func PropsFromValues(v url.Values) map[string]interface{} {
+	props := make(map[string]interface{}, len(v))
+	for k, vs := range v {
+		props[k] = formValue(vs)
+	}
+	return props
+}
+
+// formValue will return a single value as a string, and several as a list
+func formValue(vs []string) interface{} {
+	if len(vs) == 1 {
+		return vs[0]
+	}
+	list := make([]interface{}, len(vs))
+	for i, v := range vs {
+		list[i] = v
+	}
+	return list
+}
+
+// requestBody will decode the body of a request with a JSON or form
+// content type, returning nil for other content types
+func requestBody(r *http.Request) (interface{}, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if !isJSON && mediaType != "application/x-www-form-urlencoded" {
+		return nil, nil
+	}
+
+	// The body is put back together from what was read and the rest of
+	// the stream, so handlers can still read all of it
+	body := r.Body
+	b, err := ioutil.ReadAll(io.LimitReader(body, MaxRequestBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), body), body}
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > MaxRequestBody {
+		return nil, fmt.Errorf("grules: request body is larger than %d bytes", MaxRequestBody)
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, nil
+	}
+	if isJSON {
+		var v interface{}
+		err := json.Unmarshal(b, &v)
+		return v, err
+	}
+	v, err := url.ParseQuery(string(b))
+	if err != nil {
+		return nil, err
+	}
+	return PropsFromValues(v), nil
+}
+
+// EvaluateRequest will evaluate the engine against the props of an
+// HTTP request, see PropsFromRequest
+func (e Engine) EvaluateRequest(r *http.Request, params map[string]string) (bool, error) {
+	props, err := PropsFromRequest(r, params)
+	if err != nil {
+		return false, err
+	}
+	return e.Evaluate(props), nil
+}
@@ -0,0 +1,76 @@
+package grules
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPropsFromRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "http://api.example.com/orders/42?debug=1&tag=a&tag=b", strings.NewReader(`{"total": 120, "items": [{"sku": "a"}]}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Header.Set("X-Api-Key", "secret")
+
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[
+		{"comparator":"eq","path":"method","value":"POST"},
+		{"comparator":"eq","path":"path","value":"/orders/42"},
+		{"comparator":"eq","path":"host","value":"api.example.com"},
+		{"comparator":"eq","path":"header.x-api-key","value":"secret"},
+		{"comparator":"eq","path":"query.debug","value":"1"},
+		{"comparator":"contains","path":"query.tag","value":"b"},
+		{"comparator":"eq","path":"params.id","value":"42"},
+		{"comparator":"gt","path":"body.total","value":100},
+		{"comparator":"eq","path":"body.items.*.sku","value":"a"}
+	]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	passed, err := e.EvaluateRequest(r, map[string]string{"id": "42"})
+	if err != nil || !passed {
+		t.Fatalf("expected the request to pass, got %v %v", passed, err)
+	}
+	if body, _ := ioutil.ReadAll(r.Body); !strings.Contains(string(body), `"total": 120`) {
+		t.Fatalf("expected the body to still be readable, got %q", body)
+	}
+
+	r = httptest.NewRequest("POST", "/login", strings.NewReader("user=ann&remember=on"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	props, err := PropsFromRequest(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pluck(props, "body.user") != "ann" || props["params"] != nil {
+		t.Fatalf("expected the form to be decoded, got %v", props)
+	}
+
+	r = httptest.NewRequest("POST", "/upload", strings.NewReader("raw bytes"))
+	r.Header.Set("Content-Type", "application/octet-stream")
+	if props, err := PropsFromRequest(r, nil); err != nil || props["body"] != nil {
+		t.Fatalf("expected other bodies to be left out, got %v %v", props, err)
+	}
+
+	r = httptest.NewRequest("POST", "/orders", strings.NewReader(`{"total":`))
+	r.Header.Set("Content-Type", "application/json")
+	if _, err := e.EvaluateRequest(r, nil); err == nil {
+		t.Fatal("expected invalid JSON to be an error")
+	}
+
+	large := strings.Repeat(" ", MaxRequestBody+100)
+	r = httptest.NewRequest("POST", "/orders", strings.NewReader(large))
+	r.Header.Set("Content-Type", "application/json")
+	if _, err := PropsFromRequest(r, nil); err == nil {
+		t.Fatal("expected a body that is too large to be an error")
+	}
+	if body, _ := ioutil.ReadAll(r.Body); len(body) != len(large) {
+		t.Fatalf("expected the whole body to still be readable, got %d bytes", len(body))
+	}
+}
+
+func BenchmarkPropsFromRequest(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		r := httptest.NewRequest("POST", "/orders?debug=1", strings.NewReader(`{"total": 120}`))
+		r.Header.Set("Content-Type", "application/json")
+		PropsFromRequest(r, nil)
+	}
+}
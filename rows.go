@@ -0,0 +1,98 @@
+package grules
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// PropsFromRows will scan every row into props, with each column at
+// the path it is mapped to in paths, or at its name if it is not in
+// paths, so a "user_email" column can become "user.email". Integers
+// become float64 and byte slices become strings to match props
+// decoded from JSON, and NULL columns are left out. The rows are
+// closed when they have been read
+func PropsFromRows(rows *sql.Rows, paths map[string]string) ([]map[string]interface{}, error) {
+	all := []map[string]interface{}{}
+	err := scanRows(rows, paths, func(props map[string]interface{}) error {
+		all = append(all, props)
+		return nil
+	})
+	return all, err
+}
+
+// EvaluateRows will evaluate the engine against each row, scanned as
+// PropsFromRows does, calling fn with the props of every row that
+// passes. Rows are read one at a time so large results do not need to
+// fit in memory. An error from fn stops the evaluation and is returned
+func (e Engine) EvaluateRows(rows *sql.Rows, paths map[string]string, fn func(props map[string]interface{}) error) error {
+	return scanRows(rows, paths, func(props map[string]interface{}) error {
+		if !e.Evaluate(props) {
+			return nil
+		}
+		return fn(props)
+	})
+}
+
+// scanRows will call fn with the props of each row
+func scanRows(rows *sql.Rows, paths map[string]string, fn func(props map[string]interface{}) error) error {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	keys := make([][]string, len(columns))
+	for i, c := range columns {
+		path, ok := paths[c]
+		if !ok {
+			path = c
+		}
+		keys[i] = strings.Split(path, ".")
+	}
+
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		props := map[string]interface{}{}
+		for i, v := range values {
+			if v = columnValue(v); v != nil {
+				setPath(props, keys[i], v)
+			}
+		}
+		if err := fn(props); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// columnValue will convert a value scanned from a column to the type
+// it would have in props decoded from JSON
+func columnValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case []byte:
+		return string(v)
+	}
+	return v
+}
+
+// setPath will set the value at the path in props, creating the maps
+// along the path that do not exist
+func setPath(props map[string]interface{}, keys []string, v interface{}) {
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := props[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			props[key] = next
+		}
+		props = next
+	}
+	props[keys[len(keys)-1]] = v
+}
@@ -0,0 +1,131 @@
+package grules
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// testRows is the result every query against the test driver returns
+var testRows = struct {
+	columns []string
+	values  [][]driver.Value
+}{
+	columns: []string{"id", "user_email", "total", "note"},
+	values: [][]driver.Value{
+		[]driver.Value{int64(1), []byte("trevor@example.com"), float64(120), nil},
+		[]driver.Value{int64(2), []byte("anna@example.com"), float64(40), "gift"},
+	},
+}
+
+func init() {
+	sql.Register("grules-test", testDriver{})
+}
+
+type testDriver struct{}
+
+func (testDriver) Open(name string) (driver.Conn, error) { return testConn{}, nil }
+
+type testConn struct{}
+
+func (testConn) Prepare(query string) (driver.Stmt, error) { return testStmt{}, nil }
+func (testConn) Close() error                              { return nil }
+func (testConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type testStmt struct{}
+
+func (testStmt) Close() error  { return nil }
+func (testStmt) NumInput() int { return 0 }
+func (testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (testStmt) Query(args []driver.Value) (driver.Rows, error) { return &testDriverRows{}, nil }
+
+type testDriverRows struct {
+	i int
+}
+
+func (r *testDriverRows) Columns() []string { return testRows.columns }
+func (r *testDriverRows) Close() error      { return nil }
+func (r *testDriverRows) Next(dest []driver.Value) error {
+	if r.i >= len(testRows.values) {
+		return io.EOF
+	}
+	copy(dest, testRows.values[r.i])
+	r.i++
+	return nil
+}
+
+func queryTestRows(t *testing.T) *sql.Rows {
+	db, err := sql.Open("grules-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT id, user_email, total, note FROM orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestPropsFromRows(t *testing.T) {
+	props, err := PropsFromRows(queryTestRows(t), map[string]string{
+		"user_email": "user.email",
+		"total":      "order.total",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []map[string]interface{}{
+		map[string]interface{}{
+			"id":    float64(1),
+			"user":  map[string]interface{}{"email": "trevor@example.com"},
+			"order": map[string]interface{}{"total": float64(120)},
+		},
+		map[string]interface{}{
+			"id":    float64(2),
+			"user":  map[string]interface{}{"email": "anna@example.com"},
+			"order": map[string]interface{}{"total": float64(40)},
+			"note":  "gift",
+		},
+	}
+	if !reflect.DeepEqual(props, expected) {
+		t.Fatalf("expected %v, got %v", expected, props)
+	}
+}
+
+func TestEvaluateRows(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "gt", Path: "order.total", Value: float64(100)},
+			},
+		},
+	}
+	paths := map[string]string{"total": "order.total"}
+
+	ids := []interface{}{}
+	err := e.EvaluateRows(queryTestRows(t), paths, func(props map[string]interface{}) error {
+		ids = append(ids, props["id"])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []interface{}{float64(1)}) {
+		t.Fatalf("expected only row 1 to pass, got %v", ids)
+	}
+
+	stop := errors.New("stop")
+	err = NewEngine().EvaluateRows(queryTestRows(t), paths, func(props map[string]interface{}) error {
+		return stop
+	})
+	if err != stop {
+		t.Fatalf("expected the error from fn, got %v", err)
+	}
+}
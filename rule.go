@@ -1,9 +1,12 @@
 package grules
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
 const (
@@ -11,53 +14,348 @@ const (
 	OperatorAnd = "and"
 	// OperatorOr is what identifies the OR condition in a composite
 	OperatorOr = "or"
+	// OperatorNot is what identifies the NOT condition in a composite
+	OperatorNot = "not"
+	// OperatorXor is what identifies the XOR condition in a composite
+	OperatorXor = "xor"
+	// OperatorNone is what identifies the NONE condition in a composite
+	OperatorNone = "none"
+	// OperatorAtLeast is what identifies the AT LEAST N condition in a
+	// composite
+	OperatorAtLeast = "atleast"
+	// OperatorExactly is what identifies the EXACTLY N condition in a
+	// composite
+	OperatorExactly = "exactly"
 )
 
 // defaultComparators is a map of all the default comparators that
 // a new engine should include
 var defaultComparators = map[string]Comparator{
-	"eq":        equal,
-	"neq":       notEqual,
-	"gt":        greaterThan,
-	"gte":       greaterThanEqual,
-	"lt":        lessThan,
-	"lte":       lessThanEqual,
-	"contains":  contains,
-	"ncontains": notContains,
-	"oneof":     oneOf,
+	"eq":              equal,
+	"neq":             notEqual,
+	"gt":              greaterThan,
+	"gte":             greaterThanEqual,
+	"lt":              lessThan,
+	"lte":             lessThanEqual,
+	"contains":        contains,
+	"ncontains":       notContains,
+	"oneof":           oneOf,
+	"regex":           regex,
+	"nregex":          notRegex,
+	"ieq":             equalFold,
+	"ineq":            notEqualFold,
+	"icontains":       containsFold,
+	"startswith":      startsWith,
+	"nstartswith":     notStartsWith,
+	"endswith":        endsWith,
+	"nendswith":       notEndsWith,
+	"between":         between,
+	"nbetween":        notBetween,
+	"in":              in,
+	"nin":             notIn,
+	"empty":           empty,
+	"notempty":        notEmpty,
+	"exists":          exists,
+	"nexists":         notExists,
+	"before":          before,
+	"after":           after,
+	"dateeq":          dateEqual,
+	"within":          within,
+	"olderthan":       olderThan,
+	"cidr-contains":   cidrContains,
+	"ip-eq":           ipEqual,
+	"geo-within":      geoWithin,
+	"glob":            glob,
+	"contains-all":    containsAll,
+	"contains-any":    containsAny,
+	"contains-none":   containsNone,
+	"subset":          subset,
+	"superset":        superset,
+	"intersects":      intersects,
+	"len-eq":          lengthEqual,
+	"len-gt":          lengthGreaterThan,
+	"len-lt":          lengthLessThan,
+	"strlen-eq":       stringLengthEqual,
+	"strlen-gt":       stringLengthGreaterThan,
+	"strlen-lt":       stringLengthLessThan,
+	"levenshtein-lte": variadic(levenshteinAtMost, 2),
+	"jarowinkler-gte": variadic(jaroWinklerAtLeast, 2),
+	"soundslike":      soundsLike,
+	"fuzzy":           fuzzy,
+	"divisibleby":     divisibleBy,
+	"maskset":         maskSet,
+	"maskclear":       maskClear,
+	"keywords":        keywords,
+	"money-eq":        moneyComparator(nil, moneyEqual),
+	"money-neq":       moneyComparator(nil, moneyNotEqual),
+	"money-lt":        moneyComparator(nil, moneyLessThan),
+	"money-lte":       moneyComparator(nil, moneyLessThanEqual),
+	"money-gt":        moneyComparator(nil, moneyGreaterThan),
+	"money-gte":       moneyComparator(nil, moneyGreaterThanEqual),
+	"isuuid":          isUUID,
+	"isuuid-v1":       isUUIDVersion(1),
+	"isuuid-v2":       isUUIDVersion(2),
+	"isuuid-v3":       isUUIDVersion(3),
+	"isuuid-v4":       isUUIDVersion(4),
+	"isuuid-v5":       isUUIDVersion(5),
+	"isuuid-v6":       isUUIDVersion(6),
+	"isuuid-v7":       isUUIDVersion(7),
+	"isuuid-v8":       isUUIDVersion(8),
+	"isemail":         isEmail,
+	"isurl":           isURL,
+	"mimetype":        mimeType,
+	"extension":       extension,
+	"sum-eq":          aggregate(sum, equal),
+	"sum-neq":         aggregate(sum, notEqual),
+	"sum-lt":          aggregate(sum, lessThan),
+	"sum-lte":         aggregate(sum, lessThanEqual),
+	"sum-gt":          aggregate(sum, greaterThan),
+	"sum-gte":         aggregate(sum, greaterThanEqual),
+	"avg-eq":          aggregate(avg, equal),
+	"avg-neq":         aggregate(avg, notEqual),
+	"avg-lt":          aggregate(avg, lessThan),
+	"avg-lte":         aggregate(avg, lessThanEqual),
+	"avg-gt":          aggregate(avg, greaterThan),
+	"avg-gte":         aggregate(avg, greaterThanEqual),
+	"min-eq":          aggregate(minimum, equal),
+	"min-neq":         aggregate(minimum, notEqual),
+	"min-lt":          aggregate(minimum, lessThan),
+	"min-lte":         aggregate(minimum, lessThanEqual),
+	"min-gt":          aggregate(minimum, greaterThan),
+	"min-gte":         aggregate(minimum, greaterThanEqual),
+	"max-eq":          aggregate(maximum, equal),
+	"max-neq":         aggregate(maximum, notEqual),
+	"max-lt":          aggregate(maximum, lessThan),
+	"max-lte":         aggregate(maximum, lessThanEqual),
+	"max-gt":          aggregate(maximum, greaterThan),
+	"max-gte":         aggregate(maximum, greaterThanEqual),
+	"count-eq":        count(equal),
+	"count-neq":       count(notEqual),
+	"count-lt":        count(lessThan),
+	"count-lte":       count(lessThanEqual),
+	"count-gt":        count(greaterThan),
+	"count-gte":       count(greaterThanEqual),
+	"inregion":        inRegion,
+}
+
+// presenceComparators is a set of the comparators that are still
+// called when the path of a rule is missing, with a nil value
+var presenceComparators = map[string]bool{
+	"exists":  true,
+	"nexists": true,
+}
+
+// defaultCosts is a map of the cost hints of the default comparators,
+// taken from their benchmarks
+var defaultCosts = map[string]time.Duration{
+	"eq":              7 * time.Nanosecond,
+	"neq":             5 * time.Nanosecond,
+	"gt":              18 * time.Nanosecond,
+	"gte":             14 * time.Nanosecond,
+	"lt":              11 * time.Nanosecond,
+	"lte":             8 * time.Nanosecond,
+	"contains":        73 * time.Nanosecond,
+	"ncontains":       75 * time.Nanosecond,
+	"oneof":           73 * time.Nanosecond,
+	"regex":           600 * time.Nanosecond,
+	"nregex":          535 * time.Nanosecond,
+	"ieq":             25 * time.Nanosecond,
+	"ineq":            25 * time.Nanosecond,
+	"icontains":       75 * time.Nanosecond,
+	"startswith":      5 * time.Nanosecond,
+	"nstartswith":     5 * time.Nanosecond,
+	"endswith":        5 * time.Nanosecond,
+	"nendswith":       5 * time.Nanosecond,
+	"between":         40 * time.Nanosecond,
+	"nbetween":        40 * time.Nanosecond,
+	"in":              125 * time.Nanosecond,
+	"nin":             170 * time.Nanosecond,
+	"empty":           4 * time.Nanosecond,
+	"notempty":        4 * time.Nanosecond,
+	"exists":          1 * time.Nanosecond,
+	"nexists":         1 * time.Nanosecond,
+	"before":          110 * time.Nanosecond,
+	"after":           115 * time.Nanosecond,
+	"dateeq":          375 * time.Nanosecond,
+	"within":          185 * time.Nanosecond,
+	"olderthan":       175 * time.Nanosecond,
+	"cidr-contains":   80 * time.Nanosecond,
+	"ip-eq":           150 * time.Nanosecond,
+	"geo-within":      160 * time.Nanosecond,
+	"glob":            85 * time.Nanosecond,
+	"contains-all":    365 * time.Nanosecond,
+	"contains-any":    510 * time.Nanosecond,
+	"contains-none":   575 * time.Nanosecond,
+	"subset":          365 * time.Nanosecond,
+	"superset":        365 * time.Nanosecond,
+	"intersects":      510 * time.Nanosecond,
+	"len-eq":          9 * time.Nanosecond,
+	"len-gt":          9 * time.Nanosecond,
+	"len-lt":          9 * time.Nanosecond,
+	"strlen-eq":       17 * time.Nanosecond,
+	"strlen-gt":       17 * time.Nanosecond,
+	"strlen-lt":       17 * time.Nanosecond,
+	"levenshtein-lte": 610 * time.Nanosecond,
+	"jarowinkler-gte": 220 * time.Nanosecond,
+	"soundslike":      400 * time.Nanosecond,
+	"fuzzy":           2000 * time.Nanosecond,
+	"divisibleby":     45 * time.Nanosecond,
+	"maskset":         19 * time.Nanosecond,
+	"maskclear":       19 * time.Nanosecond,
+	"keywords":        4100 * time.Nanosecond,
+	"money-eq":        190 * time.Nanosecond,
+	"money-neq":       190 * time.Nanosecond,
+	"money-lt":        190 * time.Nanosecond,
+	"money-lte":       190 * time.Nanosecond,
+	"money-gt":        190 * time.Nanosecond,
+	"money-gte":       190 * time.Nanosecond,
+	"isuuid":          155 * time.Nanosecond,
+	"isuuid-v1":       155 * time.Nanosecond,
+	"isuuid-v2":       155 * time.Nanosecond,
+	"isuuid-v3":       155 * time.Nanosecond,
+	"isuuid-v4":       155 * time.Nanosecond,
+	"isuuid-v5":       155 * time.Nanosecond,
+	"isuuid-v6":       155 * time.Nanosecond,
+	"isuuid-v7":       155 * time.Nanosecond,
+	"isuuid-v8":       155 * time.Nanosecond,
+	"isemail":         230 * time.Nanosecond,
+	"isurl":           610 * time.Nanosecond,
+	"mimetype":        175 * time.Nanosecond,
+	"extension":       65 * time.Nanosecond,
+	"sum-eq":          185 * time.Nanosecond,
+	"sum-neq":         185 * time.Nanosecond,
+	"sum-lt":          185 * time.Nanosecond,
+	"sum-lte":         185 * time.Nanosecond,
+	"sum-gt":          185 * time.Nanosecond,
+	"sum-gte":         185 * time.Nanosecond,
+	"avg-eq":          185 * time.Nanosecond,
+	"avg-neq":         185 * time.Nanosecond,
+	"avg-lt":          185 * time.Nanosecond,
+	"avg-lte":         185 * time.Nanosecond,
+	"avg-gt":          185 * time.Nanosecond,
+	"avg-gte":         185 * time.Nanosecond,
+	"min-eq":          185 * time.Nanosecond,
+	"min-neq":         185 * time.Nanosecond,
+	"min-lt":          185 * time.Nanosecond,
+	"min-lte":         185 * time.Nanosecond,
+	"min-gt":          185 * time.Nanosecond,
+	"min-gte":         185 * time.Nanosecond,
+	"max-eq":          185 * time.Nanosecond,
+	"max-neq":         185 * time.Nanosecond,
+	"max-lt":          185 * time.Nanosecond,
+	"max-lte":         185 * time.Nanosecond,
+	"max-gt":          185 * time.Nanosecond,
+	"max-gte":         185 * time.Nanosecond,
+	"count-eq":        6 * time.Nanosecond,
+	"count-neq":       6 * time.Nanosecond,
+	"count-lt":        6 * time.Nanosecond,
+	"count-lte":       6 * time.Nanosecond,
+	"count-gt":        6 * time.Nanosecond,
+	"count-gte":       6 * time.Nanosecond,
+	"inregion":        180 * time.Nanosecond,
+}
+
+// defaultOperators is a map of all the default operators that
+// a new engine should include
+var defaultOperators = map[string]Operator{
+	OperatorAnd:  and,
+	OperatorOr:   or,
+	OperatorNot:  not,
+	OperatorXor:  xor,
+	OperatorNone: none,
 }
 
 // Rule is a our smallest unit of measure, each rule will be
 // evaluated separately. The comparator is the logical operation to be
 // performed, the path is the path into a map, delimited by '.', and
 // the value is the value that we expect to match the value at the
-// path
+// path, or a reference to another path whose value it should match.
+// The optional OnMissing is what the rule does when either path is
+// missing from the props, one of the Missing constants
 type Rule struct {
 	Comparator string      `json:"comparator"`
 	Path       string      `json:"path"`
 	Value      interface{} `json:"value"`
+	OnMissing  string      `json:"on_missing,omitempty"`
 }
 
+// PathRef is the key of a rule value that refers to another path, so
+// {"$path": "user.credit_limit"} compares against the value at
+// user.credit_limit instead of a fixed value
+const PathRef = "$path"
+
+// ExprRef is the key of a rule value that is an arithmetic expression,
+// so {"$expr": "$order.subtotal * 1.2"} compares against the result of
+// the expression. Paths in the expression start with $
+const ExprRef = "$expr"
+
+const (
+	// MissingFalse makes a rule false when its path is missing. It is
+	// the default
+	MissingFalse = "false"
+	// MissingTrue makes a rule true when its path is missing, for
+	// rules on optional fields that should only apply when present
+	MissingTrue = "true"
+	// MissingSkip leaves a rule out of its composite when its path is
+	// missing, as if the rule was not there
+	MissingSkip = "skip"
+	// MissingError makes the whole evaluation false when a rule's path
+	// is missing, no matter which composite the rule is in, for
+	// required fields. Required paths are checked before any composite
+	// is evaluated, so the result does not depend on which rules run
+	MissingError = "error"
+)
+
 // Composite is a group of rules that are joined by a logical operator
-// AND or OR. If the operator is AND all of the rules must be true,
-// if the operator is OR, one of the rules must be true.
+// AND, OR, NOT or XOR. If the operator is AND all of the rules must be
+// true, if the operator is OR, one of the rules must be true, if the
+// operator is NOT the rules must not all be true, if the operator is
+// XOR exactly one of the rules must be true, if the operator is NONE
+// all of the rules must be false. If the operator is AT
+// LEAST or EXACTLY, at least or exactly N of the rules must be true.
+// Custom operators can be added to the engine with AddOperator. The
+// optional ID identifies the composite for a LiveEngine. The optional
+// Aggregate and Weights say how Score combines the scores of the
+// children, see the Aggregate constants. The optional Webhooks are
+// sent by a Dispatcher when a top level composite is true.
 type Composite struct {
+	ID         string      `json:"id,omitempty"`
 	Operator   string      `json:"operator"`
+	N          int         `json:"n,omitempty"`
 	Rules      []Rule      `json:"rules"`
 	Composites []Composite `json:"composites"`
+	Aggregate  string      `json:"aggregate,omitempty"`
+	Weights    []float64   `json:"weights,omitempty"`
+	Webhooks   []Webhook   `json:"webhooks,omitempty"`
 }
 
 // Engine is a group of composites. All of the composites must be
 // true for the engine's evaluate function to return true.
 type Engine struct {
-	Composites  []Composite `json:"composites"`
-	comparators map[string]Comparator
+	Composites         []Composite `json:"composites"`
+	comparators        map[string]Comparator
+	contextComparators map[string]ContextComparator
+	scoreComparators   map[string]ScoreComparator
+	languagePaths      map[string]bool
+	operators          map[string]Operator
+	costs              map[string]time.Duration
+	profile            *Profile
+	plan               *plan
+	values             []interface{}
+	params             map[string]interface{}
+	pathLanguage       PathLanguage
+	queries            map[string]query
+	strict             bool
+	problem            *RuleError
+	err                error
 }
 
 // NewEngine will create a new engine with the default comparators
 func NewEngine() Engine {
 	e := Engine{
 		comparators: defaultComparators,
+		operators:   defaultOperators,
+		costs:       defaultCosts,
 	}
 	return e
 }
@@ -70,27 +368,248 @@ func NewJSONEngine(raw json.RawMessage) (Engine, error) {
 		return Engine{}, err
 	}
 	e.comparators = defaultComparators
+	e.operators = defaultOperators
+	e.costs = defaultCosts
 	return e, nil
 }
 
+// NewJSONEngineWithFieldNames will create a new engine from a JSON
+// representation that uses alternate field names. names maps each
+// alternate name to the name grules uses, for example
+// {"conditions": "composites", "op": "operator"}. Rule values are
+// left untouched
+func NewJSONEngineWithFieldNames(raw json.RawMessage, names map[string]string) (Engine, error) {
+	var doc map[string]interface{}
+	err := json.Unmarshal(raw, &doc)
+	if err != nil {
+		return Engine{}, err
+	}
+	renameFields(doc, names)
+	raw, err = json.Marshal(doc)
+	if err != nil {
+		return Engine{}, err
+	}
+	return NewJSONEngine(raw)
+}
+
+// renameFields will rename the fields of an engine or composite
+// object, and then the fields of its rules and composites
+func renameFields(obj map[string]interface{}, names map[string]string) {
+	renameKeys(obj, names)
+	if rules, ok := obj["rules"].([]interface{}); ok {
+		for _, r := range rules {
+			if r, ok := r.(map[string]interface{}); ok {
+				renameKeys(r, names)
+			}
+		}
+	}
+	if composites, ok := obj["composites"].([]interface{}); ok {
+		for _, c := range composites {
+			if c, ok := c.(map[string]interface{}); ok {
+				renameFields(c, names)
+			}
+		}
+	}
+}
+
+// renameKeys will rename the keys of obj found in names
+func renameKeys(obj map[string]interface{}, names map[string]string) {
+	for from, to := range names {
+		if v, ok := obj[from]; ok {
+			delete(obj, from)
+			obj[to] = v
+		}
+	}
+}
+
 // AddComparator will add a new comparator that can be used in the
 // engine's evaluation
 func (e Engine) AddComparator(name string, c Comparator) Engine {
-	e.comparators[name] = c
+	e.comparators = e.withComparator(name, c)
+	return e
+}
+
+// withComparator will return a copy of the engine's comparators with c
+// added, so the engines it shares them with, like every engine from
+// NewEngine, are left alone
+func (e Engine) withComparator(name string, c Comparator) map[string]Comparator {
+	comps := map[string]Comparator{name: c}
+	for n, cc := range e.comparators {
+		if n != name {
+			comps[n] = cc
+		}
+	}
+	return comps
+}
+
+// AddComparatorWithCost will add a new comparator along with a hint
+// of how long a single call takes, which Compile uses to run cheap
+// comparators before expensive ones
+func (e Engine) AddComparatorWithCost(name string, c Comparator, cost time.Duration) Engine {
+	e.comparators = e.withComparator(name, c)
+	costs := map[string]time.Duration{name: cost}
+	for n, cc := range e.costs {
+		if n != name {
+			costs[n] = cc
+		}
+	}
+	e.costs = costs
+	return e
+}
+
+// AddVariadicComparator will add a new comparator that takes the
+// elements of the rule's value as separate arguments. arity is the
+// number of arguments the comparator takes, or -1 for any number, and
+// rules whose value is not an array of that many elements are false
+func (e Engine) AddVariadicComparator(name string, arity int, c VariadicComparator) Engine {
+	e.comparators = e.withComparator(name, variadic(c, arity))
+	return e
+}
+
+// AddContextComparator will add a new comparator that is given the
+// whole props and the rule, so it can compare values at more than one
+// path. It takes precedence over a comparator with the same name
+func (e Engine) AddContextComparator(name string, c ContextComparator) Engine {
+	comps := map[string]ContextComparator{name: c}
+	for n, cc := range e.contextComparators {
+		if n != name {
+			comps[n] = cc
+		}
+	}
+	e.contextComparators = comps
 	return e
 }
 
-// Evaluate will ensure all of the composites in the engine are true
+// AddOperator will add a new operator that can be used to join the
+// rules of a composite in the engine's evaluation
+func (e Engine) AddOperator(name string, o Operator) Engine {
+	ops := map[string]Operator{name: o}
+	for n, op := range e.operators {
+		if n != name {
+			ops[n] = op
+		}
+	}
+	e.operators = ops
+	return e
+}
+
+// Evaluate will ensure all of the composites in the engine are true.
+// Skipped composites are ignored
 func (e Engine) Evaluate(props map[string]interface{}) bool {
-	for _, c := range e.Composites {
-		res := c.evaluate(props, e.comparators)
-		if res == false {
+	return e.evaluate(props)
+}
+
+// evaluate will evaluate the engine, keeping the state of the
+// evaluation in e
+func (e *Engine) evaluate(props map[string]interface{}) bool {
+	if !e.prepare(props) {
+		return false
+	}
+	for i, c := range e.Composites {
+		res, skipped := e.checkChild(c, props, "composites", i)
+		if (res == false && !skipped) || e.err != nil {
 			return false
 		}
 	}
 	return true
 }
 
+// EvaluateWithError will evaluate the engine like Evaluate, but when it
+// is false because a rule could not be evaluated, rather than because
+// the rules are false, it will also return a *RuleError for the first
+// such rule. The error wraps ErrUnknownComparator or ErrUnknownOperator
+// if the rule's comparator or its composite's operator has not been
+// added to the engine, ErrPathNotFound if its path is missing and its
+// OnMissing does not say what to do instead, or ErrTypeMismatch if its
+// value can not be compared with the value at its path
+func (e Engine) EvaluateWithError(props map[string]interface{}) (bool, error) {
+	e.strict = true
+	if e.evaluate(props) {
+		return true, nil
+	}
+	if e.problem != nil {
+		return false, e.problem
+	}
+	return false, nil
+}
+
+// prepare will resolve the values of the engine's plan, if it has one,
+// and make sure the path of every rule whose OnMissing is MissingError
+// exists. It returns false, and sets the engine's error, if one does
+// not
+func (e *Engine) prepare(props map[string]interface{}) bool {
+	if e.plan != nil {
+		e.values = e.plan.resolve(props)
+	}
+	for i, c := range e.Composites {
+		node, r, ok := c.missingRequired(props, e)
+		if !ok {
+			continue
+		}
+		e.err = ErrPathNotFound
+		if e.strict && e.problem == nil {
+			e.problem = &RuleError{Node: fmt.Sprintf("composites[%d].%s", i, node), Path: r.Path, Err: ErrPathNotFound}
+		}
+		return false
+	}
+	return true
+}
+
+// checkChild will check a composite that is the ith child of its
+// parent, under the given key. When the engine is strict it adds the
+// child's location to the node of a problem found inside it
+func (e *Engine) checkChild(c Composite, props map[string]interface{}, key string, i int) (bool, bool) {
+	if !e.strict {
+		return c.check(props, e)
+	}
+	before := e.problem
+	res, skipped := c.check(props, e)
+	e.locate(before, key, i, res || skipped)
+	return res, skipped
+}
+
+// locate will add the location of a child to the node of a problem
+// that was found inside the child, which is one that was not there
+// before the child was evaluated. Problems inside a child that was
+// true or skipped did not make the engine false, so they are dropped
+func (e *Engine) locate(before *RuleError, key string, i int, passed bool) {
+	if e.problem == nil || e.problem == before {
+		return
+	}
+	if passed {
+		e.problem = before
+		return
+	}
+	node := fmt.Sprintf("%s[%d]", key, i)
+	if e.problem.Node != "" {
+		node += "." + e.problem.Node
+	}
+	e.problem.Node = node
+}
+
+// fail will record a problem with a rule, when the engine is strict and
+// it is the first problem found
+func (e *Engine) fail(r Rule, err error) {
+	if e.strict && e.problem == nil {
+		e.problem = &RuleError{Path: r.Path, Err: err}
+	}
+}
+
+// Validate will make sure every operator and comparator referenced by
+// the engine has been added to it, that composites are not nested
+// deeper than MaxDepth and that the arithmetic expressions of rule
+// values are valid. The first problem found is returned as a
+// *RuleError identifying the offending node
+func (e Engine) Validate() error {
+	for i, c := range e.Composites {
+		err := c.validate(fmt.Sprintf("composites[%d]", i), 1, &e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Stringify will generate a human readable rule set
 func (e Engine) Stringify() string {
 	parts := []string{}
@@ -101,42 +620,150 @@ func (e Engine) Stringify() string {
 	return strings.Join(parts, " && ")
 }
 
-// Evaluate will ensure all either all of the rules are true, if given
-// the AND operator, or that one of the rules is true if given the OR
-// operator.
-func (c Composite) evaluate(props map[string]interface{}, comps map[string]Comparator) bool {
-	switch c.Operator {
-	case OperatorAnd:
-		for _, r := range c.Rules {
-			res := r.evaluate(props, comps)
-			if res == false {
-				return false
-			}
+// MarshalIndentStable will generate the canonical JSON representation
+// of the engine: object keys are sorted, arrays keep their order, each
+// level is indented by two spaces and the document ends with a newline.
+// The same rules always produce the same bytes, so machine edits to a
+// stored document produce minimal diffs
+func (e Engine) MarshalIndentStable() ([]byte, error) {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode into generic values so every object, including rule
+	// values, is written back with sorted keys
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Evaluate will combine the results of the rules and composites with
+// the composite's operator. If given the AND operator all of the
+// children must be true, if given the OR operator one of the children
+// must be true.
+func (c Composite) evaluate(props map[string]interface{}, e *Engine) bool {
+	res, _ := c.check(props, e)
+	return res
+}
+
+// operator will return the operator of the composite, ok is false if
+// the engine does not have it
+func (c Composite) operator(e *Engine) (Operator, bool) {
+	if q, quantified := quantifiers[c.Operator]; quantified {
+		return q(c.N), true
+	}
+	op, ok := e.operators[c.Operator]
+	return op, ok
+}
+
+// check will return the result of the composite, or skipped if every
+// one of its children was skipped. Skipped children are given to the
+// operator as the result that leaves its result as if they were not
+// there, see skipResult
+func (c Composite) check(props map[string]interface{}, e *Engine) (res bool, skipped bool) {
+	op, ok := c.operator(e)
+	if !ok {
+		if e.strict && e.problem == nil {
+			e.problem = &RuleError{Err: ErrUnknownOperator}
 		}
-		for _, cc := range c.Composites {
-			res := cc.evaluate(props, comps)
-			if res == false {
-				return false
+		return false, false
+	}
+
+	n := len(c.Rules) + len(c.Composites)
+	skips := 0
+	res = op(n, func(i int) bool {
+		var res, skipped bool
+		if i < len(c.Rules) {
+			before := e.problem
+			res, skipped = c.Rules[i].check(props, e)
+			if e.strict {
+				e.locate(before, "rules", i, res || skipped)
 			}
+		} else {
+			res, skipped = e.checkChild(c.Composites[i-len(c.Rules)], props, "composites", i-len(c.Rules))
 		}
-		return true
-	case OperatorOr:
-		for _, r := range c.Rules {
-			res := r.evaluate(props, comps)
-			if res == true {
-				return true
+		if skipped {
+			skips++
+			return skipResult(c.Operator)
+		}
+		return res
+	})
+	skipped = n > 0 && skips == n
+	if !skipped {
+		c.recordMatch(e, res)
+	}
+	return res, skipped
+}
+
+// missingRequired will return the first rule in the composite, or its
+// children, whose OnMissing is MissingError and whose path is missing,
+// along with its node relative to the composite
+func (c Composite) missingRequired(props map[string]interface{}, e *Engine) (string, Rule, bool) {
+	for i, r := range c.Rules {
+		if r.OnMissing == MissingError && !r.present(props, e) {
+			return fmt.Sprintf("rules[%d]", i), r, true
+		}
+	}
+	for i, cc := range c.Composites {
+		if node, r, ok := cc.missingRequired(props, e); ok {
+			return fmt.Sprintf("composites[%d].%s", i, node), r, true
+		}
+	}
+	return "", Rule{}, false
+}
+
+// validate will make sure the composite's operator and aggregate, and
+// those of all of its children along with their comparators, are known
+// and that it is not nested too deeply
+func (c Composite) validate(node string, depth int, e *Engine) error {
+	if depth > MaxDepth {
+		return &RuleError{Node: node, Err: ErrDepthExceeded}
+	}
+	_, ok := e.operators[c.Operator]
+	_, quantified := quantifiers[c.Operator]
+	if !ok && !quantified {
+		return &RuleError{Node: node, Err: ErrUnknownOperator}
+	}
+	if !aggregateNames[c.Aggregate] {
+		return &RuleError{Node: node, Err: ErrUnknownAggregate}
+	}
+	for i, r := range c.Rules {
+		_, ok := e.comparators[r.Comparator]
+		_, contextual := e.contextComparators[r.Comparator]
+		if !ok && !contextual {
+			return &RuleError{Node: fmt.Sprintf("%s.rules[%d]", node, i), Path: r.Path, Err: ErrUnknownComparator}
+		}
+		if expr, ok := valueRef(r.Value, ExprRef); ok {
+			if _, err := parseArithmetic(expr); err != nil {
+				return &RuleError{Node: fmt.Sprintf("%s.rules[%d]", node, i), Path: r.Path, Err: err}
 			}
 		}
-		for _, cc := range c.Composites {
-			res := cc.evaluate(props, comps)
-			if res == true {
-				return true
+		if e.pathLanguage != PathDotted {
+			if _, err := e.query(r.Path); err != nil {
+				return &RuleError{Node: fmt.Sprintf("%s.rules[%d]", node, i), Path: r.Path, Err: err}
 			}
 		}
-		return false
 	}
-
-	return false
+	for i, cc := range c.Composites {
+		err := cc.validate(fmt.Sprintf("%s.composites[%d]", node, i), depth+1, e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Stringify will generate a human readable rule set
@@ -144,29 +771,277 @@ func (c Composite) stringify(comps map[string]Comparator) string {
 	s := "("
 	parts := []string{}
 	for _, r := range c.Rules {
-		parts = append(parts, fmt.Sprintf("{%s %s %v}", r.Path, r.Comparator, r.Value))
+		parts = append(parts, r.stringify())
 	}
 	for _, cc := range c.Composites {
 		parts = append(parts, cc.stringify(comps))
 	}
-	s += strings.Join(parts, " "+c.Operator+" ")
+	if _, quantified := quantifiers[c.Operator]; quantified {
+		s += fmt.Sprintf("%s %d of ", c.Operator, c.N)
+		s += strings.Join(parts, ", ")
+	} else {
+		s += strings.Join(parts, " "+c.Operator+" ")
+	}
 
 	s += ")"
 	return s
 }
 
+// pluck will return the value at path, using the values resolved by
+// the engine's plan if it has one and the languages it detects
+func (e *Engine) pluck(props map[string]interface{}, path string) interface{} {
+	if lang, ok := e.language(props, path); ok {
+		return lang
+	}
+	if e.values != nil {
+		if i, ok := e.plan.index[path]; ok {
+			return e.values[i]
+		}
+	}
+	return pluck(props, path)
+}
+
+// stringify will generate a human readable rule
+func (r Rule) stringify() string {
+	return fmt.Sprintf("{%s %s %s}", r.Path, r.Comparator, formatValue(r.Value))
+}
+
+// formatValue will format a rule's value for Stringify. Map keys are
+// sorted so that the same rules always produce the same string
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + ":" + formatValue(v[k])
+		}
+		return "map[" + strings.Join(parts, " ") + "]"
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, elem := range v {
+			parts[i] = formatValue(elem)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// skipResult will return the result a skipped child has in a
+// composite with the given operator, which is the result that leaves
+// the result of the composite as if the child was not there: true for
+// AND and NOT, false for everything else. AT LEAST and EXACTLY count
+// skipped children as false, custom operators see them as false
+func skipResult(op string) bool {
+	return op == OperatorAnd || op == OperatorNot
+}
+
 // Evaluate will return true if the rule is true, false otherwise
-func (r Rule) evaluate(props map[string]interface{}, comps map[string]Comparator) bool {
+func (r Rule) evaluate(props map[string]interface{}, e *Engine) bool {
+	res, _ := r.check(props, e)
+	return res
+}
+
+// check will return the result of the rule, or skipped if its path is
+// missing and it should be left out of its composite
+func (r Rule) check(props map[string]interface{}, e *Engine) (res bool, skipped bool) {
+	t, skipped := r.truth(props, e)
+	return t == TruthTrue, skipped
+}
+
+// truth will return the result of the rule, which is unknown if its
+// path is missing and it does not say what to do instead, or skipped
+// if it should be left out of its composite
+func (r Rule) truth(props map[string]interface{}, e *Engine) (Truth, bool) {
+	if e.pathLanguage != PathDotted {
+		return r.truthQuery(props, e)
+	}
+	if isWildcard(r.Path) {
+		return r.truthAny(props, pluckAll(props, r.Path), e)
+	}
 	// Make sure we can get a value from the props
-	val := pluck(props, r.Path)
-	if val == nil {
+	return r.compare(props, e.pluck(props, r.Path), e)
+}
+
+// truthQuery will return the result of a rule whose path is in the
+// engine's path language. An invalid expression is treated as missing
+func (r Rule) truthQuery(props map[string]interface{}, e *Engine) (Truth, bool) {
+	p, err := e.query(r.Path)
+	if err != nil {
+		return r.compare(props, nil, e)
+	}
+	values, definite := p.selectValues(props)
+	if !definite {
+		return r.truthAny(props, values, e)
+	}
+	if len(values) == 0 {
+		return r.compare(props, nil, e)
+	}
+	return r.compare(props, values[0], e)
+}
+
+// present will return true if the rule's path, and the path its value
+// refers to, exist in the props. Context comparators are given the
+// value at the path even if it is missing, so their rules are always
+// present
+func (r Rule) present(props map[string]interface{}, e *Engine) bool {
+	if _, ok := e.contextComparators[r.Comparator]; ok {
+		return true
+	}
+	if !presenceComparators[r.Comparator] && !e.exists(props, r.Path) {
 		return false
 	}
+	_, ok := r.value(props, e)
+	return ok
+}
+
+// exists will return true if the path selects a value that is not nil
+func (e *Engine) exists(props map[string]interface{}, path string) bool {
+	for _, v := range e.selectAll(props, path) {
+		if v != nil {
+			return true
+		}
+	}
+	return false
+}
 
-	comp, ok := comps[r.Comparator]
+// selectAll will return the values the path selects in the engine's
+// path language. An invalid expression selects nothing
+func (e *Engine) selectAll(props map[string]interface{}, path string) []interface{} {
+	switch {
+	case e.pathLanguage != PathDotted:
+		p, err := e.query(path)
+		if err != nil {
+			return nil
+		}
+		values, _ := p.selectValues(props)
+		return values
+	case isWildcard(path):
+		return pluckAll(props, path)
+	}
+	return []interface{}{e.pluck(props, path)}
+}
+
+// truthAny will return the result of a rule whose path selects any
+// number of values, which is true if the comparator is true for any of
+// them. Aggregate comparators are given all of the values at once
+func (r Rule) truthAny(props map[string]interface{}, values []interface{}, e *Engine) (Truth, bool) {
+	if len(values) == 0 {
+		return r.compare(props, nil, e)
+	}
+	if isAggregate(r.Comparator) {
+		return r.compare(props, values, e)
+	}
+	for _, v := range values {
+		t, skipped := r.compare(props, v, e)
+		if t != TruthFalse || skipped {
+			return t, skipped
+		}
+	}
+	return TruthFalse, false
+}
+
+// compare will return the result of the rule for the value at its
+// path
+func (r Rule) compare(props map[string]interface{}, val interface{}, e *Engine) (Truth, bool) {
+	if e.profile != nil {
+		e.profile.pluck(r.Path, val)
+	}
+	if cc, ok := e.contextComparators[r.Comparator]; ok {
+		return truthOf(cc(val, r, props)), false
+	}
+	if val == nil && !presenceComparators[r.Comparator] {
+		return r.missing(e)
+	}
+	want, ok := r.value(props, e)
 	if !ok {
-		return false
+		return r.missing(e)
+	}
+
+	comp, ok := e.comparators[r.Comparator]
+	if !ok {
+		e.fail(r, ErrUnknownComparator)
+		return TruthFalse, false
+	}
+
+	var res bool
+	if e.profile != nil {
+		start := time.Now()
+		res = comp(val, want)
+		e.profile.compare(r.Comparator, time.Since(start))
+	} else {
+		res = comp(val, want)
+	}
+	if !res && e.strict && typeMismatch(r.Comparator, val, want) {
+		e.fail(r, ErrTypeMismatch)
+	}
+	return truthOf(res), false
+}
+
+// missing will return the result of the rule when its path, or the
+// path its value refers to, does not exist
+func (r Rule) missing(e *Engine) (Truth, bool) {
+	switch r.OnMissing {
+	case MissingTrue:
+		return TruthTrue, false
+	case MissingFalse:
+		return TruthFalse, false
+	case MissingSkip:
+		return TruthFalse, true
+	case MissingError:
+		e.fail(r, ErrPathNotFound)
+		e.err = ErrPathNotFound
+		return TruthFalse, false
 	}
+	e.fail(r, ErrPathNotFound)
+	return TruthUnknown, false
+}
+
+// value will return the value the rule compares against, with its
+// placeholders replaced by the engine's params, which is the value at
+// another path if the rule's value refers to one, or the result of an
+// arithmetic expression. ok is false if a param or a path it refers to
+// does not exist
+func (r Rule) value(props map[string]interface{}, e *Engine) (interface{}, bool) {
+	v := r.Value
+	if e.params != nil {
+		var ok bool
+		if v, ok = e.resolveParams(v); !ok {
+			return nil, false
+		}
+	}
+	if _, ok := valueRef(v, ExprRef); ok {
+		a, ok := valueArithmetic(v)
+		if !ok {
+			return nil, false
+		}
+		return a.eval(props, e)
+	}
+	path, ok := pathRef(v)
+	if !ok {
+		return v, true
+	}
+	v = e.pluck(props, path)
+	return v, v != nil
+}
 
-	return comp(val, r.Value)
+// pathRef will return the path the value refers to, ok is false if
+// the value is not an object with PathRef as its only key
+func pathRef(v interface{}) (string, bool) {
+	return valueRef(v, PathRef)
+}
+
+// valueRef will return the string under key, ok is false if the value
+// is not an object with key as its only key
+func valueRef(v interface{}, key string) (string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	s, ok := m[key].(string)
+	return s, ok
 }
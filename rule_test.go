@@ -1,12 +1,18 @@
 package grules
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 )
 
 func TestRuleEvaluate(t *testing.T) {
-	comparators := map[string]Comparator{
-		"eq": equal,
+	e := &Engine{
+		comparators: map[string]Comparator{
+			"eq":      equal,
+			"exists":  exists,
+			"nexists": notExists,
+		},
 	}
 	props := map[string]interface{}{
 		"first_name": "Trevor",
@@ -17,7 +23,7 @@ func TestRuleEvaluate(t *testing.T) {
 			Path:       "first_name",
 			Value:      "Trevor",
 		}
-		res := r.evaluate(props, comparators)
+		res := r.evaluate(props, e)
 		if res != true {
 			t.Fatal("expected rule to be true")
 		}
@@ -29,19 +35,38 @@ func TestRuleEvaluate(t *testing.T) {
 			Path:       "email",
 			Value:      "Trevor",
 		}
-		res := r.evaluate(props, comparators)
+		res := r.evaluate(props, e)
 		if res != false {
 			t.Fatal("expected rule to be false")
 		}
 	})
 
+	t.Run("presence", func(t *testing.T) {
+		r := Rule{Comparator: "exists", Path: "first_name"}
+		if r.evaluate(props, e) != true {
+			t.Fatal("expected exists rule to be true")
+		}
+		r = Rule{Comparator: "exists", Path: "email"}
+		if r.evaluate(props, e) != false {
+			t.Fatal("expected exists rule to be false")
+		}
+		r = Rule{Comparator: "nexists", Path: "email"}
+		if r.evaluate(props, e) != true {
+			t.Fatal("expected nexists rule to be true")
+		}
+		r = Rule{Comparator: "nexists", Path: "first_name"}
+		if r.evaluate(props, e) != false {
+			t.Fatal("expected nexists rule to be false")
+		}
+	})
+
 	t.Run("non comparable types", func(t *testing.T) {
 		r := Rule{
 			Comparator: "eq",
 			Path:       "name",
 			Value:      func() {},
 		}
-		res := r.evaluate(props, comparators)
+		res := r.evaluate(props, e)
 		if res != false {
 			t.Fatal("expected rule to be false")
 		}
@@ -53,7 +78,7 @@ func TestRuleEvaluate(t *testing.T) {
 			Path:       "name",
 			Value:      "Trevor",
 		}
-		res := r.evaluate(props, comparators)
+		res := r.evaluate(props, e)
 		if res != false {
 			t.Fatal("expected rule to be false")
 		}
@@ -61,10 +86,13 @@ func TestRuleEvaluate(t *testing.T) {
 }
 
 func TestCompositeEvaluate(t *testing.T) {
-	comparators := map[string]Comparator{
-		"eq": equal,
-		"gt": greaterThan,
-		"lt": lessThan,
+	e := &Engine{
+		comparators: map[string]Comparator{
+			"eq": equal,
+			"gt": greaterThan,
+			"lt": lessThan,
+		},
+		operators: defaultOperators,
 	}
 	props := map[string]interface{}{
 		"name": "Trevor",
@@ -87,7 +115,7 @@ func TestCompositeEvaluate(t *testing.T) {
 				},
 			},
 		}
-		res := c.evaluate(props, comparators)
+		res := c.evaluate(props, e)
 		if res != true {
 			t.Fatal("expected composite to be true")
 		}
@@ -109,7 +137,7 @@ func TestCompositeEvaluate(t *testing.T) {
 				},
 			},
 		}
-		res := c.evaluate(props, comparators)
+		res := c.evaluate(props, e)
 		if res != true {
 			t.Fatal("expected composite to be true")
 		}
@@ -143,7 +171,7 @@ func TestCompositeEvaluate(t *testing.T) {
 				},
 			},
 		}
-		res := c.evaluate(props, comparators)
+		res := c.evaluate(props, e)
 		if res != true {
 			t.Fatal("expected composite to be true")
 		}
@@ -177,7 +205,56 @@ func TestCompositeEvaluate(t *testing.T) {
 				},
 			},
 		}
-		res := c.evaluate(props, comparators)
+		res := c.evaluate(props, e)
+		if res != true {
+			t.Fatal("expected composite to be true")
+		}
+	})
+
+	t.Run("not", func(t *testing.T) {
+		c := Composite{
+			Operator: OperatorNot,
+			Composites: []Composite{
+				Composite{
+					Operator: OperatorOr,
+					Rules: []Rule{
+						Rule{
+							Comparator: "eq",
+							Path:       "name",
+							Value:      "John",
+						},
+						Rule{
+							Comparator: "gt",
+							Path:       "age",
+							Value:      float64(30),
+						},
+					},
+				},
+			},
+		}
+		res := c.evaluate(props, e)
+		if res != true {
+			t.Fatal("expected composite to be true")
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		c := Composite{
+			Operator: OperatorNone,
+			Rules: []Rule{
+				Rule{
+					Comparator: "eq",
+					Path:       "name",
+					Value:      "John",
+				},
+				Rule{
+					Comparator: "gt",
+					Path:       "age",
+					Value:      float64(30),
+				},
+			},
+		}
+		res := c.evaluate(props, e)
 		if res != true {
 			t.Fatal("expected composite to be true")
 		}
@@ -199,7 +276,7 @@ func TestCompositeEvaluate(t *testing.T) {
 				},
 			},
 		}
-		res := c.evaluate(props, comparators)
+		res := c.evaluate(props, e)
 		if res != false {
 			t.Fatal("expected composite to be true")
 		}
@@ -241,6 +318,218 @@ func TestAddComparator(t *testing.T) {
 	}
 }
 
+func TestAddVariadicComparator(t *testing.T) {
+	// between with an exclusive upper bound
+	halfOpen := func(a interface{}, args ...interface{}) bool {
+		return greaterThanEqual(a, args[0]) && lessThan(a, args[1])
+	}
+	e := NewEngine().AddVariadicComparator("between-excl", 2, halfOpen)
+
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"age": float64(30),
+		},
+	}
+	cases := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{value: []interface{}{float64(18), float64(65)}, expected: true},
+		{value: []interface{}{float64(18), float64(30)}, expected: false},
+		{value: []interface{}{float64(18)}, expected: false},
+		{value: []interface{}{float64(18), float64(65), float64(70)}, expected: false},
+		{value: float64(18), expected: false},
+	}
+	for i, c := range cases {
+		e.Composites = []Composite{
+			Composite{
+				Operator: OperatorAnd,
+				Rules: []Rule{
+					Rule{Comparator: "between-excl", Path: "user.age", Value: c.value},
+				},
+			},
+		}
+		res := e.Evaluate(props)
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	counting := NewEngine().AddVariadicComparator("count", -1, func(a interface{}, args ...interface{}) bool {
+		return float64(len(args)) == a
+	})
+	counting.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "count", Path: "user.age", Value: make([]interface{}, 30)},
+			},
+		},
+	}
+	if counting.Evaluate(props) != true {
+		t.Fatal("expected engine to be true")
+	}
+
+	if _, ok := NewEngine().comparators["between-excl"]; ok {
+		t.Fatal("expected other engines not to have the comparator")
+	}
+}
+
+func TestAddContextComparator(t *testing.T) {
+	// eq-path compares the value at the path with the value at the path
+	// in the rule's value
+	eqPath := func(a interface{}, r Rule, props map[string]interface{}) bool {
+		path, ok := r.Value.(string)
+		return ok && a != nil && a == pluck(props, path)
+	}
+	e := NewEngine().AddContextComparator("eq-path", eqPath)
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorNot,
+			Rules: []Rule{
+				Rule{Comparator: "eq-path", Path: "user.password", Value: "user.username"},
+			},
+		},
+	}
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []testCase{
+		testCase{args: []interface{}{"trevor", "hunter2"}, expected: true},
+		testCase{args: []interface{}{"trevor", "trevor"}, expected: false},
+	}
+	for i, c := range cases {
+		props := map[string]interface{}{
+			"user": map[string]interface{}{
+				"username": c.args[0],
+				"password": c.args[1],
+			},
+		}
+		res := e.Evaluate(props)
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	if _, ok := NewEngine().contextComparators["eq-path"]; ok {
+		t.Fatal("expected other engines not to have the comparator")
+	}
+}
+
+func TestAddOperator(t *testing.T) {
+	majority := func(n int, result func(i int) bool) bool {
+		var passed int
+		for i := 0; i < n; i++ {
+			if result(i) {
+				passed++
+			}
+		}
+		return passed*2 > n
+	}
+	e := NewEngine()
+	other := NewEngine()
+	e = e.AddOperator("majority", majority)
+	if e.operators["majority"] == nil {
+		t.Fatal("expected operator to be added under key majority")
+	}
+	if _, ok := other.operators["majority"]; ok {
+		t.Fatal("expected other engines not to have the operator")
+	}
+
+	e.Composites = []Composite{
+		Composite{
+			Operator: "majority",
+			Rules: []Rule{
+				Rule{
+					Comparator: "eq",
+					Path:       "user.name",
+					Value:      "Trevor",
+				},
+				Rule{
+					Comparator: "eq",
+					Path:       "user.age",
+					Value:      float64(23),
+				},
+				Rule{
+					Comparator: "eq",
+					Path:       "user.email",
+					Value:      "test@test.com",
+				},
+			},
+		},
+	}
+
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "Trevor",
+			"age":  float64(23),
+		},
+	}
+
+	res := e.Evaluate(props)
+	if res != true {
+		t.Fatal("expected engine to be true")
+	}
+}
+
+func TestNewJSONEngineXor(t *testing.T) {
+	j := []byte(`{"composites":[{"operator":"xor","rules":[{"comparator":"eq","path":"plan","value":"free"},{"comparator":"eq","path":"trial","value":true}]}]}`)
+	e, err := NewJSONEngine(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		props    map[string]interface{}
+		expected bool
+	}{
+		{props: map[string]interface{}{"plan": "free", "trial": false}, expected: true},
+		{props: map[string]interface{}{"plan": "pro", "trial": true}, expected: true},
+		{props: map[string]interface{}{"plan": "free", "trial": true}, expected: false},
+		{props: map[string]interface{}{"plan": "pro", "trial": false}, expected: false},
+	}
+	for i, c := range cases {
+		res := e.Evaluate(c.props)
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func TestNewJSONEngineQuantifiers(t *testing.T) {
+	j := []byte(`{"composites":[{"operator":"atleast","n":2,"rules":[{"comparator":"eq","path":"a","value":true},{"comparator":"eq","path":"b","value":true}],"composites":[{"operator":"exactly","n":1,"rules":[{"comparator":"eq","path":"c","value":true},{"comparator":"eq","path":"d","value":true}]}]}]}`)
+	e, err := NewJSONEngine(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedStr := "(atleast 2 of {a eq true}, {b eq true}, (exactly 1 of {c eq true}, {d eq true}))"
+	actualStr := e.Stringify()
+	if expectedStr != actualStr {
+		t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+	}
+
+	cases := []struct {
+		props    map[string]interface{}
+		expected bool
+	}{
+		{props: map[string]interface{}{"a": true, "b": true}, expected: true},
+		{props: map[string]interface{}{"a": true, "c": true}, expected: true},
+		{props: map[string]interface{}{"a": true, "c": true, "d": true}, expected: false},
+		{props: map[string]interface{}{"b": true}, expected: false},
+	}
+	for i, c := range cases {
+		res := e.Evaluate(c.props)
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
 func TestNewJSONEngine(t *testing.T) {
 	j := []byte(`{"composites":[{"operator":"and","rules":[{"comparator":"eq","path":"first_name","value":"Trevor"}]}]}`)
 	e, err := NewJSONEngine(j)
@@ -255,6 +544,356 @@ func TestNewJSONEngine(t *testing.T) {
 	}
 }
 
+func TestNewJSONEngineWithFieldNames(t *testing.T) {
+	j := []byte(`{"conditions":[{"op":"or","rules":[{"cmp":"eq","field":"first_name","value":{"op":"x"}}],"conditions":[{"op":"and","rules":[{"cmp":"eq","field":"age","value":23}]}]}]}`)
+	names := map[string]string{
+		"conditions": "composites",
+		"op":         "operator",
+		"cmp":        "comparator",
+		"field":      "path",
+	}
+	e, err := NewJSONEngineWithFieldNames(j, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedStr := "({first_name eq map[op:x]} or ({age eq 23}))"
+	actualStr := e.Stringify()
+	if expectedStr != actualStr {
+		t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+	}
+
+	props := map[string]interface{}{
+		"age": float64(23),
+	}
+	if e.Evaluate(props) != true {
+		t.Fatal("expected engine to pass")
+	}
+}
+
+func TestEngineValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		j := []byte(`{"composites":[{"operator":"and","rules":[{"comparator":"eq","path":"first_name","value":"Trevor"}]}]}`)
+		e, err := NewJSONEngine(j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("unknown comparator", func(t *testing.T) {
+		j := []byte(`{"composites":[{"operator":"and"},{"operator":"or","composites":[{"operator":"and","rules":[{"comparator":"eq","path":"name","value":"Trevor"},{"comparator":"unknown","path":"user.age","value":1}]}]}]}`)
+		e, err := NewJSONEngine(j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = e.Validate()
+		if !errors.Is(err, ErrUnknownComparator) {
+			t.Fatalf("expected unknown comparator, got %v", err)
+		}
+		expected := "composites[1].composites[0].rules[1] (user.age): grules: unknown comparator"
+		if err.Error() != expected {
+			t.Fatalf("expected %s but got %s", expected, err.Error())
+		}
+	})
+
+	t.Run("unknown operator", func(t *testing.T) {
+		j := []byte(`{"composites":[{"operator":"xand"}]}`)
+		e, err := NewJSONEngine(j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = e.Validate()
+		var re *RuleError
+		if !errors.As(err, &re) || re.Node != "composites[0]" || re.Err != ErrUnknownOperator {
+			t.Fatalf("expected unknown operator at composites[0], got %v", err)
+		}
+	})
+}
+
+func TestEngineValidateDepth(t *testing.T) {
+	c := Composite{Operator: OperatorAnd}
+	for i := 0; i < MaxDepth; i++ {
+		c = Composite{
+			Operator:   OperatorAnd,
+			Composites: []Composite{c},
+		}
+	}
+	e := NewEngine()
+	e.Composites = []Composite{c}
+	err := e.Validate()
+	if !errors.Is(err, ErrDepthExceeded) {
+		t.Fatalf("expected depth exceeded, got %v", err)
+	}
+
+	e.Composites = c.Composites
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEngineStable(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{
+					Comparator: "eq",
+					Path:       "user.address",
+					Value: map[string]interface{}{
+						"zip":     "30301",
+						"city":    "Atlanta",
+						"country": "US",
+						"geo": map[string]interface{}{
+							"lon": float64(-84.38),
+							"lat": float64(33.74),
+						},
+						"lines": []interface{}{"b", "a"},
+					},
+				},
+			},
+		},
+	}
+
+	expectedStr := "({user.address eq map[city:Atlanta country:US geo:map[lat:33.74 lon:-84.38] lines:[b a] zip:30301]})"
+	expectedJSON := `{"composites":[{"operator":"and","rules":[{"comparator":"eq","path":"user.address","value":{"city":"Atlanta","country":"US","geo":{"lat":33.74,"lon":-84.38},"lines":["b","a"],"zip":"30301"}}],"composites":null}]}`
+	for i := 0; i < 20; i++ {
+		actualStr := e.Stringify()
+		if expectedStr != actualStr {
+			t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+		}
+		actualJSON, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expectedJSON != string(actualJSON) {
+			t.Fatalf("expected %s but got %s", expectedJSON, actualJSON)
+		}
+	}
+}
+
+func TestMarshalIndentStable(t *testing.T) {
+	j := []byte(`{"composites":[{"rules":[{"value":{"b":1,"a":[3,1,2.50]},"path":"user.tags","comparator":"eq"},{"comparator":"lt","path":"user.name","value":"<b>"}],"operator":"and"}]}`)
+	e, err := NewJSONEngine(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{
+  "composites": [
+    {
+      "composites": null,
+      "operator": "and",
+      "rules": [
+        {
+          "comparator": "eq",
+          "path": "user.tags",
+          "value": {
+            "a": [
+              3,
+              1,
+              2.5
+            ],
+            "b": 1
+          }
+        },
+        {
+          "comparator": "lt",
+          "path": "user.name",
+          "value": "<b>"
+        }
+      ]
+    }
+  ]
+}
+`
+	actual, err := e.MarshalIndentStable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Fatalf("expected %s but got %s", expected, actual)
+	}
+
+	// Loading the output again should give the same bytes
+	e, err = NewJSONEngine(actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := e.MarshalIndentStable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != expected {
+		t.Fatalf("expected %s but got %s", expected, again)
+	}
+}
+
+func TestOnMissing(t *testing.T) {
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"age": float64(30),
+		},
+	}
+	cases := []struct {
+		doc      string
+		expected bool
+	}{
+		{doc: `{"operator":"and","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev"}]}`, expected: false},
+		{doc: `{"operator":"and","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"false"}]}`, expected: false},
+		{doc: `{"operator":"and","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"true"}]}`, expected: true},
+		{doc: `{"operator":"not","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"true"}]}`, expected: false},
+		{doc: `{"operator":"and","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"skip"},{"comparator":"gte","path":"user.age","value":18}]}`, expected: true},
+		{doc: `{"operator":"or","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"skip"},{"comparator":"lt","path":"user.age","value":18}]}`, expected: false},
+		{doc: `{"operator":"not","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"skip"},{"comparator":"lt","path":"user.age","value":18}]}`, expected: true},
+		{doc: `{"operator":"xor","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"skip"},{"comparator":"gte","path":"user.age","value":18}]}`, expected: true},
+		{doc: `{"operator":"or","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"error"},{"comparator":"gte","path":"user.age","value":18}]}`, expected: false},
+		{doc: `{"operator":"not","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"error"}]}`, expected: false},
+		{doc: `{"operator":"and","rules":[{"comparator":"gte","path":"user.age","value":18,"on_missing":"error"}]}`, expected: true},
+		{doc: `{"operator":"and","rules":[{"comparator":"nexists","path":"user.nickname","on_missing":"false"}]}`, expected: true},
+		{doc: `{"operator":"or","rules":[{"comparator":"gte","path":"user.age","value":18},{"comparator":"regex","path":"user.nickname","value":"^T","on_missing":"error"}]}`, expected: false},
+		{doc: `{"operator":"or","composites":[{"operator":"and","rules":[{"comparator":"gte","path":"user.age","value":18}]},{"operator":"and","rules":[{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"error"}]}]}`, expected: false},
+	}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[` + c.doc + `]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := e.Evaluate(props)
+		if res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+		if res := e.Compile().Evaluate(props); res != c.expected {
+			t.Fatalf("expected compiled case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func TestPathRef(t *testing.T) {
+	props := map[string]interface{}{
+		"order": map[string]interface{}{
+			"total": float64(120),
+		},
+		"user": map[string]interface{}{
+			"credit_limit": float64(100),
+			"email":        "trevor@example.com",
+			"contact":      "trevor@example.com",
+		},
+	}
+	cases := []struct {
+		doc      string
+		expected bool
+	}{
+		{doc: `{"operator":"and","rules":[{"comparator":"gt","path":"order.total","value":{"$path":"user.credit_limit"}}]}`, expected: true},
+		{doc: `{"operator":"and","rules":[{"comparator":"lte","path":"order.total","value":{"$path":"user.credit_limit"}}]}`, expected: false},
+		{doc: `{"operator":"and","rules":[{"comparator":"eq","path":"user.email","value":{"$path":"user.contact"}}]}`, expected: true},
+		{doc: `{"operator":"and","rules":[{"comparator":"gt","path":"order.total","value":{"$path":"user.missing"}}]}`, expected: false},
+		{doc: `{"operator":"and","rules":[{"comparator":"gt","path":"order.total","value":{"$path":"user.missing"},"on_missing":"true"}]}`, expected: true},
+		{doc: `{"operator":"and","rules":[{"comparator":"eq","path":"user.email","value":{"$path":"user.contact","other":1}}]}`, expected: false},
+	}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[` + c.doc + `]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := e.Evaluate(props); res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+		if res := e.Compile().Evaluate(props); res != c.expected {
+			t.Fatalf("expected compiled case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func TestWildcardPath(t *testing.T) {
+	props := map[string]interface{}{
+		"order": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"sku": "A1", "price": float64(20)},
+				map[string]interface{}{"sku": "B2", "price": float64(90)},
+			},
+		},
+	}
+	cases := []struct {
+		rule     string
+		expected bool
+	}{
+		{rule: `{"comparator":"eq","path":"order.items.*.sku","value":"B2"}`, expected: true},
+		{rule: `{"comparator":"eq","path":"order.items.*.sku","value":"C3"}`, expected: false},
+		{rule: `{"comparator":"gt","path":"order.items.*.price","value":50}`, expected: true},
+		{rule: `{"comparator":"sum-gt","path":"order.items.*.price","value":100}`, expected: true},
+		{rule: `{"comparator":"count-eq","path":"order.items.*.sku","value":2}`, expected: true},
+		{rule: `{"comparator":"exists","path":"order.items.*.sku"}`, expected: true},
+		{rule: `{"comparator":"exists","path":"order.items.*.color"}`, expected: false},
+		{rule: `{"comparator":"nexists","path":"order.items.*.color"}`, expected: true},
+		{rule: `{"comparator":"eq","path":"order.items.*.color","value":"red"}`, expected: false},
+		{rule: `{"comparator":"eq","path":"order.items.*.color","value":"red","on_missing":"true"}`, expected: true},
+	}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[` + c.rule + `]}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := e.Evaluate(props); res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+		if res := e.Compile().Evaluate(props); res != c.expected {
+			t.Fatalf("expected compiled case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+}
+
+func TestSkip(t *testing.T) {
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"age": float64(30),
+		},
+	}
+	skip := `{"comparator":"eq","path":"user.nickname","value":"Trev","on_missing":"skip"}`
+	adult := `{"comparator":"gte","path":"user.age","value":18}`
+	child := `{"comparator":"lt","path":"user.age","value":18}`
+	cases := []struct {
+		doc      string
+		expected bool
+		skipped  bool
+	}{
+		{doc: `{"operator":"and","rules":[` + skip + `,` + skip + `]}`, expected: true, skipped: true},
+		{doc: `{"operator":"or","rules":[` + skip + `]}`, expected: false, skipped: true},
+		{doc: `{"operator":"not","rules":[` + skip + `]}`, expected: false, skipped: true},
+		{doc: `{"operator":"and","rules":[` + skip + `,` + child + `]}`, expected: false, skipped: false},
+		{doc: `{"operator":"or","rules":[` + child + `],"composites":[{"operator":"not","rules":[` + skip + `]}]}`, expected: false, skipped: false},
+		{doc: `{"operator":"and","rules":[` + adult + `],"composites":[{"operator":"not","rules":[` + skip + `]}]}`, expected: true, skipped: false},
+		{doc: `{"operator":"atleast","n":1,"rules":[` + skip + `,` + adult + `]}`, expected: true, skipped: false},
+		{doc: `{"operator":"and"}`, expected: true, skipped: false},
+	}
+	for i, c := range cases {
+		var composite Composite
+		if err := json.Unmarshal([]byte(c.doc), &composite); err != nil {
+			t.Fatal(err)
+		}
+		e := NewEngine()
+		res, skipped := composite.check(props, &e)
+		if res != c.expected || skipped != c.skipped {
+			t.Fatalf("expected case %d to be %v skipped %v, got %v skipped %v", i, c.expected, c.skipped, res, skipped)
+		}
+	}
+
+	// Skipped composites are ignored by the engine
+	e, err := NewJSONEngine([]byte(`{"composites":[
+		{"operator":"not","rules":[` + skip + `]},
+		{"operator":"and","rules":[` + adult + `]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Evaluate(props) != true {
+		t.Fatal("expected engine to pass")
+	}
+}
+
 func TestEngineEvaluate(t *testing.T) {
 	t.Run("no composites", func(t *testing.T) {
 		props := map[string]interface{}{
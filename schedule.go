@@ -0,0 +1,291 @@
+package grules
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schedule says when a scheduled job runs
+type Schedule interface {
+	// Next will return the first time the job runs after t
+	Next(t time.Time) time.Time
+}
+
+// cronSchedule is a cron expression. Each field is a bit set of the
+// values it matches
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// anyDay is true if the day of the month or the day of the week
+	// is *, which makes a day match only if both fields match
+	anyDay bool
+}
+
+// everySchedule runs every interval
+type everySchedule time.Duration
+
+// cronFields is the range of each field of a cron expression
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// cronNames are the names that can be used for months and days of the
+// week
+var cronNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// cronMacros are the named schedules
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule will parse a cron expression with five fields, minute,
+// hour, day of month, month and day of week, which can be *, numbers,
+// names like jan or mon, ranges like 1-5, lists like 1,15 and steps
+// like */5. @hourly, @daily, @weekly, @monthly and @yearly are also
+// supported, as is @every followed by a duration like @every 5m.
+// Errors wrap ErrSyntax
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: invalid interval in %q", ErrSyntax, spec)
+		}
+		return everySchedule(d), nil
+	}
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("%w: expected %d fields in %q", ErrSyntax, len(cronFields), spec)
+	}
+	sets := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s in %q", ErrSyntax, cronFields[i].name, spec)
+		}
+		sets[i] = set
+	}
+	// Sunday is both 0 and 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &cronSchedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		anyDay: fields[2] == "*" || fields[4] == "*",
+	}, nil
+}
+
+// parseCronField will return the bit set of the values a field
+// matches
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, ErrSyntax
+			}
+			step = n
+			part = part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = parseCronValue(bounds[0]); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = parseCronValue(bounds[1]); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, ErrSyntax
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// parseCronValue will parse a number or a name
+func parseCronValue(s string) (int, error) {
+	if n, ok := cronNames[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// Next will return the first minute after t that matches the schedule,
+// in t's location, or the zero time if there is none within 5 years
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, t.Location())
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay will return true if the day of t matches the schedule. As
+// in cron, when both day fields are restricted either can match
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next will return t plus the interval
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// PropsProvider returns the props a scheduled job evaluates its engine
+// against, such as one for every account
+type PropsProvider func(ctx context.Context) ([]map[string]interface{}, error)
+
+// Scheduler evaluates engines against the props from a provider on a
+// schedule, dispatching the actions of the composites that are true
+// with a Dispatcher. OnError is called with the name of a job whose
+// provider failed
+type Scheduler struct {
+	dispatcher *Dispatcher
+	onError    func(job string, err error)
+	jobs       []scheduledJob
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// scheduledJob is an engine evaluated on a schedule
+type scheduledJob struct {
+	name     string
+	schedule Schedule
+	engine   Engine
+	props    PropsProvider
+}
+
+// NewScheduler will create a new scheduler that dispatches with d. The
+// optional onError is called when a job's provider fails
+func NewScheduler(d *Dispatcher, onError func(job string, err error)) *Scheduler {
+	return &Scheduler{dispatcher: d, onError: onError}
+}
+
+// Add will add a job that evaluates the engine on the schedule, see
+// ParseSchedule. Jobs must be added before Start
+func (s *Scheduler) Add(name, spec string, e Engine, props PropsProvider) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	s.AddSchedule(name, schedule, e, props)
+	return nil
+}
+
+// AddSchedule will add a job that evaluates the engine on a custom
+// schedule. Jobs must be added before Start
+func (s *Scheduler) AddSchedule(name string, schedule Schedule, e Engine, props PropsProvider) {
+	s.jobs = append(s.jobs, scheduledJob{name: name, schedule: schedule, engine: e, props: props})
+}
+
+// Start will start running the jobs. A job does not run again until
+// its last run has finished, runs it misses in the meantime are
+// skipped
+func (s *Scheduler) Start() {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.wg.Add(len(s.jobs))
+	for _, job := range s.jobs {
+		go s.loop(job)
+	}
+}
+
+// Stop will stop running the jobs and wait for the runs in progress to
+// finish. The dispatcher is not closed
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// loop will run the job on its schedule until the scheduler is stopped
+func (s *Scheduler) loop(job scheduledJob) {
+	defer s.wg.Done()
+	next := job.schedule.Next(time.Now())
+	for !next.IsZero() {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.run(job)
+		next = job.schedule.Next(time.Now())
+	}
+}
+
+// run will evaluate the job's engine against every props from its
+// provider
+func (s *Scheduler) run(job scheduledJob) {
+	props, err := job.props(s.ctx)
+	if err != nil {
+		if s.onError != nil {
+			s.onError(job.name, err)
+		}
+		return
+	}
+	for _, p := range props {
+		s.dispatcher.Dispatch(job.engine, p)
+	}
+}
@@ -0,0 +1,124 @@
+package grules
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 10, 7, 30, 0, time.UTC)
+	cases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{spec: "* * * * *", expected: time.Date(2024, time.January, 31, 10, 8, 0, 0, time.UTC)},
+		{spec: "*/5 * * * *", expected: time.Date(2024, time.January, 31, 10, 10, 0, 0, time.UTC)},
+		{spec: "0 9-17 * * mon-fri", expected: time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{spec: "30 2 * * *", expected: time.Date(2024, time.February, 1, 2, 30, 0, 0, time.UTC)},
+		{spec: "0 0 29 feb *", expected: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 31 * *", expected: time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1,15 * 7", expected: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * sun", expected: time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC)},
+		{spec: "5/20 10 * * *", expected: time.Date(2024, time.January, 31, 10, 25, 0, 0, time.UTC)},
+		{spec: "@hourly", expected: time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", expected: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@every 90s", expected: time.Date(2024, time.January, 31, 10, 9, 0, 0, time.UTC)},
+		{spec: "0 0 30 feb *", expected: time.Time{}},
+	}
+	for i, c := range cases {
+		s, err := ParseSchedule(c.spec)
+		if err != nil {
+			t.Fatalf("expected case %d to parse, got %v", i, err)
+		}
+		if res := s.Next(from); !res.Equal(c.expected) {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	// Minutes and hours step in local time, even when the offset is not
+	// a whole number of hours
+	kolkata := time.FixedZone("IST", 5*60*60+30*60)
+	s, err := ParseSchedule("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := time.Date(2024, time.January, 31, 9, 0, 0, 0, kolkata)
+	if res := s.Next(time.Date(2024, time.January, 31, 8, 0, 0, 0, kolkata)); !res.Equal(expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *", "@every", "@every -1m", "@often"} {
+		if _, err := ParseSchedule(spec); !errors.Is(err, ErrSyntax) {
+			t.Fatalf("expected %q to be a syntax error, got %v", spec, err)
+		}
+	}
+}
+
+func BenchmarkParseSchedule(b *testing.B) {
+	from := time.Date(2024, time.January, 31, 10, 7, 30, 0, time.UTC)
+	for i := 0; i < b.N; i++ {
+		s, _ := ParseSchedule("0 9-17 * * mon-fri")
+		s.Next(from)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{ID: "breach", Operator: OperatorAnd, Rules: []Rule{Rule{Comparator: "gt", Path: "balance", Value: float64(100)}}},
+	}
+	metrics := new(expvar.Map).Init()
+	d := NewDispatcher(DispatcherConfig{Actions: map[string][]Action{"breach": []Action{MetricAction(metrics)}}})
+
+	var mu sync.Mutex
+	runs := 0
+	failed := []string{}
+	s := NewScheduler(d, func(job string, err error) {
+		mu.Lock()
+		failed = append(failed, job)
+		mu.Unlock()
+	})
+	if err := s.Add("accounts", "@every 10ms", e, func(ctx context.Context) ([]map[string]interface{}, error) {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		return []map[string]interface{}{
+			map[string]interface{}{"balance": float64(50)},
+			map[string]interface{}{"balance": float64(150)},
+		}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("broken", "@every 10ms", e, func(ctx context.Context) ([]map[string]interface{}, error) {
+		return nil, errors.New("unavailable")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("invalid", "every 10ms", e, nil); !errors.Is(err, ErrSyntax) {
+		t.Fatalf("expected an invalid schedule to be a syntax error, got %v", err)
+	}
+
+	NewScheduler(d, nil).Stop()
+
+	s.Start()
+	time.Sleep(55 * time.Millisecond)
+	s.Stop()
+	d.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if runs < 2 {
+		t.Fatalf("expected the job to run at least twice, got %d", runs)
+	}
+	if v := metrics.Get("breach"); v == nil || v.String() != strconv.Itoa(runs) {
+		t.Fatalf("expected a breach for every run, got %v for %d runs", v, runs)
+	}
+	if len(failed) < 2 || failed[0] != "broken" {
+		t.Fatalf("expected the broken job to fail, got %v", failed)
+	}
+}
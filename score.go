@@ -0,0 +1,203 @@
+package grules
+
+import (
+	"math"
+)
+
+// ScoreComparator is a comparator that returns how well a matches b as
+// a score between 0 and 1, rather than true or false, for example a
+// fuzzy string match or the output of a model
+type ScoreComparator func(a, b interface{}) float64
+
+// ScoreThreshold is the score at which a rule with a score comparator
+// is true when the engine is evaluated with Evaluate
+var ScoreThreshold = 0.5
+
+const (
+	// AggregateMin scores a composite with the lowest score of its
+	// children, the default for AND
+	AggregateMin = "min"
+	// AggregateMax scores a composite with the highest score of its
+	// children, the default for OR
+	AggregateMax = "max"
+	// AggregateProduct scores a composite with the product of the
+	// scores of its children
+	AggregateProduct = "product"
+	// AggregateWeighted scores a composite with the weighted average of
+	// the scores of its children, using the composite's Weights in the
+	// order of its rules and then its composites. Missing weights are 1
+	AggregateWeighted = "weighted"
+)
+
+// aggregateNames is the set of names a composite's Aggregate can have,
+// where empty is the default of its operator
+var aggregateNames = map[string]bool{
+	"":                true,
+	AggregateMin:      true,
+	AggregateMax:      true,
+	AggregateProduct:  true,
+	AggregateWeighted: true,
+}
+
+// AddScoreComparator will add a new comparator that returns a score.
+// Score uses the score itself, and Evaluate treats the rule as true
+// when the score is at least ScoreThreshold
+func (e Engine) AddScoreComparator(name string, c ScoreComparator) Engine {
+	e.comparators = e.withComparator(name, func(a, b interface{}) bool {
+		return c(a, b) >= ScoreThreshold
+	})
+	comps := map[string]ScoreComparator{name: c}
+	for n, sc := range e.scoreComparators {
+		if n != name {
+			comps[n] = sc
+		}
+	}
+	e.scoreComparators = comps
+	return e
+}
+
+// Score will return the score of the engine, between 0 and 1, along
+// with whether it reaches the threshold. Rules with a score comparator
+// are scored with it, other rules score 1 if they are true and 0 if
+// they are false. Composites combine the scores of their children with
+// their Aggregate, or if it is empty: AND and NOT take the lowest
+// score, OR and NONE the highest, and other operators score 1 or 0 by
+// their result, counting children that score at least
+// ScoreThreshold as true and skipped children like Evaluate does. NOT
+// and NONE score the opposite of their aggregate. The engine takes the
+// lowest score of its composites, and skipped composites are ignored
+func (e Engine) Score(props map[string]interface{}, threshold float64) (float64, bool) {
+	if !e.prepare(props) {
+		return 0, false
+	}
+	score := 1.0
+	for _, c := range e.Composites {
+		s, skipped := c.score(props, &e)
+		if e.err != nil {
+			return 0, false
+		}
+		if !skipped {
+			score = math.Min(score, s)
+		}
+	}
+	return score, score >= threshold
+}
+
+// score will return the score of the composite, or skipped if every
+// one of its children was skipped
+func (c Composite) score(props map[string]interface{}, e *Engine) (float64, bool) {
+	scores := []float64{}
+	weights := []float64{}
+	// truths is the result of every child for operators without an
+	// aggregate, with skipped children given to them like in Evaluate
+	truths := []bool{}
+	add := func(i int, s float64, skipped bool) {
+		if skipped {
+			truths = append(truths, skipResult(c.Operator))
+			return
+		}
+		truths = append(truths, s >= ScoreThreshold)
+		w := 1.0
+		if i < len(c.Weights) {
+			w = c.Weights[i]
+		}
+		scores = append(scores, s)
+		weights = append(weights, w)
+	}
+	for i, r := range c.Rules {
+		s, skipped := r.score(props, e)
+		add(i, s, skipped)
+	}
+	for i, cc := range c.Composites {
+		s, skipped := cc.score(props, e)
+		add(len(c.Rules)+i, s, skipped)
+	}
+	if len(scores) == 0 && len(c.Rules)+len(c.Composites) > 0 {
+		return 0, true
+	}
+
+	aggregate := c.Aggregate
+	negate := c.Operator == OperatorNot || c.Operator == OperatorNone
+	if aggregate == "" {
+		switch c.Operator {
+		case OperatorAnd, OperatorNot:
+			aggregate = AggregateMin
+		case OperatorOr, OperatorNone:
+			aggregate = AggregateMax
+		default:
+			op, ok := c.operator(e)
+			if !ok {
+				return 0, false
+			}
+			res := op(len(truths), func(i int) bool { return truths[i] })
+			c.recordMatch(e, res)
+			return boolScore(res), false
+		}
+	}
+
+	var score float64
+	switch aggregate {
+	case AggregateMin:
+		score = 1
+		for _, s := range scores {
+			score = math.Min(score, s)
+		}
+	case AggregateMax:
+		for _, s := range scores {
+			score = math.Max(score, s)
+		}
+	case AggregateProduct:
+		score = 1
+		for _, s := range scores {
+			score *= s
+		}
+	case AggregateWeighted:
+		total := 0.0
+		for i, s := range scores {
+			score += s * weights[i]
+			total += weights[i]
+		}
+		if total > 0 {
+			score /= total
+		}
+	}
+	if negate {
+		score = 1 - score
+	}
+	c.recordMatch(e, score >= ScoreThreshold)
+	return score, false
+}
+
+// score will return the score of the rule, or skipped if it should be
+// left out of its composite. A path that selects more than one value
+// scores the highest of them, like a rule is true if it is true for
+// any of them
+func (r Rule) score(props map[string]interface{}, e *Engine) (float64, bool) {
+	sc, ok := e.scoreComparators[r.Comparator]
+	if _, contextual := e.contextComparators[r.Comparator]; !ok || contextual {
+		res, skipped := r.check(props, e)
+		return boolScore(res), skipped
+	}
+	want, ok := r.value(props, e)
+	score := -1.0
+	if ok {
+		for _, val := range e.selectAll(props, r.Path) {
+			if val != nil {
+				score = math.Max(score, sc(val, want))
+			}
+		}
+	}
+	if score < 0 {
+		res, skipped := r.check(props, e)
+		return boolScore(res), skipped
+	}
+	return math.Min(1, score), false
+}
+
+// boolScore will return 1 for true and 0 for false
+func boolScore(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,150 @@
+package grules
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	// similarity is the fraction of b's words that are in a
+	similarity := func(a, b interface{}) float64 {
+		sa, _ := a.(string)
+		sb, _ := b.(string)
+		words := strings.Fields(sb)
+		found := 0
+		for _, w := range words {
+			if strings.Contains(sa, w) {
+				found++
+			}
+		}
+		return float64(found) / float64(len(words))
+	}
+	e := NewEngine().AddScoreComparator("similar", similarity)
+
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "trevor smith",
+			"age":  float64(30),
+		},
+	}
+	half := `{"comparator":"similar","path":"user.name","value":"trevor jones"}`
+	full := `{"comparator":"similar","path":"user.name","value":"trevor smith"}`
+	none := `{"comparator":"similar","path":"user.name","value":"john jones"}`
+	adult := `{"comparator":"gte","path":"user.age","value":18}`
+	cases := []struct {
+		doc      string
+		expected float64
+	}{
+		{doc: `{"operator":"and","rules":[` + half + `,` + adult + `]}`, expected: 0.5},
+		{doc: `{"operator":"or","rules":[` + half + `,` + none + `]}`, expected: 0.5},
+		{doc: `{"operator":"not","rules":[` + half + `,` + full + `]}`, expected: 0.5},
+		{doc: `{"operator":"none","rules":[` + none + `,` + full + `]}`, expected: 0},
+		{doc: `{"operator":"and","aggregate":"product","rules":[` + half + `,` + half + `]}`, expected: 0.25},
+		{doc: `{"operator":"and","aggregate":"weighted","weights":[3,1],"rules":[` + full + `,` + none + `]}`, expected: 0.75},
+		{doc: `{"operator":"and","aggregate":"weighted","weights":[1],"rules":[` + full + `,` + none + `]}`, expected: 0.5},
+		{doc: `{"operator":"not","aggregate":"product","rules":[` + full + `,` + half + `,` + half + `]}`, expected: 0.75},
+		{doc: `{"operator":"none","aggregate":"weighted","weights":[3,1],"rules":[` + full + `,` + none + `]}`, expected: 0.25},
+		{doc: `{"operator":"xor","rules":[` + full + `,` + none + `]}`, expected: 1},
+		{doc: `{"operator":"atleast","n":2,"rules":[` + half + `,` + none + `]}`, expected: 0},
+		{doc: `{"operator":"and","composites":[{"operator":"or","rules":[` + none + `,` + half + `]},{"operator":"and","rules":[` + full + `]}]}`, expected: 0.5},
+		{doc: `{"operator":"and","rules":[` + full + `,{"comparator":"similar","path":"user.nickname","value":"trev","on_missing":"skip"}]}`, expected: 1},
+	}
+	for i, c := range cases {
+		engine, err := NewJSONEngine([]byte(`{"composites":[` + c.doc + `]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Composites = engine.Composites
+		res, _ := e.Score(props, 0.5)
+		if math.Abs(res-c.expected) > 1e-9 {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	// Compile moves weights along with the children it reorders
+	engine, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","aggregate":"weighted","weights":[0.9,0.1],"rules":[{"comparator":"regex","path":"user.name","value":"^trevor"},{"comparator":"eq","path":"user.age","value":40}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := engine.Score(props, 0.5)
+	after, _ := engine.Compile().Score(props, 0.5)
+	if math.Abs(before-0.9) > 1e-9 || math.Abs(after-before) > 1e-9 {
+		t.Fatalf("expected a score of 0.9 before and after Compile, got %v and %v", before, after)
+	}
+
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "similar", Path: "user.name", Value: "trevor jones"},
+			},
+		},
+	}
+	if score, ok := e.Score(props, 0.6); score != 0.5 || ok {
+		t.Fatalf("expected a score of 0.5 below the threshold, got %v %v", score, ok)
+	}
+	if e.Evaluate(props) != true {
+		t.Fatal("expected a score of 0.5 to be true in Evaluate")
+	}
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := NewEngine().comparators["similar"]; ok {
+		t.Fatal("expected other engines not to have the comparator")
+	}
+
+	// Custom operators see skipped children like in Evaluate
+	all := func(n int, result func(i int) bool) bool {
+		for i := 0; i < n; i++ {
+			if !result(i) {
+				return false
+			}
+		}
+		return true
+	}
+	engine, err = NewJSONEngine([]byte(`{"composites":[{"operator":"all","rules":[` + full + `,{"comparator":"similar","path":"user.nickname","value":"trev","on_missing":"skip"}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine = engine.AddScoreComparator("similar", similarity).AddOperator("all", all)
+	if score, _ := engine.Score(props, 0.5); score != boolScore(engine.Evaluate(props)) {
+		t.Fatalf("expected a skipped child of a custom operator to score like in Evaluate, got %v", score)
+	}
+
+	e.Composites[0].Aggregate = "avg"
+	if err := e.Validate(); !errors.Is(err, ErrUnknownAggregate) {
+		t.Fatalf("expected an unknown aggregate to be invalid, got %v", err)
+	}
+
+	// Paths are resolved in the engine's path language, and a path
+	// that selects more than one value scores the highest of them
+	props["user"].(map[string]interface{})["nicknames"] = []interface{}{"john jones", "trevor jones"}
+	paths := []struct {
+		lang  PathLanguage
+		path  string
+		value string
+	}{
+		{lang: PathDotted, path: "user.nicknames.*", value: "trevor smith"},
+		{lang: PathJSONPath, path: "$.user.name", value: "trevor jones"},
+		{lang: PathJSONPath, path: "$.user.nicknames[*]", value: "trevor smith"},
+		{lang: PathJMESPath, path: "user.name", value: "trevor jones"},
+		{lang: PathJMESPath, path: "user.nicknames[*]", value: "trevor smith"},
+	}
+	for i, p := range paths {
+		e.Composites = []Composite{
+			Composite{
+				Operator: OperatorAnd,
+				Rules:    []Rule{Rule{Comparator: "similar", Path: p.path, Value: p.value}},
+			},
+		}
+		engine, err := e.WithPathLanguage(p.lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score, _ := engine.Score(props, 0.5); score != 0.5 {
+			t.Fatalf("expected case %d to be %v, got %v", i, 0.5, score)
+		}
+	}
+}
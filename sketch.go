@@ -0,0 +1,125 @@
+package grules
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sort"
+)
+
+// sketchPrecision is the number of bits of a hash used to pick a
+// register of the cardinality estimate, giving 1024 registers and a
+// standard error of about 3%
+const sketchPrecision = 10
+
+// ValueStats summarizes the values seen at a path by a sampling
+// profile. Count is how many values were seen, Distinct is an estimate
+// of how many of them were different and Numbers is a uniform random
+// sample of the numeric values, sorted, for use with Percentile
+type ValueStats struct {
+	Path     string
+	Count    int
+	Distinct int
+	Numbers  []float64
+}
+
+// Percentile will estimate the value below which q of the numeric
+// values fall, where q is between 0 and 1. ok is false if no numeric
+// values were seen
+func (s ValueStats) Percentile(q float64) (float64, bool) {
+	if len(s.Numbers) == 0 {
+		return 0, false
+	}
+	if q <= 0 {
+		return s.Numbers[0], true
+	}
+	if q >= 1 {
+		return s.Numbers[len(s.Numbers)-1], true
+	}
+	// Interpolate between the two closest ranks
+	rank := q * float64(len(s.Numbers)-1)
+	lo := int(rank)
+	frac := rank - float64(lo)
+	return s.Numbers[lo] + frac*(s.Numbers[lo+1]-s.Numbers[lo]), true
+}
+
+// sketch samples the values seen at a single path. Numbers are kept
+// in a reservoir so every number seen has the same chance of being in
+// the sample, and distinct values are counted with a HyperLogLog
+type sketch struct {
+	count     int
+	numbers   int
+	reservoir []float64
+	registers [1 << sketchPrecision]uint8
+}
+
+// add will record a value, keeping at most size numbers in the
+// reservoir
+func (s *sketch) add(v interface{}, size int, r *rand.Rand) {
+	s.count++
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%T:%v", v, v)
+	sum := mix64(h.Sum64())
+	i := sum >> (64 - sketchPrecision)
+	rho := uint8(bits.LeadingZeros64(sum<<sketchPrecision|1<<(sketchPrecision-1)) + 1)
+	if rho > s.registers[i] {
+		s.registers[i] = rho
+	}
+
+	f, ok := toFloat64(v)
+	if !ok {
+		return
+	}
+	s.numbers++
+	if len(s.reservoir) < size {
+		s.reservoir = append(s.reservoir, f)
+	} else if j := r.Intn(s.numbers); j < size {
+		s.reservoir[j] = f
+	}
+}
+
+// mix64 will spread the bits of an FNV hash, whose high bits are too
+// alike for similar short inputs, using the finalizer of MurmurHash3
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// distinct will estimate the number of distinct values seen
+func (s *sketch) distinct() int {
+	m := float64(len(s.registers))
+	sum, zeros := 0.0, 0
+	for _, reg := range s.registers {
+		sum += math.Pow(2, -float64(reg))
+		if reg == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	// Small cardinalities are better estimated by the number of empty
+	// registers
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(estimate))
+}
+
+// stats will summarize the sketch
+func (s *sketch) stats(path string) ValueStats {
+	numbers := append([]float64(nil), s.reservoir...)
+	sort.Float64s(numbers)
+	return ValueStats{
+		Path:     path,
+		Count:    s.count,
+		Distinct: s.distinct(),
+		Numbers:  numbers,
+	}
+}
@@ -0,0 +1,79 @@
+package grules
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSamplingProfile(t *testing.T) {
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules: []Rule{
+				Rule{Comparator: "gte", Path: "user.age", Value: float64(18)},
+				Rule{Comparator: "eq", Path: "user.country", Value: "US"},
+			},
+		},
+	}
+	countries := []string{"US", "CA", "MX"}
+
+	p := NewSamplingProfile(100)
+	p.rand = rand.New(rand.NewSource(1))
+	profiled := e.WithProfile(p)
+	for i := 0; i < 1000; i++ {
+		profiled.Evaluate(map[string]interface{}{
+			"user": map[string]interface{}{
+				"age":     float64(18 + i%50),
+				"country": countries[i%len(countries)],
+			},
+		})
+	}
+
+	r := p.Report()
+	if len(r.Values) != 2 {
+		t.Fatalf("expected values at 2 paths, got %v", r.Values)
+	}
+
+	age := r.Values[0]
+	if age.Path != "user.age" || age.Count != 1000 || len(age.Numbers) != 100 {
+		t.Fatalf("expected 1000 ages with a sample of 100, got %s %d %d", age.Path, age.Count, len(age.Numbers))
+	}
+	if age.Distinct < 45 || age.Distinct > 55 {
+		t.Fatalf("expected about 50 distinct ages, got %d", age.Distinct)
+	}
+	median, ok := age.Percentile(0.5)
+	if !ok || median < 33 || median > 53 {
+		t.Fatalf("expected a median age of about 43, got %v", median)
+	}
+	if min, _ := age.Percentile(0); min < 18 {
+		t.Fatalf("expected a minimum age of at least 18, got %v", min)
+	}
+	if max, _ := age.Percentile(1); max > 67 {
+		t.Fatalf("expected a maximum age of at most 67, got %v", max)
+	}
+
+	country := r.Values[1]
+	if country.Path != "user.country" || country.Count != 1000 || country.Distinct != 3 {
+		t.Fatalf("expected 1000 countries with 3 distinct, got %s %d %d", country.Path, country.Count, country.Distinct)
+	}
+	if _, ok := country.Percentile(0.5); ok {
+		t.Fatal("expected no percentiles for strings")
+	}
+
+	if len(NewProfile().Report().Values) != 0 {
+		t.Fatal("expected a profile without sampling to have no values")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	s := ValueStats{Numbers: []float64{1, 2, 3, 4, 5}}
+	cases := map[float64]float64{0: 1, 0.25: 2, 0.5: 3, 0.6: 3.4, 1: 5}
+	for q, expected := range cases {
+		res, ok := s.Percentile(q)
+		if !ok || math.Abs(res-expected) > 1e-9 {
+			t.Fatalf("expected percentile %v to be %v, got %v", q, expected, res)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package grules
+
+import (
+	"fmt"
+)
+
+// spelOperators is a map of the relational operators that can be used
+// in a SpEL expression to the comparator each one becomes
+var spelOperators = map[string]string{
+	"==": "eq",
+	"eq": "eq",
+	"!=": "neq",
+	"ne": "neq",
+	"<":  "lt",
+	"lt": "lt",
+	"<=": "lte",
+	"le": "lte",
+	">":  "gt",
+	"gt": "gt",
+	">=": "gte",
+	"ge": "gte",
+}
+
+// spelFlipped is a map of comparators to the comparator that gives the
+// same result when the arguments are swapped, used when the literal is
+// on the left of the operator
+var spelFlipped = map[string]string{
+	"eq":  "eq",
+	"neq": "neq",
+	"lt":  "gt",
+	"lte": "gte",
+	"gt":  "lt",
+	"gte": "lte",
+}
+
+// spelOperand is one side of a relational operator, either a path
+// into the props or a literal value
+type spelOperand struct {
+	path  string
+	value interface{}
+}
+
+// NewSpELEngine will create a new engine from a condition written in
+// the subset of SpEL/MVEL that rule systems commonly export, for
+// example
+//
+//	user.age >= 18 && (user.address?.country == 'US' or user['tier'] eq "gold")
+//
+// Relational operators, and, or, not and parentheses are supported.
+// Properties can be navigated with ".", "?." and ['name'], and a
+// property on its own is true when it is equal to true
+func NewSpELEngine(expr string) (Engine, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return Engine{}, err
+	}
+	n, err := p.spelOr()
+	if err != nil {
+		return Engine{}, err
+	}
+	if err := p.done(); err != nil {
+		return Engine{}, err
+	}
+	return n.engine(), nil
+}
+
+// spelOr will parse conditions joined by or
+func (p *parser) spelOr() (node, error) {
+	n, err := p.spelAnd()
+	if err != nil {
+		return node{}, err
+	}
+	for p.accept("or") || p.accept("||") {
+		right, err := p.spelAnd()
+		if err != nil {
+			return node{}, err
+		}
+		n = join(OperatorOr, n, right)
+	}
+	return n, nil
+}
+
+// spelAnd will parse conditions joined by and
+func (p *parser) spelAnd() (node, error) {
+	n, err := p.spelCondition()
+	if err != nil {
+		return node{}, err
+	}
+	for p.accept("and") || p.accept("&&") {
+		right, err := p.spelCondition()
+		if err != nil {
+			return node{}, err
+		}
+		n = join(OperatorAnd, n, right)
+	}
+	return n, nil
+}
+
+// spelCondition will parse a single comparison, a negated condition
+// or a condition in parentheses
+func (p *parser) spelCondition() (node, error) {
+	if p.accept("!") || p.accept("not") {
+		n, err := p.spelCondition()
+		if err != nil {
+			return node{}, err
+		}
+		return join(OperatorNot, n), nil
+	}
+	if p.accept("(") {
+		n, err := p.spelOr()
+		if err != nil {
+			return node{}, err
+		}
+		return n, p.expect(")")
+	}
+
+	left, err := p.spelOperand()
+	if err != nil {
+		return node{}, err
+	}
+
+	op := p.peek()
+	comparator, ok := spelOperators[op.text]
+	if !ok || op.kind == tokenString || op.kind == tokenNumber {
+		if left.path == "" {
+			return node{}, p.unexpected()
+		}
+		return node{rule: &Rule{Comparator: "eq", Path: left.path, Value: true}}, nil
+	}
+	p.next()
+
+	right, err := p.spelOperand()
+	if err != nil {
+		return node{}, err
+	}
+
+	switch {
+	case left.path != "" && right.path == "":
+		return node{rule: &Rule{Comparator: comparator, Path: left.path, Value: right.value}}, nil
+	case left.path == "" && right.path != "":
+		return node{rule: &Rule{Comparator: spelFlipped[comparator], Path: right.path, Value: left.value}}, nil
+	}
+	return node{}, fmt.Errorf("%w at %d: expected a property and a literal", ErrSyntax, op.pos)
+}
+
+// spelOperand will parse a property or a literal
+func (p *parser) spelOperand() (spelOperand, error) {
+	t := p.peek()
+	if t.kind != tokenIdent || t.text == "true" || t.text == "false" || t.text == "null" {
+		v, err := p.literal()
+		return spelOperand{value: v}, err
+	}
+	p.next()
+
+	path := t.text
+	for {
+		switch {
+		case p.accept("?."):
+			t := p.peek()
+			if t.kind != tokenIdent {
+				return spelOperand{}, p.unexpected()
+			}
+			p.next()
+			path += "." + t.text
+		case p.accept("["):
+			t := p.peek()
+			if t.kind != tokenString {
+				return spelOperand{}, p.unexpected()
+			}
+			p.next()
+			if err := p.expect("]"); err != nil {
+				return spelOperand{}, err
+			}
+			path += "." + t.text
+		default:
+			return spelOperand{path: path}, nil
+		}
+	}
+}
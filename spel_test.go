@@ -0,0 +1,85 @@
+package grules
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewSpELEngine(t *testing.T) {
+	props := map[string]interface{}{
+		"user": map[string]interface{}{
+			"age":    float64(25),
+			"tier":   "gold",
+			"active": true,
+			"address": map[string]interface{}{
+				"country": "US",
+			},
+		},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		e, err := NewSpELEngine(`user.age >= 18 && (user.address?.country == 'US' or user['tier'] eq "silver") and user.active`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedStr := "({user.age gte 18} and {user.active eq true} and ({user.address.country eq US} or {user.tier eq silver}))"
+		actualStr := e.Stringify()
+		if expectedStr != actualStr {
+			t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+		}
+		if e.Evaluate(props) != true {
+			t.Fatal("expected engine to pass")
+		}
+	})
+
+	t.Run("literal on the left", func(t *testing.T) {
+		e, err := NewSpELEngine(`30 < user.age || 'gold' != user.tier`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedStr := "({user.age gt 30} or {user.tier neq gold})"
+		actualStr := e.Stringify()
+		if expectedStr != actualStr {
+			t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+		}
+		if e.Evaluate(props) != false {
+			t.Fatal("expected engine to fail")
+		}
+	})
+
+	t.Run("not", func(t *testing.T) {
+		e, err := NewSpELEngine(`!(user.age < 18 or user.tier == 'gold') && not user.active`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedStr := "((({user.age lt 18} or {user.tier eq gold})) and ({user.active eq true}))"
+		actualStr := e.Stringify()
+		if expectedStr != actualStr {
+			t.Fatalf("expected %s but got %s", expectedStr, actualStr)
+		}
+		if e.Evaluate(props) != false {
+			t.Fatal("expected engine to fail")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		exprs := []string{
+			`user.age >=`,
+			`user.age == user.tier`,
+			`18 == 18`,
+			`user.age > 18 &&`,
+			`(user.age > 18`,
+			`user[tier] == 'gold'`,
+			`user?.'tier' == 'gold'`,
+		}
+		for i, expr := range exprs {
+			_, err := NewSpELEngine(expr)
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("expected case %d to be a syntax error, got %v", i, err)
+			}
+		}
+	})
+}
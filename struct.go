@@ -0,0 +1,332 @@
+package grules
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// EvaluateStruct will evaluate the engine against a struct, or a
+// pointer to one, like Evaluate, resolving paths against its exported
+// fields instead of a map. Fields are named by their grules tag, or
+// their json tag, so the same paths work for the struct and for its
+// JSON decoded into a map. Names are matched exactly, then case
+// insensitively, so "user.age" is the Age field of the User field, and
+// the fields of untagged embedded structs are promoted. Pointers are followed
+// and maps with string keys can be part of a path. Numbers are given to
+// comparators as float64, byte slices and values with a MarshalText
+// method, like time.Time, as strings, slices as []interface{} and
+// structs and maps as map[string]interface{} keyed by field name.
+// Engines with JSONPath or JMESPath paths, or rules with context
+// comparators, are given the whole struct converted this way, with a
+// pointer that leads back to a value it is inside of converted to nil.
+// Anything that is not a struct is false
+func (e Engine) EvaluateStruct(v interface{}) bool {
+	props, ok := e.propsFromStruct(v)
+	if !ok {
+		return false
+	}
+	return e.Evaluate(props)
+}
+
+// propsFromStruct will return the props of a struct that the engine's
+// rules reference
+func (e *Engine) propsFromStruct(v interface{}) (map[string]interface{}, bool) {
+	rv, ok := indirect(reflect.ValueOf(v))
+	if !ok || rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+	tree, whole := e.pathTree()
+	if whole {
+		props, _ := propValue(rv).(map[string]interface{})
+		return props, true
+	}
+	return structProps(rv, tree), true
+}
+
+// structProps will return the values at the paths in the tree
+func structProps(v reflect.Value, tree *pathTree) map[string]interface{} {
+	props := map[string]interface{}{}
+	for key, child := range tree.children {
+		if fv, ok := lookupField(v, key); ok {
+			if val, ok := treeValue(fv, child); ok {
+				props[key] = val
+			}
+		}
+	}
+	return props
+}
+
+// treeValue will return the values at the paths in the tree below v,
+// or false if there are none
+func treeValue(v reflect.Value, tree *pathTree) (interface{}, bool) {
+	if tree.all {
+		return propValue(v), true
+	}
+	v, ok := indirect(v)
+	if !ok {
+		return nil, false
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map:
+		return structProps(v, tree), true
+	case reflect.Slice, reflect.Array:
+		elem := tree.children["*"]
+		if elem == nil {
+			return nil, false
+		}
+		values := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if val, ok := treeValue(v.Index(i), elem); ok {
+				values = append(values, val)
+			}
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// indirect will follow pointers and interfaces, returning false if one
+// is nil
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, v.IsValid()
+}
+
+// lookupField will return the field of a struct, or the value of a map,
+// with the key
+func lookupField(v reflect.Value, key string) (reflect.Value, bool) {
+	v, ok := indirect(v)
+	if !ok {
+		return v, false
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		f, ok := fields.byName[key]
+		if !ok {
+			for _, sf := range fields.list {
+				if strings.EqualFold(sf.name, key) {
+					f, ok = sf, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return v, false
+		}
+		return fieldByIndex(v, f.index)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v, false
+		}
+		mv := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+		return mv, mv.IsValid()
+	}
+	return v, false
+}
+
+// fieldByIndex will return a nested field, returning false instead of
+// panicking if an embedded pointer is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, n := range index {
+		if i > 0 {
+			var ok bool
+			if v, ok = indirect(v); !ok {
+				return v, false
+			}
+		}
+		v = v.Field(n)
+	}
+	return v, true
+}
+
+// structField is a field of a struct, which may be promoted from an
+// embedded struct
+type structField struct {
+	name  string
+	index []int
+}
+
+// fieldSet is the fields of a struct type, in order and by name
+type fieldSet struct {
+	list   []structField
+	byName map[string]structField
+}
+
+// fieldSets is a cache of the fields of struct types, keyed by the type
+var fieldSets sync.Map
+
+// embeddedStruct is a struct type embedded at the index
+type embeddedStruct struct {
+	index []int
+	typ   reflect.Type
+}
+
+// structFields will return the exported fields of a struct type,
+// named by fieldName. A field of an untagged embedded struct is
+// promoted unless a field with the same name is less deeply nested
+func structFields(t reflect.Type) *fieldSet {
+	if fs, ok := fieldSets.Load(t); ok {
+		return fs.(*fieldSet)
+	}
+	fs := &fieldSet{byName: map[string]structField{}}
+	visited := map[reflect.Type]bool{}
+	current := []embeddedStruct{{typ: t}}
+	for len(current) > 0 {
+		next := []embeddedStruct{}
+		names := map[string]bool{}
+		for _, parent := range current {
+			if visited[parent.typ] {
+				continue
+			}
+			visited[parent.typ] = true
+			for i := 0; i < parent.typ.NumField(); i++ {
+				sf := parent.typ.Field(i)
+				index := append(append([]int{}, parent.index...), i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				name, tagged := fieldName(sf)
+				if name == "-" {
+					continue
+				}
+				if sf.Anonymous && !tagged && ft.Kind() == reflect.Struct {
+					next = append(next, embeddedStruct{index: index, typ: ft})
+					continue
+				}
+				exported := sf.PkgPath == "" || sf.Anonymous && ft.Kind() == reflect.Struct
+				if _, ok := fs.byName[name]; ok || !exported || names[name] {
+					continue
+				}
+				names[name] = true
+				fs.list = append(fs.list, structField{name: name, index: index})
+			}
+		}
+		for _, f := range fs.list {
+			fs.byName[f.name] = f
+		}
+		current = next
+	}
+	fieldSets.Store(t, fs)
+	return fs
+}
+
+// fieldName will return the name of a field in paths, which is the
+// name in its grules or json tag, and whether it was tagged. A name of
+// - leaves the field out
+func fieldName(sf reflect.StructField) (string, bool) {
+	for _, key := range []string{"grules", "json"} {
+		tag, ok := sf.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		if name := strings.Split(tag, ",")[0]; name != "" {
+			return name, true
+		}
+	}
+	return sf.Name, false
+}
+
+// textMarshaler is the type of encoding.TextMarshaler
+var textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// visit is a pointer, map or slice that propValue is inside of
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// propValue will convert a value to the types of decoded JSON. A value
+// that refers back to one it is inside of, like a Parent pointer that
+// leads to itself, is converted to nil there
+func propValue(v reflect.Value) interface{} {
+	return convertValue(v, map[visit]bool{})
+}
+
+// convertValue will convert a value like propValue, where seen is the
+// pointers, maps and slices it is inside of
+func convertValue(v reflect.Value, seen map[visit]bool) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Ptr {
+			if !enter(v, seen) {
+				return nil
+			}
+			defer delete(seen, visit{v.Pointer(), v.Type()})
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.Len() > 0 {
+		if !enter(v, seen) {
+			return nil
+		}
+		defer delete(seen, visit{v.Pointer(), v.Type()})
+	}
+	if v.Type().Implements(textMarshaler) {
+		if text, err := v.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			return string(v.Bytes())
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = convertValue(v.Index(i), seen)
+		}
+		return values
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = convertValue(iter.Value(), seen)
+		}
+		return m
+	case reflect.Struct:
+		m := map[string]interface{}{}
+		for _, f := range structFields(v.Type()).list {
+			if fv, ok := fieldByIndex(v, f.index); ok {
+				m[f.name] = convertValue(fv, seen)
+			}
+		}
+		return m
+	}
+	return nil
+}
+
+// enter will add the pointer, map or slice to seen, returning false if
+// it is already there
+func enter(v reflect.Value, seen map[visit]bool) bool {
+	key := visit{v.Pointer(), v.Type()}
+	if seen[key] {
+		return false
+	}
+	seen[key] = true
+	return true
+}
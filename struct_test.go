@@ -0,0 +1,212 @@
+package grules
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type testAudit struct {
+	Created time.Time
+	Note    string
+}
+
+type testAddress struct {
+	City    string
+	Country string
+}
+
+type testUser struct {
+	testAudit
+	*testAddress
+	Name   string
+	Age    int
+	Tags   []string
+	Limits map[string]float64
+	Orders []testOrder
+	Parent *testUser
+	secret string
+}
+
+type testOrder struct {
+	SKU   string
+	Total uint
+}
+
+func TestEvaluateStruct(t *testing.T) {
+	created := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+	user := testUser{
+		testAudit:   testAudit{Created: created, Note: "new"},
+		testAddress: &testAddress{City: "Wellington", Country: "NZ"},
+		Name:        "Ann",
+		Age:         30,
+		Tags:        []string{"vip"},
+		Limits:      map[string]float64{"daily": 500},
+		Orders:      []testOrder{testOrder{SKU: "a", Total: 10}, testOrder{SKU: "b", Total: 200}},
+		secret:      "hidden",
+	}
+
+	cases := []struct {
+		rule     string
+		value    interface{}
+		expected bool
+	}{
+		{rule: `{"comparator":"eq","path":"Name","value":"Ann"}`, value: user, expected: true},
+		{rule: `{"comparator":"gte","path":"age","value":18}`, value: &user, expected: true},
+		{rule: `{"comparator":"eq","path":"country","value":"NZ"}`, value: user, expected: true},
+		{rule: `{"comparator":"eq","path":"note","value":"new"}`, value: user, expected: true},
+		{rule: `{"comparator":"eq","path":"created","value":"2024-01-02T03:04:05Z"}`, value: user, expected: true},
+		{rule: `{"comparator":"contains","path":"tags","value":"vip"}`, value: user, expected: true},
+		{rule: `{"comparator":"lte","path":"limits.daily","value":500}`, value: user, expected: true},
+		{rule: `{"comparator":"eq","path":"orders.*.sku","value":"b"}`, value: user, expected: true},
+		{rule: `{"comparator":"max-gt","path":"orders.*.total","value":100}`, value: user, expected: true},
+		{rule: `{"comparator":"lt","path":"age","value":{"$expr":"$limits.daily"}}`, value: user, expected: true},
+		{rule: `{"comparator":"exists","path":"secret"}`, value: user, expected: false},
+		{rule: `{"comparator":"exists","path":"parent.name"}`, value: user, expected: false},
+		{rule: `{"comparator":"eq","path":"parent.city","value":"Wellington"}`, value: testUser{Parent: &user}, expected: true},
+		{rule: `{"comparator":"exists","path":"city"}`, value: testUser{}, expected: false},
+		{rule: `{"comparator":"exists","path":"name"}`, value: map[string]interface{}{"name": "Ann"}, expected: false},
+		{rule: `{"comparator":"exists","path":"name"}`, value: (*testUser)(nil), expected: false},
+	}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[` + c.rule + `]}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := e.EvaluateStruct(c.value); res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[{"comparator":"eq","path":"Orders[?Total > ` + "`100`" + `].SKU | [0]","value":"b"}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err = e.WithPathLanguage(PathJMESPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.EvaluateStruct(user) {
+		t.Fatal("expected JMESPath paths to be resolved against the whole struct")
+	}
+
+	// A value that leads back to itself is cut off where it does
+	cyclic := &testUser{Name: "Ann", Limits: map[string]float64{}}
+	cyclic.Parent = cyclic
+	cases = []struct {
+		rule     string
+		value    interface{}
+		expected bool
+	}{
+		{rule: `{"comparator":"eq","path":"parent.parent.name","value":"Ann"}`, value: cyclic, expected: true},
+		{rule: `{"comparator":"exists","path":"parent"}`, value: cyclic, expected: true},
+	}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[` + c.rule + `]}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := e.EvaluateStruct(c.value); res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+	}
+	e, err = NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[{"comparator":"eq","path":"$.Parent.Name","value":"Ann"}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err = e.WithPathLanguage(PathJSONPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.EvaluateStruct(cyclic) {
+		t.Fatal("expected a cyclic struct to be converted")
+	}
+}
+
+func BenchmarkEvaluateStruct(b *testing.B) {
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[
+		{"comparator":"gte","path":"age","value":18},
+		{"comparator":"eq","path":"country","value":"NZ"}
+	]}]}`))
+	if err != nil {
+		b.Fatal(err)
+	}
+	user := testUser{testAddress: &testAddress{Country: "NZ"}, Age: 30, Tags: []string{"a", "b"}}
+	for i := 0; i < b.N; i++ {
+		e.EvaluateStruct(user)
+	}
+}
+
+type testEvent struct {
+	testAddress `json:"address"`
+	ID          string            `json:"id"`
+	Kind        string            `json:"type,omitempty" grules:"kind"`
+	Score       float64           `json:"score_value"`
+	Password    string            `json:"-"`
+	Items       []testItem        `json:"items"`
+	Meta        map[string]string `json:"meta"`
+}
+
+type testItem struct {
+	Price float64 `json:"price"`
+}
+
+func TestStructTags(t *testing.T) {
+	event := testEvent{
+		testAddress: testAddress{City: "Wellington"},
+		ID:          "e1",
+		Kind:        "click",
+		Score:       0.75,
+		Password:    "secret",
+		Items:       []testItem{testItem{Price: 5}, testItem{Price: 50}},
+		Meta:        map[string]string{"source": "web"},
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var props map[string]interface{}
+	if err := json.Unmarshal(data, &props); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		rule     string
+		expected bool
+	}{
+		{rule: `{"comparator":"eq","path":"id","value":"e1"}`, expected: true},
+		{rule: `{"comparator":"gt","path":"score_value","value":0.5}`, expected: true},
+		{rule: `{"comparator":"eq","path":"address.City","value":"Wellington"}`, expected: true},
+		{rule: `{"comparator":"gt","path":"items.*.price","value":10}`, expected: true},
+		{rule: `{"comparator":"eq","path":"meta.source","value":"web"}`, expected: true},
+		{rule: `{"comparator":"exists","path":"Password"}`, expected: false},
+		{rule: `{"comparator":"exists","path":"City"}`, expected: false},
+	}
+	for i, c := range cases {
+		e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[` + c.rule + `]}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := e.EvaluateStruct(event); res != c.expected {
+			t.Fatalf("expected case %d to be %v, got %v", i, c.expected, res)
+		}
+		if res := e.Evaluate(props); res != c.expected {
+			t.Fatalf("expected case %d to be %v for the decoded JSON, got %v", i, c.expected, res)
+		}
+	}
+
+	e, err := NewJSONEngine([]byte(`{"composites":[{"operator":"and","rules":[
+		{"comparator":"eq","path":"kind","value":"click"},
+		{"comparator":"eq","path":"items[?price > ` + "`10`" + `].price | [0]","value":50}
+	]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err = e.WithPathLanguage(PathJMESPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.EvaluateStruct(event) {
+		t.Fatal("expected the grules tag to name the field for JMESPath paths")
+	}
+}
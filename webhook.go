@@ -0,0 +1,129 @@
+package grules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Webhook is an HTTP request a Dispatcher sends when the composite it
+// belongs to is true. Method defaults to POST. Payload is a
+// text/template given the Result, which is sent as JSON when it is
+// empty. Nothing the template writes is escaped, so values should be
+// written with its json function, as in {"total": {{json .Props.total}}}
+type Webhook struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Payload string            `json:"payload,omitempty"`
+	client  *http.Client
+}
+
+// Result is a top level composite that was true, along with the props
+// it was true for
+type Result struct {
+	Composite Composite              `json:"composite"`
+	Props     map[string]interface{} `json:"props"`
+}
+
+// errWebhookStatus is returned when a webhook gets a response that is
+// not a success
+var errWebhookStatus = errors.New("grules: webhook failed")
+
+// payloads is a cache of parsed payload templates, keyed by the
+// template
+var payloads sync.Map
+
+// request will build the HTTP request for the webhook
+func (w Webhook) request(ctx context.Context, r Result) (*http.Request, error) {
+	var body bytes.Buffer
+	if w.Payload == "" {
+		if err := json.NewEncoder(&body).Encode(r); err != nil {
+			return nil, err
+		}
+	} else {
+		t, err := parsePayload(w.Payload)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(&body, r); err != nil {
+			return nil, err
+		}
+	}
+
+	method := w.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(strings.ToUpper(method), w.URL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// payloadFuncs are the functions payload templates can use
+var payloadFuncs = template.FuncMap{
+	"json": payloadJSON,
+}
+
+// payloadJSON will return v as JSON, so strings are quoted and escaped
+func payloadJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// parsePayload will return the parsed payload template
+func parsePayload(payload string) (*template.Template, error) {
+	if t, ok := payloads.Load(payload); ok {
+		return t.(*template.Template), nil
+	}
+	t, err := template.New("payload").Option("missingkey=zero").Funcs(payloadFuncs).Parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	payloads.Store(payload, t)
+	return t, nil
+}
+
+// defaultClient is the client webhooks are sent with when they are not
+// given one
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// Execute will send the webhook once. Errors are Permanent unless the
+// request could not be sent, or the response was a 5xx or 429
+func (w Webhook) Execute(ctx context.Context, r Result) error {
+	req, err := w.request(ctx, r)
+	if err != nil {
+		return Permanent(err)
+	}
+	client := w.client
+	if client == nil {
+		client = defaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%w: %s %s: %s", errWebhookStatus, req.Method, w.URL, res.Status)
+	if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return Permanent(err)
+}
@@ -0,0 +1,136 @@
+package grules
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcher(t *testing.T) {
+	var mu sync.Mutex
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, r.Method+" "+r.Header.Get("X-Tenant")+" "+string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	e, err := NewJSONEngine([]byte(`{"composites":[
+		{"id":"big-order","operator":"and","rules":[{"comparator":"gt","path":"order.total","value":100}],
+		 "webhooks":[{"url":"` + server.URL + `","method":"put","headers":{"X-Tenant":"acme"},"payload":"{\"id\":{{json .Composite.ID}},\"note\":{{json .Props.order.note}}}"}]},
+		{"id":"vip","operator":"and","rules":[{"comparator":"eq","path":"user.vip","value":true}],
+		 "webhooks":[{"url":"` + server.URL + `"}]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDispatcher(DispatcherConfig{Workers: 2})
+	props := map[string]interface{}{
+		"order": map[string]interface{}{"total": float64(150), "note": `say "hi"`},
+		"user":  map[string]interface{}{"vip": false},
+	}
+	if d.Dispatch(e, props) {
+		t.Fatal("expected the engine to fail")
+	}
+	d.Close()
+
+	if len(bodies) != 1 || bodies[0] != `PUT acme {"id":"big-order","note":"say \"hi\""}` {
+		t.Fatalf("expected only the big-order webhook, got %q", bodies)
+	}
+}
+
+func TestDispatcherDefaultPayload(t *testing.T) {
+	results := make(chan Result, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var res Result
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&res) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		results <- res
+	}))
+	defer server.Close()
+
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			ID:       "adult",
+			Operator: OperatorAnd,
+			Rules:    []Rule{Rule{Comparator: "gte", Path: "age", Value: float64(18)}},
+			Webhooks: []Webhook{Webhook{URL: server.URL}},
+		},
+	}
+	d := NewDispatcher(DispatcherConfig{})
+	if !d.Dispatch(e, map[string]interface{}{"age": float64(30)}) {
+		t.Fatal("expected the engine to pass")
+	}
+	d.Close()
+
+	res := <-results
+	if res.Composite.ID != "adult" || res.Props["age"] != float64(30) {
+		t.Fatalf("expected the result as JSON, got %+v", res)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts[r.URL.Path]++
+		n := attempts[r.URL.Path]
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/flaky":
+			if n < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/down":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/bad":
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	e := NewEngine()
+	e.Composites = []Composite{
+		Composite{
+			Operator: OperatorAnd,
+			Rules:    []Rule{Rule{Comparator: "exists", Path: "id"}},
+			Webhooks: []Webhook{
+				Webhook{URL: server.URL + "/flaky"},
+				Webhook{URL: server.URL + "/down"},
+				Webhook{URL: server.URL + "/bad"},
+			},
+		},
+	}
+
+	failed := []string{}
+	d := NewDispatcher(DispatcherConfig{
+		Retries: 2,
+		Backoff: time.Millisecond,
+		OnError: func(a Action, r Result, err error) {
+			failed = append(failed, a.(Webhook).URL[len(server.URL):])
+		},
+	})
+	d.Dispatch(e, map[string]interface{}{"id": "1"})
+	d.Close()
+
+	expected := map[string]int{"/flaky": 3, "/down": 3, "/bad": 1}
+	for path, n := range expected {
+		if attempts[path] != n {
+			t.Fatalf("expected %s to be sent %d times, got %d", path, n, attempts[path])
+		}
+	}
+	if len(failed) != 2 || failed[0] != "/down" || failed[1] != "/bad" {
+		t.Fatalf("expected /down and /bad to fail, got %v", failed)
+	}
+}